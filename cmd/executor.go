@@ -10,6 +10,7 @@ import (
 	migratecmd "github.com/forbole/juno/v4/cmd/migrate"
 	parsecmd "github.com/forbole/juno/v4/cmd/parse"
 	startcmd "github.com/forbole/juno/v4/cmd/start"
+	statuscmd "github.com/forbole/juno/v4/cmd/status"
 	"github.com/forbole/juno/v4/types"
 	"github.com/forbole/juno/v4/types/config"
 	"github.com/spf13/cobra"
@@ -42,6 +43,7 @@ func BuildDefaultExecutor(config *Config) cli.Executor {
 		parsecmd.NewParseCmd(config.GetParseConfig()),
 		startcmd.NewStartCmd(config.GetParseConfig()),
 		migratecmd.NewMigrateCmd(config.GetName(), config.GetParseConfig()),
+		statuscmd.NewStatusCmd(config.GetParseConfig()),
 	)
 
 	return PrepareRootCmd(config.GetName(), rootCmd)
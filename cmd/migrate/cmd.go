@@ -29,7 +29,7 @@ func getVersions() []string {
 
 // NewMigrateCmd returns the Cobra command allowing to migrate config and tables to v3 version
 func NewMigrateCmd(appName string, parseConfig *parsecmdtypes.Config) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "migrate [to-version]",
 		Short: "Perform the migrations from the current version to the specified one",
 		Long: `Migrates all the necessary things (config file, database, etc) from the current version to the new one.
@@ -56,4 +56,8 @@ Note that migrations must be performed in order: to migrate from vX to vX+2 you
 			return migrator(parseConfig)
 		},
 	}
+
+	cmd.AddCommand(NewMigrateDBCmd(appName, parseConfig))
+
+	return cmd
 }
@@ -0,0 +1,119 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	parsecmdtypes "github.com/forbole/juno/v4/cmd/parse/types"
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/types/config"
+)
+
+// NewMigrateDBCmd returns the Cobra command allowing to inspect and run the versioned database schema migrations
+func NewMigrateDBCmd(appName string, parseConfig *parsecmdtypes.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Inspect, apply or revert the versioned database schema migrations",
+		Long: `Manages the versioned migrations of the database schema, recorded in the schema_migrations table.
+Pending migrations are also applied by the start command, which refuses to run against a schema migrated by a newer version.
+`,
+		Example: fmt.Sprintf("%s migrate db status", appName),
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:     "status",
+			Short:   "List the schema migrations and whether they are applied",
+			PreRunE: parsecmdtypes.ReadConfigPreRunE(parseConfig),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				db, err := parsecmdtypes.GetDatabase(config.Cfg, parseConfig)
+				if err != nil {
+					return err
+				}
+
+				statuses, err := db.GetMigrationStatus(context.Background())
+				if err != nil {
+					return err
+				}
+
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "VERSION\tSTATUS\tAPPLIED AT\tDESCRIPTION")
+				for _, s := range statuses {
+					status, appliedAt := "pending", ""
+					if s.Applied {
+						status, appliedAt = "applied", time.Unix(s.AppliedAt, 0).UTC().Format(time.RFC3339)
+					}
+					if s.Unknown {
+						status = "unknown"
+					}
+					fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, status, appliedAt, s.Description)
+				}
+				return w.Flush()
+			},
+		},
+		&cobra.Command{
+			Use:     "up [steps]",
+			Short:   "Apply the pending schema migrations, or only the first steps ones",
+			Args:    cobra.RangeArgs(0, 1),
+			PreRunE: parsecmdtypes.ReadConfigPreRunE(parseConfig),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runMigrations(cmd, parseConfig, args, 0, func(db database.Database, steps int) ([]database.Migration, error) {
+					return db.MigrateUp(context.Background(), steps)
+				})
+			},
+		},
+		&cobra.Command{
+			Use:     "down [steps]",
+			Short:   "Revert the last applied schema migration, or the last steps ones",
+			Args:    cobra.RangeArgs(0, 1),
+			PreRunE: parsecmdtypes.ReadConfigPreRunE(parseConfig),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runMigrations(cmd, parseConfig, args, 1, func(db database.Database, steps int) ([]database.Migration, error) {
+					return db.MigrateDown(context.Background(), steps)
+				})
+			},
+		},
+	)
+
+	return cmd
+}
+
+// runMigrations runs the given migration function with the number of steps passed as argument, or defaultSteps
+func runMigrations(
+	cmd *cobra.Command, parseConfig *parsecmdtypes.Config, args []string, defaultSteps int,
+	migrate func(db database.Database, steps int) ([]database.Migration, error),
+) error {
+	cmd.SetOut(os.Stdout)
+
+	steps := defaultSteps
+	if len(args) == 1 {
+		value, err := strconv.Atoi(args[0])
+		if err != nil || value <= 0 {
+			return fmt.Errorf("invalid number of steps: %s", args[0])
+		}
+		steps = value
+	}
+
+	db, err := parsecmdtypes.GetDatabase(config.Cfg, parseConfig)
+	if err != nil {
+		return err
+	}
+
+	done, err := migrate(db, steps)
+	for _, m := range done {
+		cmd.Printf("%d %s\n", m.Version, m.Description)
+	}
+	if err != nil {
+		return err
+	}
+	if len(done) == 0 {
+		cmd.Println("No migration to run")
+	}
+	return nil
+}
@@ -25,6 +25,10 @@ func NewParseConfigFromToml(tomlConfig *config.TomlConfig) config.Config {
 	config.Chain = tomlConfig.Chain
 	config.Parser = tomlConfig.Parser
 	config.Database = tomlConfig.Database
+	if tomlConfig.EnableDualDB {
+		config.Database.EnableDualDB = true
+		config.Database.DsnSwitched = tomlConfig.DsnSwitched
+	}
 	config.Logging = tomlConfig.Logging
 
 	nodeConfig := &nodeconfig.Config{}
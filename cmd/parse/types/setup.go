@@ -27,8 +27,7 @@ func GetParserContext(cfg config.Config, parseConfig *Config) (*parser.Context,
 	}
 
 	// Get the db
-	databaseCtx := database.NewContext(cfg.Database, &encodingConfig)
-	db, err := parseConfig.GetDBBuilder()(databaseCtx)
+	db, err := GetDatabase(cfg, parseConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -51,6 +50,13 @@ func GetParserContext(cfg config.Config, parseConfig *Config) (*parser.Context,
 	return parser.NewContext(&encodingConfig, cp, db, registeredModules, nil), nil
 }
 
+// GetDatabase builds the database of the given configuration
+func GetDatabase(cfg config.Config, parseConfig *Config) (database.Database, error) {
+	encodingConfig := parseConfig.GetEncodingConfigBuilder()()
+	databaseCtx := database.NewContext(cfg.Database, &encodingConfig)
+	return parseConfig.GetDBBuilder()(databaseCtx)
+}
+
 // getConfig returns the SDK Config instance as well as if it's sealed or not
 func getConfig() (config *sdk.Config, sealed bool) {
 	sdkConfig := sdk.GetConfig()
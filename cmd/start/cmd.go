@@ -36,6 +36,12 @@ func NewStartCmd(cmdCfg *parsecmdtypes.Config) *cobra.Command {
 				return err
 			}
 
+			// Refuse to run against a schema migrated by a newer version
+			err = ctx.Database.CheckSchemaVersion(context.Background())
+			if err != nil {
+				return err
+			}
+
 			// Prepare tables
 			for _, module := range ctx.Modules {
 				if module, ok := module.(modules.PrepareTablesModule); ok {
@@ -46,6 +52,13 @@ func NewStartCmd(cmdCfg *parsecmdtypes.Config) *cobra.Command {
 				}
 			}
 
+			// Apply the versioned migrations before the auto migration, which fails on the data they fix, like
+			// the duplicates of a column whose index was made unique
+			_, err = ctx.Database.MigrateUp(context.Background(), 0)
+			if err != nil {
+				return err
+			}
+
 			// Critical fix: add AutoMigrate call for schema upgrades
 			log.Info("Running database auto migration...")
 			for _, module := range ctx.Modules {
@@ -58,6 +71,7 @@ func NewStartCmd(cmdCfg *parsecmdtypes.Config) *cobra.Command {
 					log.Infow("auto migration completed", "module", module.Name())
 				}
 			}
+
 			log.Info("Database migration completed successfully")
 
 			// Run all the additional operations
@@ -179,9 +193,16 @@ func enqueueMissingBlocks(exportQueue types.HeightQueue, ctx *parser.Context) {
 		}
 	} else {
 		log.Infow("syncing missing blocks...", "latest_block_height", latestBlockHeight)
-		for _, i := range ctx.Database.GetMissingHeights(context.TODO(), startHeight, latestBlockHeight) {
-			log.Debugw("enqueueing missing block", "height", i)
-			exportQueue <- i
+		gaps, err := ctx.Database.GetSyncGaps(context.TODO(), startHeight, latestBlockHeight)
+		if err != nil {
+			log.Errorw("failed to get missing heights from database", "error", err)
+			return
+		}
+		for _, gap := range gaps {
+			log.Infow("enqueueing missing blocks", "start_height", gap.Start, "end_height", gap.End)
+			for i := gap.Start; i <= gap.End; i++ {
+				exportQueue <- i
+			}
 		}
 	}
 }
@@ -0,0 +1,118 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	parsecmdtypes "github.com/forbole/juno/v4/cmd/parse/types"
+	"github.com/forbole/juno/v4/database/dual"
+	"github.com/forbole/juno/v4/types/config"
+)
+
+// NewStatusCmd returns the Cobra command allowing to inspect the indexing status
+func NewStatusCmd(parseConfig *parsecmdtypes.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the indexing status",
+	}
+
+	cmd.AddCommand(
+		newGapsCmd(parseConfig),
+		newDualCmd(parseConfig),
+	)
+
+	return cmd
+}
+
+// newGapsCmd returns the Cobra command printing the ranges of heights that are not processed yet
+func newGapsCmd(parseConfig *parsecmdtypes.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "gaps [start-height] [end-height]",
+		Short: "Print the ranges of missing heights",
+		Long: `Print the ranges of heights that are not processed yet.
+The start height defaults to the one of the parsing config, and the end height to the last stored block.`,
+		Args:    cobra.RangeArgs(0, 2),
+		PreRunE: parsecmdtypes.ReadConfigPreRunE(parseConfig),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := parsecmdtypes.GetDatabase(config.Cfg, parseConfig)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			startHeight := config.Cfg.Parser.StartHeight
+			if len(args) > 0 {
+				startHeight, err = strconv.ParseUint(args[0], 10, 64)
+				if err != nil {
+					return fmt.Errorf("make sure the given start height is a positive integer")
+				}
+			}
+
+			endHeight, err := db.GetLastBlockHeight(ctx)
+			if err != nil {
+				return fmt.Errorf("error while getting db last block height: %s", err)
+			}
+			if len(args) > 1 {
+				endHeight, err = strconv.ParseUint(args[1], 10, 64)
+				if err != nil {
+					return fmt.Errorf("make sure the given end height is a positive integer")
+				}
+			}
+
+			gaps, err := db.GetSyncGaps(ctx, startHeight, endHeight)
+			if err != nil {
+				return err
+			}
+
+			var missing uint64
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "START\tEND\tBLOCKS")
+			for _, gap := range gaps {
+				fmt.Fprintf(w, "%d\t%d\t%d\n", gap.Start, gap.End, gap.End-gap.Start+1)
+				missing += gap.End - gap.Start + 1
+			}
+			if err = w.Flush(); err != nil {
+				return err
+			}
+
+			fmt.Printf("%d missing blocks in %d ranges between %d and %d\n", missing, len(gaps), startHeight, endHeight)
+			return nil
+		},
+	}
+}
+
+// newDualCmd returns the Cobra command printing the processed height of each database when writing to two databases
+func newDualCmd(parseConfig *parsecmdtypes.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:     "dual",
+		Short:   "Print the processed height of the primary and the switched databases",
+		Args:    cobra.NoArgs,
+		PreRunE: parsecmdtypes.ReadConfigPreRunE(parseConfig),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := parsecmdtypes.GetDatabase(config.Cfg, parseConfig)
+			if err != nil {
+				return err
+			}
+
+			dualDb, ok := db.(*dual.Database)
+			if !ok {
+				return fmt.Errorf("dual database is not enabled")
+			}
+			if err = dualDb.LoadWatermarks(context.Background()); err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "DATABASE\tHEIGHT\tLAG\tAUTHORITATIVE")
+			for _, watermark := range dualDb.Watermarks() {
+				fmt.Fprintf(w, "%s\t%d\t%d\t%t\n", watermark.Name, watermark.Height, watermark.Lag, watermark.Authoritative)
+			}
+			return w.Flush()
+		},
+	}
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/forbole/juno/v4/models"
+)
+
+// archiveTable is a table whose removed rows can be moved to an archive table having its columns
+type archiveTable struct {
+	model   schema.Tabler
+	archive schema.Tabler
+}
+
+// archiveTables are the tables which can be archived, by name
+var archiveTables = map[string]archiveTable{
+	(&models.Bucket{}).TableName(): {model: &models.Bucket{}, archive: &models.BucketArchive{}},
+	(&models.Object{}).TableName(): {model: &models.Object{}, archive: &models.ObjectArchive{}},
+	(&models.Group{}).TableName():  {model: &models.Group{}, archive: &models.GroupArchive{}},
+}
+
+// ArchiveSearch selects rows among both the rows of a table and its archived rows
+type ArchiveSearch struct {
+	// Where holds the values the columns of the rows must have
+	Where map[string]interface{}
+	// Limit is the maximum number of rows returned, all of them when 0
+	Limit int
+	// Desc orders the rows by descending id
+	Desc bool
+}
+
+// ValidateArchiveTable returns an error if the removed rows of table cannot be archived
+func ValidateArchiveTable(table string) error {
+	if _, ok := archiveTables[table]; !ok {
+		return fmt.Errorf("archival of table %q is not supported", table)
+	}
+	return nil
+}
+
+// columnsOf returns the quoted columns of the model, in the order of its schema
+func columnsOf(db *gorm.DB, model schema.Tabler) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return "", err
+	}
+
+	columns := make([]string, len(stmt.Schema.DBNames))
+	for i, name := range stmt.Schema.DBNames {
+		columns[i] = stmt.Quote(clause.Column{Name: name})
+	}
+	return strings.Join(columns, ", "), nil
+}
+
+// PrepareArchiveTables creates the archive tables. The archive tables created by previous versions had the
+// primary key and the unique indexes of the archived tables, they are rebuilt with their rows.
+func (db *Impl) PrepareArchiveTables(ctx context.Context) error {
+	archives := make([]schema.Tabler, 0, len(archiveTables))
+	for _, t := range archiveTables {
+		if err := db.rebuildArchiveTable(ctx, t); err != nil {
+			return err
+		}
+		archives = append(archives, t.archive)
+	}
+	return db.PrepareTables(ctx, archives)
+}
+
+// rebuildArchiveTable rebuilds the archive table of t if it was created by a previous version
+func (db *Impl) rebuildArchiveTable(ctx context.Context, t archiveTable) error {
+	table := t.archive.TableName()
+	m := db.session(ctx).Migrator()
+	if !m.HasTable(table) || m.HasColumn(t.archive, "archive_id") {
+		return nil
+	}
+
+	// the new table is created aside, as auto migration cannot change the primary key of a table
+	rebuilt := table + "_rebuilt"
+	return db.session(ctx).Transaction(func(tx *gorm.DB) error {
+		columns, err := columnsOf(tx, t.model)
+		if err != nil {
+			return err
+		}
+
+		if err = tx.Table(rebuilt).AutoMigrate(t.archive); err != nil {
+			return err
+		}
+		err = tx.Exec(
+			fmt.Sprintf("INSERT INTO ? (%s) SELECT %s FROM ? ORDER BY id", columns, columns),
+			clause.Table{Name: rebuilt}, clause.Table{Name: table},
+		).Error
+		if err != nil {
+			return err
+		}
+		if err = tx.Migrator().DropTable(table); err != nil {
+			return err
+		}
+		return tx.Migrator().RenameTable(rebuilt, table)
+	})
+}
+
+// ArchiveRemoved moves at most batchSize rows of the table which were removed before the given time (seconds)
+// to its archive table, and returns the number of moved rows.
+func (db *Impl) ArchiveRemoved(ctx context.Context, table string, before int64, batchSize int) (int64, error) {
+	if err := ValidateArchiveTable(table); err != nil {
+		return 0, err
+	}
+	t := archiveTables[table]
+
+	var moved int64
+	err := db.session(ctx).Transaction(func(tx *gorm.DB) error {
+		columns, err := columnsOf(tx, t.model)
+		if err != nil {
+			return err
+		}
+
+		var ids []uint64
+		err = tx.Table(table).
+			Where("removed IS TRUE AND update_time < ?", before).
+			Order("id").Limit(batchSize).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		// the columns are listed, as the column order of the two tables differs when columns were added by migrations
+		err = tx.Exec(
+			fmt.Sprintf("INSERT INTO ? (%s) SELECT %s FROM ? WHERE id IN ?", columns, columns),
+			clause.Table{Name: t.archive.TableName()}, clause.Table{Name: table}, ids,
+		).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("id IN ?", ids).Delete(t.model)
+		moved = result.RowsAffected
+		return result.Error
+	})
+	return moved, err
+}
+
+// searchWithArchive returns the rows of table and of its archive table matching the search, ordered by id
+func searchWithArchive[T any](db *gorm.DB, table string, search ArchiveSearch) ([]*T, error) {
+	t := archiveTables[table]
+	columns, err := columnsOf(db, t.model)
+	if err != nil {
+		return nil, err
+	}
+
+	hot := db.Table(table).Select(columns).Where(search.Where)
+	archived := db.Table(t.archive.TableName()).Select(columns).Where(search.Where)
+	query := db.Table("(?) AS searched", db.Raw("? UNION ALL ?", hot, archived)).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: search.Desc})
+	if search.Limit > 0 {
+		query = query.Limit(search.Limit)
+	}
+
+	var result []*T
+	err = query.Find(&result).Error
+	return result, err
+}
+
+// SearchBuckets returns the buckets matching the search, the removed and archived ones included, ordered by id
+func (db *Impl) SearchBuckets(ctx context.Context, search ArchiveSearch) ([]*models.Bucket, error) {
+	return searchWithArchive[models.Bucket](db.session(ctx), (&models.Bucket{}).TableName(), search)
+}
+
+// SearchObjects returns the objects matching the search, the removed and archived ones included, ordered by id
+func (db *Impl) SearchObjects(ctx context.Context, search ArchiveSearch) ([]*models.Object, error) {
+	return searchWithArchive[models.Object](db.session(ctx), (&models.Object{}).TableName(), search)
+}
+
+// SearchGroups returns the group members matching the search, the removed and archived ones included, ordered by id
+func (db *Impl) SearchGroups(ctx context.Context, search ArchiveSearch) ([]*models.Group, error) {
+	return searchWithArchive[models.Group](db.session(ctx), (&models.Group{}).TableName(), search)
+}
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/forbole/juno/v4/common"
+	databaseconfig "github.com/forbole/juno/v4/database/config"
+	"github.com/forbole/juno/v4/database/sqlclient"
+	"github.com/forbole/juno/v4/models"
+)
+
+func newArchiveImpl(t *testing.T, name string) *Impl {
+	db, err := sqlclient.New(&databaseconfig.Config{Type: databaseconfig.SQLite, DSN: "file:" + name + "?mode=memory&cache=shared"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Bucket{}, &models.Object{}, &models.Group{}))
+	impl := &Impl{Db: db}
+	require.NoError(t, impl.PrepareArchiveTables(context.Background()))
+	return impl
+}
+
+func TestArchiveRemoved(t *testing.T) {
+	db := newArchiveImpl(t, "archive_removed")
+	ctx := context.Background()
+
+	objects := []*models.Object{
+		{ObjectID: common.HexToHash("0x01"), BucketName: "bucket", ObjectName: "removed-old", Removed: true, UpdateTime: 10},
+		{ObjectID: common.HexToHash("0x02"), BucketName: "bucket", ObjectName: "removed-recent", Removed: true, UpdateTime: 100},
+		{ObjectID: common.HexToHash("0x03"), BucketName: "bucket", ObjectName: "live", UpdateTime: 10},
+		{ObjectID: common.HexToHash("0x04"), BucketName: "other", ObjectName: "removed-old", Removed: true, UpdateTime: 20},
+	}
+	require.NoError(t, db.Db.Create(objects).Error)
+
+	moved, err := db.ArchiveRemoved(ctx, "objects", 50, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), moved)
+	moved, err = db.ArchiveRemoved(ctx, "objects", 50, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), moved)
+	moved, err = db.ArchiveRemoved(ctx, "objects", 50, 10)
+	require.NoError(t, err)
+	require.Zero(t, moved)
+
+	var hot, archived []string
+	require.NoError(t, db.Db.Model(&models.Object{}).Order("id").Pluck("object_name", &hot).Error)
+	require.NoError(t, db.Db.Model(&models.ObjectArchive{}).Order("id").Pluck("object_name", &archived).Error)
+	require.Equal(t, []string{"removed-recent", "live"}, hot)
+	require.Equal(t, []string{"removed-old", "removed-old"}, archived)
+
+	// the archived rows are kept as they were
+	var archivedObject models.Object
+	require.NoError(t, db.Db.Table("objects_archive").Where("object_id = ?", common.HexToHash("0x04")).Take(&archivedObject).Error)
+	require.Equal(t, *objects[3], archivedObject)
+
+	// the search covers both tables
+	found, err := db.SearchObjects(ctx, ArchiveSearch{Where: map[string]interface{}{"bucket_name": "bucket"}})
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+	require.Equal(t, "removed-old", found[0].ObjectName)
+	require.Equal(t, *objects[1], *found[1])
+
+	found, err = db.SearchObjects(ctx, ArchiveSearch{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+
+	found, err = db.SearchObjects(ctx, ArchiveSearch{Where: map[string]interface{}{"bucket_name": "bucket"}, Limit: 1, Desc: true})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, "live", found[0].ObjectName)
+
+	_, err = db.ArchiveRemoved(ctx, "txs", 50, 10)
+	require.Error(t, err)
+}
+
+func TestArchiveRemoved_FlushesWriteBuffer(t *testing.T) {
+	db := newArchiveImpl(t, "archive_buffered")
+	ctx := WithWriteBuffer(context.Background())
+
+	require.NoError(t, db.SaveObject(ctx, &models.Object{ObjectID: common.HexToHash("0x05"), ObjectName: "buffered"}))
+	require.NoError(t, db.UpdateObject(ctx, &models.Object{ObjectID: common.HexToHash("0x05"), Removed: true, UpdateTime: 1}))
+
+	// the pending writes are flushed before the rows are moved
+	moved, err := db.ArchiveRemoved(ctx, "objects", 50, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), moved)
+	require.ErrorIs(t, db.Db.Where("object_id = ?", common.HexToHash("0x05")).Take(&models.Object{}).Error, gorm.ErrRecordNotFound)
+}
+
+func TestArchiveRemoved_ArchivedTwice(t *testing.T) {
+	db := newArchiveImpl(t, "archive_twice")
+	ctx := context.Background()
+
+	groupID := common.HexToHash("0x01")
+	member := common.HexToAddress("0x0a")
+	for i := 0; i < 2; i++ {
+		// the name and the membership are free again once the removed rows are archived,
+		// and SQLite gives the new rows the ids of the archived ones
+		require.NoError(t, db.Db.Create(&models.Bucket{BucketName: "bucket", Removed: true, UpdateTime: 10}).Error)
+		require.NoError(t, db.Db.Create(&models.Group{GroupID: groupID, AccountID: member, Removed: true, UpdateTime: 10}).Error)
+
+		moved, err := db.ArchiveRemoved(ctx, "buckets", 50, 10)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), moved)
+		moved, err = db.ArchiveRemoved(ctx, "groups", 50, 10)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), moved)
+	}
+
+	buckets, err := db.SearchBuckets(ctx, ArchiveSearch{Where: map[string]interface{}{"bucket_name": "bucket"}})
+	require.NoError(t, err)
+	require.Len(t, buckets, 2)
+	groups, err := db.SearchGroups(ctx, ArchiveSearch{Where: map[string]interface{}{"group_id": groupID, "account_id": member}})
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+}
+
+// legacyObjectArchive is the archive table of the objects as created by previous versions
+type legacyObjectArchive struct {
+	models.Object
+}
+
+func (*legacyObjectArchive) TableName() string {
+	return "objects_archive"
+}
+
+func TestPrepareArchiveTables_RebuildsLegacyTables(t *testing.T) {
+	db, err := sqlclient.New(&databaseconfig.Config{Type: databaseconfig.SQLite, DSN: "file:archive_legacy?mode=memory&cache=shared"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Object{}, &legacyObjectArchive{}))
+	object := models.Object{ID: 7, ObjectID: common.HexToHash("0x07"), ObjectName: "archived", Removed: true}
+	require.NoError(t, db.Create(&legacyObjectArchive{Object: object}).Error)
+
+	impl := &Impl{Db: db}
+	require.NoError(t, impl.PrepareArchiveTables(context.Background()))
+	// rebuilding is done once
+	require.NoError(t, impl.PrepareArchiveTables(context.Background()))
+
+	require.True(t, db.Migrator().HasColumn(&models.ObjectArchive{}, "archive_id"))
+	require.False(t, db.Migrator().HasIndex(&models.ObjectArchive{}, "idx_object_id"))
+	require.False(t, db.Migrator().HasTable("objects_archive_rebuilt"))
+
+	found, err := impl.SearchObjects(context.Background(), ArchiveSearch{})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, object, *found[0])
+
+	// the rebuilt table accepts the row archived again
+	require.NoError(t, db.Create(&models.Object{ID: 7, ObjectID: common.HexToHash("0x07"), Removed: true}).Error)
+	moved, err := impl.ArchiveRemoved(context.Background(), "objects", 50, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), moved)
+}
+
+func TestArchiveTables_HaveTheArchivedColumns(t *testing.T) {
+	db := newArchiveImpl(t, "archive_columns")
+	for table, archive := range archiveTables {
+		model, err := schema.Parse(archive.model, &sync.Map{}, db.Db.NamingStrategy)
+		require.NoError(t, err)
+		archived, err := schema.Parse(archive.archive, &sync.Map{}, db.Db.NamingStrategy)
+		require.NoError(t, err)
+
+		for _, name := range model.DBNames {
+			modelField, archivedField := model.FieldsByDBName[name], archived.LookUpField(name)
+			require.NotNil(t, archivedField, "%s archive lacks column %s", table, name)
+			require.Equal(t, modelField.FieldType, archivedField.FieldType, "%s archive column %s", table, name)
+			require.Equal(t, modelField.DataType, archivedField.DataType, "%s archive column %s", table, name)
+		}
+		for _, index := range archived.ParseIndexes() {
+			require.NotEqual(t, "UNIQUE", index.Class, "%s archive index %s", table, index.Name)
+		}
+	}
+}
+
+func TestArchiveTables_CreatedWithTheArchivedColumns(t *testing.T) {
+	db := newArchiveImpl(t, "archive_created_columns")
+	for table, archive := range archiveTables {
+		hotColumns, err := db.Db.Migrator().ColumnTypes(archive.model)
+		require.NoError(t, err)
+		archivedColumns, err := db.Db.Migrator().ColumnTypes(archive.archive)
+		require.NoError(t, err)
+
+		types := make(map[string]string, len(archivedColumns))
+		for _, column := range archivedColumns {
+			types[column.Name()] = column.DatabaseTypeName()
+		}
+		for _, column := range hotColumns {
+			archivedType, ok := types[column.Name()]
+			require.True(t, ok, "%s archive table lacks column %s", table, column.Name())
+			require.Equal(t, column.DatabaseTypeName(), archivedType, "%s archive table column %s", table, column.Name())
+		}
+	}
+}
@@ -5,18 +5,30 @@ import (
 
 	"github.com/forbole/juno/v4/database"
 	databaseconfig "github.com/forbole/juno/v4/database/config"
+	"github.com/forbole/juno/v4/database/dual"
 	"github.com/forbole/juno/v4/database/mysql"
 	"github.com/forbole/juno/v4/database/postgresql"
+	"github.com/forbole/juno/v4/database/sqlite"
 )
 
 // Builder represents a generic Builder implementation that build the proper database
 // instance based on the configuration the user has specified
 func Builder(ctx *database.Context) (database.Database, error) {
+	if ctx.Cfg.EnableDualDB {
+		return dual.Builder(ctx, build)
+	}
+	return build(ctx)
+}
+
+// build returns the database of the type of the configuration
+func build(ctx *database.Context) (database.Database, error) {
 	switch ctx.Cfg.Type {
 	case databaseconfig.PostgreSQL:
 		return postgresql.Builder(ctx)
 	case databaseconfig.MySQL:
 		return mysql.Builder(ctx)
+	case databaseconfig.SQLite:
+		return sqlite.Builder(ctx)
 	default:
 		return nil, errors.New("unsupported database type")
 	}
@@ -24,6 +24,7 @@ type DatabaseType string
 const (
 	PostgreSQL DatabaseType = "postgres"
 	MySQL      DatabaseType = "mysql"
+	SQLite     DatabaseType = "sqlite"
 )
 
 type Config struct {
@@ -37,6 +38,29 @@ type Config struct {
 	ConnMaxLifetime    Duration
 	PartitionSize      int64 `yaml:"partition_size"`
 	PartitionBatchSize int64 `yaml:"partition_batch"`
+	// PartitionTables lists the tables partitioned by height ranges of PartitionSize (PostgreSQL only)
+	PartitionTables []string `yaml:"partition_tables"`
+	// PartitionsAhead is the number of partitions created ahead of the last written height
+	PartitionsAhead int64 `yaml:"partitions_ahead"`
+	// PartitionRetention is the number of partitions kept behind the write head, 0 keeps all of them.
+	// Older partitions are detached, or dropped when DropPartitions is set
+	PartitionRetention int64 `yaml:"partition_retention"`
+	DropPartitions     bool  `yaml:"drop_partitions"`
+	// EnableDualDB writes every block to both the database of DSN and the one of DsnSwitched,
+	// so that the indexer can move to another database without downtime
+	EnableDualDB bool   `yaml:"enable_dual_db"`
+	DsnSwitched  string `yaml:"dsn_switched"`
+	// SecretsSwitched replaces DsnSwitched with the secret it describes, like Secrets does for DSN
+	SecretsSwitched *Params `yaml:"secrets_switched"`
+	// TypeSwitched is the type of the database of DsnSwitched, Type when empty
+	TypeSwitched DatabaseType `yaml:"type_switched"`
+	// Switched makes the database of DsnSwitched the authoritative one, which serves the reads.
+	// It is only read on start, which fails when it is behind the database of DSN or diverged from it.
+	// The running indexer is switched by the /dual/switch endpoint of the telemetry server, which does
+	// not change the config: Switched must be set as well for the switch to survive a restart
+	Switched bool `yaml:"switched"`
+	// EnableOutbox records the changes of the buckets, objects, groups and policies in the outbox table
+	EnableOutbox bool `yaml:"enable_outbox"`
 }
 
 func (c *Config) getURL() *url.URL {
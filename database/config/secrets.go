@@ -1,7 +1,15 @@
 package config
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -12,36 +20,222 @@ var (
 	ErrorNilString = errors.New("secret string is nil")
 )
 
+// SecretsProviderType is the store a secret is read from
+type SecretsProviderType string
+
+const (
+	AWSSecrets  SecretsProviderType = "aws"
+	EnvSecrets  SecretsProviderType = "env"
+	FileSecrets SecretsProviderType = "file"
+	HTTPSecrets SecretsProviderType = "http"
+)
+
+// httpSecretsTimeout is the timeout of a request to the HTTP secrets endpoint
+const httpSecretsTimeout = 10 * time.Second
+
 // Params holds all the secret manager information for connecting to database.
-// When this information is provided, DBConfiguration.DSN will be replaced with the secret value from AWS SM.
+// When this information is provided, DBConfiguration.DSN will be replaced with the secret value.
 type Params struct {
-	SecretId string `yaml:"SecretId"`
-	Region   string `yaml:"Region"`
+	// Provider is the store the secret is read from, AWS Secrets Manager when empty
+	Provider SecretsProviderType `yaml:"Provider"`
+	SecretId string              `yaml:"SecretId"`
+	Region   string              `yaml:"Region"`
+	// Env is the environment variable holding the secret
+	Env string `yaml:"Env"`
+	// File is the path of the file holding the secret, e.g. a mounted Kubernetes secret
+	File string `yaml:"File"`
+	// URL is the endpoint returning the secret, called with Token as bearer token when set
+	URL   string `yaml:"URL"`
+	Token string `yaml:"Token"`
+	// Key is the field holding the DSN when the secret is a JSON object
+	Key string `yaml:"Key"`
+	// RefreshInterval is the interval between two fetches of the secret. When 0, the secret is
+	// fetched again only when the database rejects the credentials.
+	RefreshInterval Duration `yaml:"RefreshInterval"`
+}
+
+// String implements fmt.Stringer, the Token is hidden so that the params can be logged
+func (p Params) String() string {
+	token := ""
+	if p.Token != "" {
+		token = "***"
+	}
+	return fmt.Sprintf("{Provider:%s SecretId:%s Region:%s Env:%s File:%s URL:%s Token:%s Key:%s RefreshInterval:%s}",
+		p.Provider, p.SecretId, p.Region, p.Env, p.File, p.URL, token, p.Key, time.Duration(p.RefreshInterval))
+}
+
+// SecretsProvider returns the current value of a secret
+type SecretsProvider interface {
+	GetSecret(ctx context.Context) (string, error)
 }
 
+// NewSecretsProvider returns the SecretsProvider described by the given params
+func NewSecretsProvider(params *Params) (SecretsProvider, error) {
+	var provider SecretsProvider
+	switch params.Provider {
+	case AWSSecrets, "":
+		if params.SecretId == "" {
+			return nil, errors.New("no SecretId set for the aws secrets provider")
+		}
+		provider = &awsSecretsProvider{secretId: params.SecretId, region: params.Region}
+	case EnvSecrets:
+		if params.Env == "" {
+			return nil, errors.New("no Env set for the env secrets provider")
+		}
+		provider = &envSecretsProvider{name: params.Env}
+	case FileSecrets:
+		if params.File == "" {
+			return nil, errors.New("no File set for the file secrets provider")
+		}
+		provider = &fileSecretsProvider{path: params.File}
+	case HTTPSecrets:
+		if params.URL == "" {
+			return nil, errors.New("no URL set for the http secrets provider")
+		}
+		provider = &httpSecretsProvider{
+			url:    params.URL,
+			token:  params.Token,
+			client: &http.Client{Timeout: httpSecretsTimeout},
+		}
+	default:
+		return nil, fmt.Errorf("unsupported secrets provider %q", params.Provider)
+	}
+
+	if params.Key != "" {
+		provider = &keySecretsProvider{SecretsProvider: provider, key: params.Key}
+	}
+	return provider, nil
+}
+
+// GetString returns the current value of the secret described by config
 func GetString(config *Params) (string, error) {
-	sess, err := session.NewSession()
+	provider, err := NewSecretsProvider(config)
 	if err != nil {
 		return "", err
 	}
+	return provider.GetSecret(context.Background())
+}
+
+// awsSecretsProvider reads the secret from AWS Secrets Manager
+type awsSecretsProvider struct {
+	secretId string
+	region   string
+}
 
+func (p *awsSecretsProvider) GetSecret(ctx context.Context) (string, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return "", err
+	}
 	svc := secretsmanager.New(
 		sess,
-		aws.NewConfig().WithRegion(config.Region),
+		aws.NewConfig().WithRegion(p.region),
 	)
-
 	input := &secretsmanager.GetSecretValueInput{
-		SecretId:     aws.String(config.SecretId),
+		SecretId:     aws.String(p.secretId),
 		VersionStage: aws.String("AWSCURRENT"),
 	}
 
-	result, err := svc.GetSecretValue(input)
+	result, err := svc.GetSecretValueWithContext(ctx, input)
 	if err != nil {
 		return "", err
 	}
-
 	if result.SecretString != nil {
 		return *result.SecretString, nil
 	}
 	return "", ErrorNilString
 }
+
+// envSecretsProvider reads the secret from an environment variable
+type envSecretsProvider struct {
+	name string
+}
+
+func (p *envSecretsProvider) GetSecret(context.Context) (string, error) {
+	value, ok := os.LookupEnv(p.name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", p.name)
+	}
+	if value == "" {
+		return "", ErrorNilString
+	}
+	return value, nil
+}
+
+// fileSecretsProvider reads the secret from a file. The file is read on each call, so that
+// a secret mounted by an orchestrator is picked up when it is updated.
+type fileSecretsProvider struct {
+	path string
+}
+
+func (p *fileSecretsProvider) GetSecret(context.Context) (string, error) {
+	content, err := os.ReadFile(p.path)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(string(content))
+	if value == "" {
+		return "", ErrorNilString
+	}
+	return value, nil
+}
+
+// httpSecretsProvider reads the secret from the body of a GET request to a secrets endpoint
+type httpSecretsProvider struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (p *httpSecretsProvider) GetSecret(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return "", err
+	}
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("secrets endpoint returned status %d", resp.StatusCode)
+	}
+
+	value := strings.TrimSpace(string(body))
+	if value == "" {
+		return "", ErrorNilString
+	}
+	return value, nil
+}
+
+// keySecretsProvider reads a field of a secret which is a JSON object
+type keySecretsProvider struct {
+	SecretsProvider
+	key string
+}
+
+func (p *keySecretsProvider) GetSecret(ctx context.Context) (string, error) {
+	secret, err := p.SecretsProvider.GetSecret(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var fields map[string]interface{}
+	if err = json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object: %s", err)
+	}
+	value, ok := fields[p.key].(string)
+	if !ok || value == "" {
+		return "", fmt.Errorf("secret has no %s string field", p.key)
+	}
+	return value, nil
+}
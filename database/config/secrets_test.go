@@ -0,0 +1,77 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretsProviders(t *testing.T) {
+	t.Setenv("JUNO_TEST_DSN", "env-dsn")
+
+	file := filepath.Join(t.TempDir(), "dsn")
+	require.NoError(t, os.WriteFile(file, []byte("file-dsn\n"), 0o600))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"dsn": "http-dsn"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		params   Params
+		expected string
+		err      bool
+	}{
+		{name: "env", params: Params{Provider: EnvSecrets, Env: "JUNO_TEST_DSN"}, expected: "env-dsn"},
+		{name: "missing env", params: Params{Provider: EnvSecrets, Env: "JUNO_TEST_MISSING"}, err: true},
+		{name: "file", params: Params{Provider: FileSecrets, File: file}, expected: "file-dsn"},
+		{name: "http with key", params: Params{Provider: HTTPSecrets, URL: server.URL, Token: "token", Key: "dsn"}, expected: "http-dsn"},
+		{name: "http unauthorized", params: Params{Provider: HTTPSecrets, URL: server.URL}, err: true},
+		{name: "missing key", params: Params{Provider: HTTPSecrets, URL: server.URL, Token: "token", Key: "password"}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewSecretsProvider(&tt.params)
+			require.NoError(t, err)
+
+			secret, err := provider.GetSecret(context.Background())
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, secret)
+		})
+	}
+}
+
+func TestNewSecretsProvider_Invalid(t *testing.T) {
+	for _, params := range []Params{
+		{},
+		{Provider: EnvSecrets},
+		{Provider: FileSecrets},
+		{Provider: HTTPSecrets},
+		{Provider: "vault", SecretId: "id"},
+	} {
+		_, err := NewSecretsProvider(&params)
+		require.Error(t, err, params.Provider)
+	}
+}
+
+func TestParams_StringHidesToken(t *testing.T) {
+	params := &Params{Provider: HTTPSecrets, URL: "https://secrets", Token: "credential"}
+	require.NotContains(t, fmt.Sprintf("%+v", params), "credential")
+	require.NotContains(t, fmt.Sprintf("%v", *params), "credential")
+	require.Contains(t, params.String(), "https://secrets")
+}
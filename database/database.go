@@ -15,6 +15,7 @@ import (
 
 	"github.com/forbole/juno/v4/common"
 	databaseconfig "github.com/forbole/juno/v4/database/config"
+	"github.com/forbole/juno/v4/database/sqlclient"
 	"github.com/forbole/juno/v4/log"
 	"github.com/forbole/juno/v4/models"
 	"github.com/forbole/juno/v4/types"
@@ -22,12 +23,27 @@ import (
 
 // Database represents an abstract database that can be used to save data inside it
 type Database interface {
+	// Repository lists and gets the indexed resources
+	Repository
+
 	// PrepareTables create tables
 	PrepareTables(ctx context.Context, tables []schema.Tabler) error
 
 	// AutoMigrate Automatically migrate your schema, to keep your schema up to date.
 	AutoMigrate(ctx context.Context, tables []schema.Tabler) error
 
+	// GetMigrationStatus returns the registered schema migrations and whether they are applied.
+	GetMigrationStatus(ctx context.Context) ([]*MigrationStatus, error)
+
+	// CheckSchemaVersion returns an error if the database schema was migrated by a newer version.
+	CheckSchemaVersion(ctx context.Context) error
+
+	// MigrateUp applies up to steps pending schema migrations, all of them if steps is 0.
+	// MigrateDown reverts the last steps applied schema migrations.
+	// Both return the migrations they ran.
+	MigrateUp(ctx context.Context, steps int) ([]Migration, error)
+	MigrateDown(ctx context.Context, steps int) ([]Migration, error)
+
 	// HasBlock tells whether the database has already stored the block having the given height.
 	// An error is returned if the operation fails.
 	HasBlock(ctx context.Context, height uint64) (bool, error)
@@ -39,6 +55,14 @@ type Database interface {
 	// GetMissingHeights returns a slice of missing block heights between startHeight and endHeight
 	GetMissingHeights(ctx context.Context, startHeight, endHeight uint64) []uint64
 
+	// SaveProcessedHeight records that the block at the given height is fully processed.
+	// An error is returned if the operation fails.
+	SaveProcessedHeight(ctx context.Context, height uint64) error
+
+	// GetSyncGaps returns the ranges of heights between startHeight and endHeight that are not processed yet.
+	// An error is returned if the operation fails.
+	GetSyncGaps(ctx context.Context, startHeight, endHeight uint64) ([]HeightRange, error)
+
 	// SaveBlock will be called when a new block is parsed, passing the block itself
 	// and the transactions contained inside that block.
 	// An error is returned if the operation fails.
@@ -64,6 +88,25 @@ type Database interface {
 	// An error is returned if the operation fails.
 	UpdateBucket(ctx context.Context, bucket *models.Bucket) error
 
+	// UpdateBucketFlowRateLimit, UpdateBucketFlowRateLimitStatus and UpdateBucketSpAsDelegatedAgent update
+	// a single setting of the bucket, zero values included.
+	// An error is returned if the operation fails.
+	UpdateBucketFlowRateLimit(ctx context.Context, bucket *models.Bucket) error
+	UpdateBucketFlowRateLimitStatus(ctx context.Context, bucket *models.Bucket) error
+	UpdateBucketSpAsDelegatedAgent(ctx context.Context, bucket *models.Bucket) error
+
+	// SaveBucketSettingHistory will be called to record a change of the bucket settings, at the event position of ctx.
+	// The change is recorded once, however many times it is saved.
+	// An error is returned if the operation fails.
+	SaveBucketSettingHistory(ctx context.Context, history *models.BucketSettingHistory) error
+
+	// GetBucketSettingHistory returns the setting changes of the bucket, oldest first.
+	GetBucketSettingHistory(ctx context.Context, bucketId common.Hash) ([]*models.BucketSettingHistory, error)
+
+	// GetBucketFlowRateLimit returns the last flow rate limit set for the bucket name, payment address and owner,
+	// whether the bucket existed or not. Nil is returned if no limit is set.
+	GetBucketFlowRateLimit(ctx context.Context, bucketName string, paymentAddress, owner common.Address) (*common.Big, error)
+
 	// SaveObject will be called to save each object contained inside a block.
 	// An error is returned if the operation fails.
 	SaveObject(ctx context.Context, object *models.Object) error
@@ -72,13 +115,17 @@ type Database interface {
 	// An error is returned if the operation fails.
 	UpdateObject(ctx context.Context, object *models.Object) error
 
-	// GetObject returns an object model with given objectId.
-	// It should return only one record
-	GetObject(ctx context.Context, objectId common.Hash) (*models.Object, error)
+	// SaveObjectLineage will be called to record the source of an object created by a copy.
+	// An error is returned if the operation fails.
+	SaveObjectLineage(ctx context.Context, lineage *models.ObjectLineage) error
 
-	SaveEpoch(ctx context.Context, epoch *models.Epoch) error
+	// GetObjectLineage walks the copy links of the object. Ancestors are returned from the nearest source
+	// to the original object, descendants level by level starting with the direct copies.
+	GetObjectLineage(ctx context.Context, objectId common.Hash) (ancestors []*models.ObjectLineage, descendants []*models.ObjectLineage, err error)
 
-	GetEpoch(ctx context.Context) (*models.Epoch, error)
+	// SaveEpoch records the last fully indexed block, the end of the first gap-free sync range, once epoch,
+	// the block just processed, is saved in the sync ranges. The saved epoch never moves backwards.
+	SaveEpoch(ctx context.Context, epoch *models.Epoch) error
 
 	// SavePaymentAccount will be called to save PaymentAccount.
 	// An error is returned if the operation fails.
@@ -88,6 +135,28 @@ type Database interface {
 	// An error is returned if the operation fails.
 	SaveStreamRecord(ctx context.Context, streamRecord *models.StreamRecord) error
 
+	// SaveStreamRecordHistory will be called to keep the stream record of an account as of a block.
+	// An error is returned if the operation fails.
+	SaveStreamRecordHistory(ctx context.Context, history *models.StreamRecordHistory) error
+
+	// GetStreamRecordHistory returns the stream records of the account updated in [startTime, endTime), oldest first.
+	GetStreamRecordHistory(ctx context.Context, account common.Address, startTime, endTime int64) ([]*models.StreamRecordHistory, error)
+
+	// SaveStreamRecordOutFlows replaces the out flows of the account and keeps its out flow count in sync.
+	// An error is returned if the operation fails.
+	SaveStreamRecordOutFlows(ctx context.Context, from common.Address, outFlows []*models.StreamRecordOutFlow) error
+
+	// GetStreamRecordOutFlows returns the out flows of the account.
+	GetStreamRecordOutFlows(ctx context.Context, from common.Address) ([]*models.StreamRecordOutFlow, error)
+
+	// SavePaymentLedger will be called to record a balance-affecting payment event, at the event position of ctx.
+	// The event is recorded once, however many times it is saved.
+	// An error is returned if the operation fails.
+	SavePaymentLedger(ctx context.Context, ledger *models.PaymentLedger) error
+
+	// GetPaymentLedger returns the ledger entries of the account with a timestamp in [startTime, endTime), oldest first.
+	GetPaymentLedger(ctx context.Context, account common.Address, startTime, endTime int64) ([]*models.PaymentLedger, error)
+
 	// SavePermission will be called to save each policy contained inside a event.
 	// An error is returned if the operation fails.
 	SavePermission(ctx context.Context, permission *models.Permission) error
@@ -96,6 +165,12 @@ type Database interface {
 	// An error is returned if the operation fails.
 	UpdatePermission(ctx context.Context, permission *models.Permission) error
 
+	// GetPermissionsByResource returns the policies of the resource which are not removed.
+	GetPermissionsByResource(ctx context.Context, resourceType string, resourceID common.Hash) ([]*models.Permission, error)
+
+	// GetStatementsByPolicyIDs returns the statements of the policies which are not removed.
+	GetStatementsByPolicyIDs(ctx context.Context, policyIDs []common.Hash) ([]*models.Statements, error)
+
 	// CreateGroup will be called to save each group contained inside an event.
 	// An error is returned if the operation fails.
 	CreateGroup(ctx context.Context, groupMembers []*models.Group) error
@@ -116,12 +191,41 @@ type Database interface {
 	// An error is returned if the operation fails.
 	UpdateStorageProvider(ctx context.Context, storageProvider *models.StorageProvider) error
 
-	// MultiSaveStatement will be called to save each statement contained inside a policy.
+	// SaveSpStatusHistory will be called to record each status transition of a sp, at the event position of ctx.
+	// The first transition saved for a sp at a position is kept.
 	// An error is returned if the operation fails.
+	SaveSpStatusHistory(ctx context.Context, history *models.SpStatusHistory) error
+
+	// SaveSpPriceHistory will be called to record each storage price update of a sp.
+	// An error is returned if the operation fails.
+	SaveSpPriceHistory(ctx context.Context, price *models.SpPriceHistory) error
+
+	// GetSpPriceAt returns the price of the given sp that is effective at the given timestamp (seconds).
+	// Nil is returned if the sp had no price yet at that time.
+	GetSpPriceAt(ctx context.Context, spId uint32, timestamp int64) (*models.SpPriceHistory, error)
+
+	// SaveGlobalStorePrice will be called to record each global sp store price update.
+	// An error is returned if the operation fails.
+	SaveGlobalStorePrice(ctx context.Context, price *models.GlobalStorePrice) error
+
+	// GetGlobalStorePriceAt returns the global store price that is effective at the given timestamp (seconds).
+	// Nil is returned if there was no global price yet at that time.
+	GetGlobalStorePriceAt(ctx context.Context, timestamp int64) (*models.GlobalStorePrice, error)
+
+	// MultiSaveStatement will be called to save each statement contained inside a policy, they replace the
+	// statements previously saved for the policy. An error is returned if the operation fails.
 	MultiSaveStatement(ctx context.Context, statements []*models.Statements) error
 
 	RemoveStatements(ctx context.Context, policyID common.Hash) error
 
+	// RemovePoliciesByResources marks the policies of the given resources and their statements as removed.
+	// The number of removed policies is returned.
+	RemovePoliciesByResources(ctx context.Context, resourceType string, resourceIDs []common.Hash, updateTime int64) (int64, error)
+
+	// RemoveExpiredPolicies marks the policies and statements that expired before now (seconds) as removed.
+	// The numbers of removed policies and statements are returned.
+	RemoveExpiredPolicies(ctx context.Context, now int64) (int64, int64, error)
+
 	SaveGVG(ctx context.Context, gvg *models.GlobalVirtualGroup) error
 
 	UpdateGVG(ctx context.Context, gvg *models.GlobalVirtualGroup) error
@@ -134,10 +238,52 @@ type Database interface {
 
 	UpdateVGF(ctx context.Context, vgf *models.GlobalVirtualGroupFamily) error
 
+	// UpdateGVGsByFamily applies the non-zero fields of gvg to every global virtual group of the family.
+	UpdateGVGsByFamily(ctx context.Context, familyId uint32, gvg *models.GlobalVirtualGroup) error
+
+	// SaveVgSwap starts the swap at the event position of ctx, a swap of the same target started at the same
+	// position is kept as is so that the swap is started once when its block is processed again.
+	SaveVgSwap(ctx context.Context, swap *models.VgSwap) error
+
+	// UpdateVgSwap moves the swaps of the same type and target currently in fromStatus to the status of swap.
+	// The swaps started at the event position of ctx are left out, they were started by the event being processed again.
+	UpdateVgSwap(ctx context.Context, fromStatus string, swap *models.VgSwap) error
+
 	SaveDBStatistics(ctx context.Context, ds *models.DataStat) error
 
+	// SaveChallenge will be called to save a started challenge.
+	// An error is returned if the operation fails.
+	SaveChallenge(ctx context.Context, challenge *models.Challenge) error
+
+	// UpdateChallenge will be called to record the attestation of a challenge, which is created if its start
+	// is not indexed.
+	// An error is returned if the operation fails.
+	UpdateChallenge(ctx context.Context, challenge *models.Challenge) error
+
+	// GetChallengesBySp returns the challenges of the storage provider joined with their objects, latest first.
+	GetChallengesBySp(ctx context.Context, spId uint32, offset, limit int) ([]*models.ChallengeDetail, error)
+
+	// PrepareArchiveTables creates the archive tables, and rebuilds the ones created by previous versions,
+	// which had the primary key and the unique indexes of the archived tables.
+	PrepareArchiveTables(ctx context.Context) error
+
+	// ArchiveRemoved moves at most batchSize rows of the table removed before the given time (seconds)
+	// to its archive table. The number of moved rows is returned.
+	ArchiveRemoved(ctx context.Context, table string, before int64, batchSize int) (int64, error)
+
+	// GetUnpublishedChanges returns at most limit outbox records which are not published yet, oldest first.
+	GetUnpublishedChanges(ctx context.Context, limit int) ([]*models.Outbox, error)
+
+	// MarkChangesPublished marks the outbox records with the given ids as published at publishTime (seconds).
+	// An error is returned if the operation fails.
+	MarkChangesPublished(ctx context.Context, ids []uint64, publishTime int64) error
+
+	// FlushWriteBuffer writes the pending writes of the WriteBuffer carried by ctx, if any.
+	// An error is returned if the operation fails.
+	FlushWriteBuffer(ctx context.Context) error
+
 	// Begin begins a transaction with any transaction options opts
-	Begin(ctx context.Context) *Impl
+	Begin(ctx context.Context) Database
 
 	// Rollback rollbacks the changes in a transaction
 	Rollback()
@@ -160,6 +306,10 @@ type PruningDb interface {
 
 	// GetLastPruned returns the last height at which the database was pruned
 	GetLastPruned() (int64, error)
+
+	// ApplyRetention deletes at most batchSize rows of the table of the policy which fall out of it.
+	// The windows of the policy end at latestHeight and now (seconds). The number of deleted rows is returned.
+	ApplyRetention(ctx context.Context, policy RetentionPolicy, latestHeight uint64, now int64, batchSize int) (int64, error)
 }
 
 // Context contains the data that might be used to build a Database instance
@@ -182,31 +332,16 @@ type Builder func(ctx *Context) (Database, error)
 type Impl struct {
 	Db             *gorm.DB
 	EncodingConfig *params.EncodingConfig
-}
-
-// createPartitionIfNotExists creates a new partition having the given partition id if not existing
-func (db *Impl) createPartitionIfNotExists(table string, partitionID int64) error {
-	partitionTable := fmt.Sprintf("%s_%d", table, partitionID)
-
-	stmt := fmt.Sprintf(
-		"CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES IN (%d)",
-		partitionTable,
-		table,
-		partitionID,
-	)
-	err := db.Db.Exec(stmt).Error
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+	// Outbox records the changes of the buckets, objects, groups and policies in the outbox table.
+	// As a record needs the row before the change, the write buffer is not used for the buckets and objects
+	// when it is set: they are written right away, along with their record.
+	Outbox bool
 }
 
 // -------------------------------------------------------------------------------------------------------------------
 
 func (db *Impl) PrepareTables(ctx context.Context, tables []schema.Tabler) error {
-	q := db.Db.WithContext(ctx)
+	q := db.session(ctx)
 	m := db.Db.Migrator()
 
 	for _, t := range tables {
@@ -358,16 +493,22 @@ func (db *Impl) SaveCommitSignatures(ctx context.Context, signatures []*types.Co
 
 	stmt = stmt[:len(stmt)-1]
 	stmt += " ON CONFLICT (validator_address, timestamp) DO NOTHING"
-	err := db.Db.WithContext(ctx).Exec(stmt, sparams...).Error
+	err := db.session(ctx).Exec(stmt, sparams...).Error
 	return err
 }
 
 func (db *Impl) SaveBucket(ctx context.Context, bucket *models.Bucket) error {
-	err := db.Db.WithContext(ctx).Table((&models.Bucket{}).TableName()).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "bucket_id"}},
-		UpdateAll: true,
-	}).Create(bucket).Error
-	return err
+	// the outbox needs the row before the write, which the buffer does not have
+	if buffer := writeBufferFrom(ctx); buffer != nil && !db.Outbox {
+		buffer.upsert((&models.Bucket{}).TableName(), []string{"bucket_id"}, bufferKey(bucket.BucketID), bucket)
+		return nil
+	}
+	return recordChanges(ctx, db, bucketChanges, where("bucket_id = ?", bucket.BucketID), func(tx *gorm.DB) error {
+		return tx.Table((&models.Bucket{}).TableName()).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "bucket_id"}},
+			UpdateAll: true,
+		}).Create(bucket).Error
+	})
 }
 
 func (db *Impl) UpdateBucket(ctx context.Context, bucket *models.Bucket) error {
@@ -422,15 +563,27 @@ func (db *Impl) UpdateBucket(ctx context.Context, bucket *models.Bucket) error {
 		updates["removed"] = bucket.Removed
 	}
 
-	return db.Db.WithContext(ctx).Table((&models.Bucket{}).TableName()).Where("bucket_id = ?", bucket.BucketID).Updates(updates).Error
+	if buffer := writeBufferFrom(ctx); buffer != nil && !db.Outbox {
+		return buffer.update(db.Db.WithContext(ctx), (&models.Bucket{}).TableName(), []string{"bucket_id"}, bufferKey(bucket.BucketID),
+			map[string]interface{}{"bucket_id": bucket.BucketID}, updates)
+	}
+	return recordChanges(ctx, db, bucketChanges, where("bucket_id = ?", bucket.BucketID), func(tx *gorm.DB) error {
+		return tx.Table((&models.Bucket{}).TableName()).Where("bucket_id = ?", bucket.BucketID).Updates(updates).Error
+	})
 }
 
 func (db *Impl) SaveObject(ctx context.Context, object *models.Object) error {
-	err := db.Db.WithContext(ctx).Table((&models.Object{}).TableName()).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "object_id"}},
-		UpdateAll: true,
-	}).Create(object).Error
-	return err
+	// the outbox needs the row before the write, which the buffer does not have
+	if buffer := writeBufferFrom(ctx); buffer != nil && !db.Outbox {
+		buffer.upsert((&models.Object{}).TableName(), []string{"object_id"}, bufferKey(object.ObjectID), object)
+		return nil
+	}
+	return recordChanges(ctx, db, objectChanges, where("object_id = ?", object.ObjectID), func(tx *gorm.DB) error {
+		return tx.Table((&models.Object{}).TableName()).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "object_id"}},
+			UpdateAll: true,
+		}).Create(object).Error
+	})
 }
 
 func (db *Impl) UpdateObject(ctx context.Context, object *models.Object) error {
@@ -527,48 +680,282 @@ func (db *Impl) UpdateObject(ctx context.Context, object *models.Object) error {
 		updates["removed"] = object.Removed
 	}
 
-	return db.Db.WithContext(ctx).Table((&models.Object{}).TableName()).Where("object_id = ?", object.ObjectID).Updates(updates).Error
+	if buffer := writeBufferFrom(ctx); buffer != nil && !db.Outbox {
+		return buffer.update(db.Db.WithContext(ctx), (&models.Object{}).TableName(), []string{"object_id"}, bufferKey(object.ObjectID),
+			map[string]interface{}{"object_id": object.ObjectID}, updates)
+	}
+	return recordChanges(ctx, db, objectChanges, where("object_id = ?", object.ObjectID), func(tx *gorm.DB) error {
+		return tx.Table((&models.Object{}).TableName()).Where("object_id = ?", object.ObjectID).Updates(updates).Error
+	})
+}
+
+func (db *Impl) SaveObjectLineage(ctx context.Context, lineage *models.ObjectLineage) error {
+	return db.session(ctx).Table((&models.ObjectLineage{}).TableName()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "dst_object_id"}},
+		UpdateAll: true,
+	}).Create(lineage).Error
+}
+
+func (db *Impl) GetObjectLineage(ctx context.Context, objectId common.Hash) ([]*models.ObjectLineage, []*models.ObjectLineage, error) {
+	visited := map[common.Hash]bool{objectId: true}
+
+	// an object has at most one source, so the ancestors form a single chain
+	var ancestors []*models.ObjectLineage
+	for current := objectId; ; {
+		var lineage models.ObjectLineage
+		err := db.session(ctx).Where("dst_object_id = ?", current).Take(&lineage).Error
+		if errIsNotFound(err) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if visited[lineage.SrcObjectID] {
+			break
+		}
+		visited[lineage.SrcObjectID] = true
+		ancestors = append(ancestors, &lineage)
+		current = lineage.SrcObjectID
+	}
+
+	var descendants []*models.ObjectLineage
+	for frontier := []common.Hash{objectId}; len(frontier) != 0; {
+		var lineages []*models.ObjectLineage
+		err := db.session(ctx).Where("src_object_id IN ?", frontier).Order("id ASC").Find(&lineages).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		frontier = frontier[:0]
+		for _, lineage := range lineages {
+			if visited[lineage.DstObjectID] {
+				continue
+			}
+			visited[lineage.DstObjectID] = true
+			descendants = append(descendants, lineage)
+			frontier = append(frontier, lineage.DstObjectID)
+		}
+	}
+	return ancestors, descendants, nil
 }
 
 func (db *Impl) GetObject(ctx context.Context, objectId common.Hash) (*models.Object, error) {
 	var object models.Object
 
-	err := db.Db.WithContext(ctx).Where(
-		"object_id = ? AND removed IS NOT TRUE", objectId).Find(&object).Error
+	err := db.session(ctx).Where(
+		"object_id = ? AND removed IS NOT TRUE", objectId).Take(&object).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &object, nil
 }
 
+func (db *Impl) GetBucket(ctx context.Context, bucketId common.Hash) (*models.Bucket, error) {
+	var bucket models.Bucket
+
+	err := db.session(ctx).Where("bucket_id = ? AND removed IS NOT TRUE", bucketId).Take(&bucket).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bucket, nil
+}
+
+func (db *Impl) GetBucketByName(ctx context.Context, bucketName string) (*models.Bucket, error) {
+	var bucket models.Bucket
+
+	err := db.session(ctx).Where("bucket_name = ? AND removed IS NOT TRUE", bucketName).Take(&bucket).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bucket, nil
+}
+
+func (db *Impl) UpdateBucketFlowRateLimit(ctx context.Context, bucket *models.Bucket) error {
+	return db.updateBucketSetting(ctx, bucket, "flow_rate_limit")
+}
+
+func (db *Impl) UpdateBucketFlowRateLimitStatus(ctx context.Context, bucket *models.Bucket) error {
+	return db.updateBucketSetting(ctx, bucket, "flow_rate_limited")
+}
+
+func (db *Impl) UpdateBucketSpAsDelegatedAgent(ctx context.Context, bucket *models.Bucket) error {
+	return db.updateBucketSetting(ctx, bucket, "sp_as_delegated_agent_disabled")
+}
+
+// updateBucketSetting writes the column even when it holds a zero value, unlike UpdateBucket
+func (db *Impl) updateBucketSetting(ctx context.Context, bucket *models.Bucket, column string) error {
+	return recordChanges(ctx, db, bucketChanges, where("bucket_id = ?", bucket.BucketID), func(tx *gorm.DB) error {
+		return tx.Table((&models.Bucket{}).TableName()).Where("bucket_id = ?", bucket.BucketID).
+			Select(column, "update_at", "update_tx_hash", "update_time").Updates(bucket).Error
+	})
+}
+
+func (db *Impl) SaveBucketSettingHistory(ctx context.Context, history *models.BucketSettingHistory) error {
+	history.EventIndex = eventPositionFrom(ctx).EventIndex
+	return db.session(ctx).Table((&models.BucketSettingHistory{}).TableName()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "bucket_id"}, {Name: "height"}, {Name: "tx_hash"}, {Name: "event_index"}},
+		DoNothing: true,
+	}).Create(history).Error
+}
+
+func (db *Impl) GetBucketSettingHistory(ctx context.Context, bucketId common.Hash) ([]*models.BucketSettingHistory, error) {
+	var histories []*models.BucketSettingHistory
+
+	err := db.session(ctx).Where("bucket_id = ?", bucketId).Order("height ASC, id ASC").Find(&histories).Error
+	if err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
+
+func (db *Impl) GetBucketFlowRateLimit(ctx context.Context, bucketName string, paymentAddress, owner common.Address) (*common.Big, error) {
+	var history models.BucketSettingHistory
+
+	err := db.session(ctx).Where("bucket_name = ? AND payment_address = ? AND bucket_owner = ? AND setting = ?",
+		bucketName, paymentAddress, owner, models.BucketSettingFlowRateLimit).Order("id DESC").Take(&history).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return history.FlowRateLimit, nil
+}
+
 func (db *Impl) SaveStreamRecord(ctx context.Context, streamRecord *models.StreamRecord) error {
-	err := db.Db.WithContext(ctx).Table((&models.StreamRecord{}).TableName()).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "account"}},
-		UpdateAll: true,
+	// out_flow_count is left out, it is maintained by SaveStreamRecordOutFlows
+	err := db.session(ctx).Table((&models.StreamRecord{}).TableName()).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "account"}},
+		DoUpdates: clause.AssignmentColumns([]string{"crud_timestamp", "netflow_rate", "static_balance", "buffer_balance",
+			"lock_balance", "status", "settle_timestamp", "frozen_netflow_rate"}),
 	}).Create(streamRecord).Error
 	return err
 }
 
 func (db *Impl) SavePaymentAccount(ctx context.Context, paymentAccount *models.PaymentAccount) error {
-	err := db.Db.WithContext(ctx).Table((&models.PaymentAccount{}).TableName()).Clauses(clause.OnConflict{
+	err := db.session(ctx).Table((&models.PaymentAccount{}).TableName()).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "addr"}},
 		UpdateAll: true,
 	}).Create(paymentAccount).Error
 	return err
 }
 
+func (db *Impl) SaveStreamRecordHistory(ctx context.Context, history *models.StreamRecordHistory) error {
+	return db.session(ctx).Table((&models.StreamRecordHistory{}).TableName()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "account"}, {Name: "height"}},
+		UpdateAll: true,
+	}).Create(history).Error
+}
+
+func (db *Impl) GetStreamRecordHistory(ctx context.Context, account common.Address, startTime, endTime int64) ([]*models.StreamRecordHistory, error) {
+	var history []*models.StreamRecordHistory
+
+	err := db.session(ctx).Where("account = ? AND crud_timestamp >= ? AND crud_timestamp < ?", account, startTime, endTime).
+		Order("height ASC").Find(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (db *Impl) SaveStreamRecordOutFlows(ctx context.Context, from common.Address, outFlows []*models.StreamRecordOutFlow) error {
+	return db.session(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("from_address = ?", from).Delete(&models.StreamRecordOutFlow{}).Error; err != nil {
+			return err
+		}
+		if len(outFlows) > 0 {
+			if err := tx.Table((&models.StreamRecordOutFlow{}).TableName()).Create(outFlows).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Table((&models.StreamRecord{}).TableName()).Where("account = ?", from).Update("out_flow_count", len(outFlows)).Error
+	})
+}
+
+func (db *Impl) GetStreamRecordOutFlows(ctx context.Context, from common.Address) ([]*models.StreamRecordOutFlow, error) {
+	var outFlows []*models.StreamRecordOutFlow
+
+	err := db.session(ctx).Where("from_address = ?", from).Order("id ASC").Find(&outFlows).Error
+	if err != nil {
+		return nil, err
+	}
+	return outFlows, nil
+}
+
+func (db *Impl) SavePaymentLedger(ctx context.Context, ledger *models.PaymentLedger) error {
+	ledger.EventIndex = eventPositionFrom(ctx).EventIndex
+	return db.session(ctx).Table((&models.PaymentLedger{}).TableName()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "height"}, {Name: "tx_hash"}, {Name: "event_index"}},
+		DoNothing: true,
+	}).Create(ledger).Error
+}
+
+func (db *Impl) GetPaymentLedger(ctx context.Context, account common.Address, startTime, endTime int64) ([]*models.PaymentLedger, error) {
+	var ledger []*models.PaymentLedger
+
+	err := db.session(ctx).Where("account = ? AND timestamp >= ? AND timestamp < ?", account, startTime, endTime).
+		Order("height ASC, id ASC").Find(&ledger).Error
+	if err != nil {
+		return nil, err
+	}
+	return ledger, nil
+}
+
+// SaveEpoch implements database.Database. The epoch is set to the end of the first gap-free sync range, with the
+// hash and time of epoch when it is that block, of the stored block at that height otherwise. The epoch row is created
+// if missing, then only moved forward: the guarded update leaves it as is when another save moved it at or past
+// that height meanwhile.
 func (db *Impl) SaveEpoch(ctx context.Context, epoch *models.Epoch) error {
-	err := db.Db.Table((&models.Epoch{}).TableName()).Clauses(clause.OnConflict{
+	synced, err := db.GetSyncedHeight(ctx)
+	if err != nil {
+		return err
+	}
+	if synced != uint64(epoch.BlockHeight) {
+		block, err := db.GetBlockByHeight(ctx, synced)
+		if err != nil {
+			return err
+		}
+		epoch = &models.Epoch{OneRowId: true, BlockHeight: int64(synced)}
+		if block != nil {
+			epoch.BlockHash = block.Hash
+			epoch.UpdateTime = int64(block.Timestamp)
+		}
+	}
+
+	q := db.session(ctx)
+	err = q.Table((&models.Epoch{}).TableName()).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "one_row_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"block_height", "block_hash", "update_time"}),
+		DoNothing: true,
 	}).Create(epoch).Error
-	return err
+	if err != nil {
+		return err
+	}
+
+	return q.Table((&models.Epoch{}).TableName()).
+		Where("one_row_id = ? AND block_height < ?", true, epoch.BlockHeight).
+		Updates(map[string]interface{}{
+			"block_height": epoch.BlockHeight,
+			"block_hash":   epoch.BlockHash,
+			"update_time":  epoch.UpdateTime,
+		}).Error
 }
 
 func (db *Impl) GetEpoch(ctx context.Context) (*models.Epoch, error) {
 	var epoch models.Epoch
 
-	err := db.Db.Find(&epoch).Error
+	conn := db.Db
+	if tx := db.snapshot(ctx); tx != nil {
+		conn = tx
+	}
+	err := conn.Find(&epoch).Error
 	if err != nil && !errIsNotFound(err) {
 		return nil, err
 	}
@@ -576,34 +963,92 @@ func (db *Impl) GetEpoch(ctx context.Context) (*models.Epoch, error) {
 }
 
 func (db *Impl) SavePermission(ctx context.Context, permission *models.Permission) error {
-	return db.Db.WithContext(ctx).Table((&models.Permission{}).TableName()).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "principal_type"}, {Name: "principal_value"}, {Name: "resource_type"}, {Name: "resource_id"}},
-		UpdateAll: true,
-	}).Create(permission).Error
+	scope := where("principal_type = ? AND principal_value = ? AND resource_type = ? AND resource_id = ?",
+		permission.PrincipalType, permission.PrincipalValue, permission.ResourceType, permission.ResourceID)
+	return recordChanges(ctx, db, policyChanges, scope, func(tx *gorm.DB) error {
+		return tx.Table((&models.Permission{}).TableName()).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "principal_type"}, {Name: "principal_value"}, {Name: "resource_type"}, {Name: "resource_id"}},
+			UpdateAll: true,
+		}).Create(permission).Error
+	})
 }
 
 func (db *Impl) UpdatePermission(ctx context.Context, permission *models.Permission) error {
-	return db.Db.WithContext(ctx).Table((&models.Permission{}).TableName()).Where("policy_id = ?", permission.PolicyID).Updates(permission).Error
+	return recordChanges(ctx, db, policyChanges, where("policy_id = ?", permission.PolicyID), func(tx *gorm.DB) error {
+		return tx.Table((&models.Permission{}).TableName()).Where("policy_id = ?", permission.PolicyID).Updates(permission).Error
+	})
+}
+
+func (db *Impl) GetPermissionsByResource(ctx context.Context, resourceType string, resourceID common.Hash) ([]*models.Permission, error) {
+	var permissions []*models.Permission
+
+	err := db.session(ctx).Where("resource_type = ? AND resource_id = ? AND removed IS NOT TRUE", resourceType, resourceID).
+		Find(&permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (db *Impl) GetStatementsByPolicyIDs(ctx context.Context, policyIDs []common.Hash) ([]*models.Statements, error) {
+	var statements []*models.Statements
+	if len(policyIDs) == 0 {
+		return statements, nil
+	}
+
+	err := db.session(ctx).Where("policy_id IN ? AND removed IS NOT TRUE", policyIDs).Order("id ASC").Find(&statements).Error
+	if err != nil {
+		return nil, err
+	}
+	return statements, nil
 }
 
 func (db *Impl) CreateGroup(ctx context.Context, groupMembers []*models.Group) error {
-	err := db.Db.WithContext(ctx).Table((&models.Group{}).TableName()).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "group_id"}, {Name: "account_id"}},
-		UpdateAll: true,
-	}).Create(groupMembers).Error
-	return err
+	// the scope may select more rows than the written ones, the rows left unchanged are not recorded
+	groupIDs, accounts := make([]common.Hash, len(groupMembers)), make([]common.Address, len(groupMembers))
+	for i, member := range groupMembers {
+		groupIDs[i], accounts[i] = member.GroupID, member.AccountID
+	}
+	return recordChanges(ctx, db, groupChanges, where("group_id IN ? AND account_id IN ?", groupIDs, accounts), func(tx *gorm.DB) error {
+		return tx.Table((&models.Group{}).TableName()).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "group_id"}, {Name: "account_id"}},
+			UpdateAll: true,
+		}).Create(groupMembers).Error
+	})
 }
 
 func (db *Impl) UpdateGroup(ctx context.Context, group *models.Group) error {
-	return db.Db.WithContext(ctx).Table((&models.Group{}).TableName()).Where("group_id = ? AND account_id = ?", group.GroupID, group.AccountID).Updates(group).Error
+	scope := where("group_id = ? AND account_id = ?", group.GroupID, group.AccountID)
+	return recordChanges(ctx, db, groupChanges, scope, func(tx *gorm.DB) error {
+		return tx.Table((&models.Group{}).TableName()).Where("group_id = ? AND account_id = ?", group.GroupID, group.AccountID).Updates(group).Error
+	})
 }
 
 func (db *Impl) DeleteGroup(ctx context.Context, group *models.Group) error {
-	return db.Db.WithContext(ctx).Table((&models.Group{}).TableName()).Where("group_id = ?", group.GroupID).Updates(group).Error
+	return recordChanges(ctx, db, groupChanges, where("group_id = ?", group.GroupID), func(tx *gorm.DB) error {
+		return tx.Table((&models.Group{}).TableName()).Where("group_id = ?", group.GroupID).Updates(group).Error
+	})
+}
+
+func (db *Impl) GetGroup(ctx context.Context, groupID common.Hash) (*models.Group, error) {
+	return db.GetGroupMember(ctx, groupID, common.HexToAddress("0"))
+}
+
+func (db *Impl) GetGroupMember(ctx context.Context, groupID common.Hash, account common.Address) (*models.Group, error) {
+	var group models.Group
+
+	err := db.session(ctx).Where("group_id = ? AND account_id = ? AND removed IS NOT TRUE", groupID, account).Take(&group).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
 }
 
 func (db *Impl) CreateStorageProvider(ctx context.Context, storageProvider *models.StorageProvider) error {
-	err := db.Db.WithContext(ctx).Table((&models.StorageProvider{}).TableName()).Clauses(clause.OnConflict{
+	err := db.session(ctx).Table((&models.StorageProvider{}).TableName()).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "sp_id"}},
 		UpdateAll: true,
 	}).Create(storageProvider).Error
@@ -611,18 +1056,159 @@ func (db *Impl) CreateStorageProvider(ctx context.Context, storageProvider *mode
 }
 
 func (db *Impl) UpdateStorageProvider(ctx context.Context, storageProvider *models.StorageProvider) error {
-	return db.Db.WithContext(ctx).Table((&models.StorageProvider{}).TableName()).Where("sp_id = ? ", storageProvider.SpId).Updates(storageProvider).Error
+	return db.session(ctx).Table((&models.StorageProvider{}).TableName()).Where("sp_id = ? ", storageProvider.SpId).Updates(storageProvider).Error
+}
+
+func (db *Impl) GetStorageProvider(ctx context.Context, spId uint32) (*models.StorageProvider, error) {
+	var storageProvider models.StorageProvider
+
+	err := db.session(ctx).Where("sp_id = ? AND removed IS NOT TRUE", spId).Take(&storageProvider).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &storageProvider, nil
+}
+
+func (db *Impl) SaveSpStatusHistory(ctx context.Context, history *models.SpStatusHistory) error {
+	history.EventIndex = eventPositionFrom(ctx).EventIndex
+	return db.session(ctx).Table((&models.SpStatusHistory{}).TableName()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "sp_id"}, {Name: "height"}, {Name: "tx_hash"}, {Name: "event_index"}},
+		DoNothing: true,
+	}).Create(history).Error
+}
+
+func (db *Impl) SaveSpPriceHistory(ctx context.Context, price *models.SpPriceHistory) error {
+	return db.session(ctx).Table((&models.SpPriceHistory{}).TableName()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "sp_id"}, {Name: "height"}},
+		UpdateAll: true,
+	}).Create(price).Error
+}
+
+func (db *Impl) GetSpPriceAt(ctx context.Context, spId uint32, timestamp int64) (*models.SpPriceHistory, error) {
+	var price models.SpPriceHistory
+
+	err := db.session(ctx).Where("sp_id = ? AND update_time_sec <= ?", spId, timestamp).
+		Order("update_time_sec DESC, height DESC").Take(&price).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+func (db *Impl) SaveGlobalStorePrice(ctx context.Context, price *models.GlobalStorePrice) error {
+	return db.session(ctx).Table((&models.GlobalStorePrice{}).TableName()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "update_time_sec"}},
+		UpdateAll: true,
+	}).Create(price).Error
+}
+
+func (db *Impl) GetGlobalStorePriceAt(ctx context.Context, timestamp int64) (*models.GlobalStorePrice, error) {
+	var price models.GlobalStorePrice
+
+	err := db.session(ctx).Where("update_time_sec <= ?", timestamp).
+		Order("update_time_sec DESC").Take(&price).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
 }
 
 func (db *Impl) MultiSaveStatement(ctx context.Context, statements []*models.Statements) error {
-	return db.Db.WithContext(ctx).Table((&models.Statements{}).TableName()).Create(statements).Error
+	if len(statements) == 0 {
+		return nil
+	}
+	policyIDs := make([]common.Hash, 0, len(statements))
+	for _, s := range statements {
+		policyIDs = append(policyIDs, s.PolicyID)
+	}
+	return db.session(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("policy_id IN ?", policyIDs).Delete(&models.Statements{}).Error; err != nil {
+			return err
+		}
+		return tx.Table((&models.Statements{}).TableName()).Create(statements).Error
+	})
 }
 
 func (db *Impl) RemoveStatements(ctx context.Context, policyID common.Hash) error {
-	return db.Db.WithContext(ctx).Table((&models.Statements{}).TableName()).Where("policy_id = ?", policyID).Update("removed", true).Error
+	return db.session(ctx).Table((&models.Statements{}).TableName()).Where("policy_id = ?", policyID).Update("removed", true).Error
+}
+
+func (db *Impl) RemovePoliciesByResources(ctx context.Context, resourceType string, resourceIDs []common.Hash, updateTime int64) (int64, error) {
+	if len(resourceIDs) == 0 {
+		return 0, nil
+	}
+
+	var removed int64
+	scope := where("resource_type = ? AND resource_id IN ? AND removed IS NOT TRUE", resourceType, resourceIDs)
+	err := recordChanges(ctx, db, policyChanges, scope, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			policies := tx.Table((&models.Permission{}).TableName()).
+				Where("resource_type = ? AND resource_id IN ? AND removed IS NOT TRUE", resourceType, resourceIDs)
+			if err := tx.Table((&models.Statements{}).TableName()).
+				Where("policy_id IN (?) AND removed IS NOT TRUE", policies.Session(&gorm.Session{}).Select("policy_id")).
+				Update("removed", true).Error; err != nil {
+				return err
+			}
+
+			result := policies.Updates(map[string]interface{}{"removed": true, "update_timestamp": updateTime})
+			removed = result.RowsAffected
+			return result.Error
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+func (db *Impl) RemoveExpiredPolicies(ctx context.Context, now int64) (int64, int64, error) {
+	var policies, statements int64
+	scope := where("expiration_time > 0 AND expiration_time < ? AND removed IS NOT TRUE", now)
+	err := recordChanges(ctx, db, policyChanges, scope, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			expired := tx.Table((&models.Permission{}).TableName()).
+				Where("expiration_time > 0 AND expiration_time < ? AND removed IS NOT TRUE", now)
+			result := tx.Table((&models.Statements{}).TableName()).
+				Where("policy_id IN (?) AND removed IS NOT TRUE", expired.Session(&gorm.Session{}).Select("policy_id")).
+				Update("removed", true)
+			if result.Error != nil {
+				return result.Error
+			}
+			statements = result.RowsAffected
+
+			result = expired.Updates(map[string]interface{}{"removed": true, "update_timestamp": now})
+			if result.Error != nil {
+				return result.Error
+			}
+			policies = result.RowsAffected
+
+			result = tx.Table((&models.Statements{}).TableName()).
+				Where("expiration_time > 0 AND expiration_time < ? AND removed IS NOT TRUE", now).
+				Update("removed", true)
+			statements += result.RowsAffected
+			return result.Error
+		})
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return policies, statements, nil
 }
 
 func (db *Impl) SaveGVG(ctx context.Context, gvg *models.GlobalVirtualGroup) error {
+	if buffer := writeBufferFrom(ctx); buffer != nil {
+		buffer.upsert((&models.GlobalVirtualGroup{}).TableName(), []string{"global_virtual_group_id"}, bufferKey(gvg.GlobalVirtualGroupId), gvg)
+		return nil
+	}
 	err := db.Db.WithContext(ctx).Table((&models.GlobalVirtualGroup{}).TableName()).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "global_virtual_group_id"}},
 		UpdateAll: true,
@@ -631,11 +1217,15 @@ func (db *Impl) SaveGVG(ctx context.Context, gvg *models.GlobalVirtualGroup) err
 }
 
 func (db *Impl) UpdateGVG(ctx context.Context, gvg *models.GlobalVirtualGroup) error {
-	err := db.Db.WithContext(ctx).Table((&models.GlobalVirtualGroup{}).TableName()).Where("global_virtual_group_id = ?", gvg.GlobalVirtualGroupId).Updates(gvg).Error
+	err := db.session(ctx).Table((&models.GlobalVirtualGroup{}).TableName()).Where("global_virtual_group_id = ?", gvg.GlobalVirtualGroupId).Updates(gvg).Error
 	return err
 }
 
 func (db *Impl) SaveLVG(ctx context.Context, lvg *models.LocalVirtualGroup) error {
+	if buffer := writeBufferFrom(ctx); buffer != nil {
+		buffer.upsert((&models.LocalVirtualGroup{}).TableName(), []string{"local_virtual_group_id"}, bufferKey(lvg.LocalVirtualGroupId), lvg)
+		return nil
+	}
 	err := db.Db.WithContext(ctx).Table((&models.LocalVirtualGroup{}).TableName()).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "local_virtual_group_id"}},
 		UpdateAll: true,
@@ -644,11 +1234,15 @@ func (db *Impl) SaveLVG(ctx context.Context, lvg *models.LocalVirtualGroup) erro
 }
 
 func (db *Impl) UpdateLVG(ctx context.Context, lvg *models.LocalVirtualGroup) error {
-	err := db.Db.WithContext(ctx).Table((&models.LocalVirtualGroup{}).TableName()).Where("local_virtual_group_id = ? and bucket_id = ?", lvg.LocalVirtualGroupId, lvg.BucketID).Updates(lvg).Error
+	err := db.session(ctx).Table((&models.LocalVirtualGroup{}).TableName()).Where("local_virtual_group_id = ? and bucket_id = ?", lvg.LocalVirtualGroupId, lvg.BucketID).Updates(lvg).Error
 	return err
 }
 
 func (db *Impl) SaveVGF(ctx context.Context, vgf *models.GlobalVirtualGroupFamily) error {
+	if buffer := writeBufferFrom(ctx); buffer != nil {
+		buffer.upsert((&models.GlobalVirtualGroupFamily{}).TableName(), []string{"global_virtual_group_family_id"}, bufferKey(vgf.GlobalVirtualGroupFamilyId), vgf)
+		return nil
+	}
 	err := db.Db.WithContext(ctx).Table((&models.GlobalVirtualGroupFamily{}).TableName()).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "global_virtual_group_family_id"}},
 		UpdateAll: true,
@@ -657,17 +1251,89 @@ func (db *Impl) SaveVGF(ctx context.Context, vgf *models.GlobalVirtualGroupFamil
 }
 
 func (db *Impl) UpdateVGF(ctx context.Context, vgf *models.GlobalVirtualGroupFamily) error {
-	err := db.Db.WithContext(ctx).Table((&models.GlobalVirtualGroupFamily{}).TableName()).Where("global_virtual_group_family_id = ?", vgf.GlobalVirtualGroupFamilyId).Updates(vgf).Error
+	err := db.session(ctx).Table((&models.GlobalVirtualGroupFamily{}).TableName()).Where("global_virtual_group_family_id = ?", vgf.GlobalVirtualGroupFamilyId).Updates(vgf).Error
 	return err
 }
 
+func (db *Impl) GetGVG(ctx context.Context, gvgId uint32) (*models.GlobalVirtualGroup, error) {
+	var gvg models.GlobalVirtualGroup
+
+	err := db.session(ctx).Where("global_virtual_group_id = ? AND removed IS NOT TRUE", gvgId).Take(&gvg).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &gvg, nil
+}
+
+func (db *Impl) UpdateGVGsByFamily(ctx context.Context, familyId uint32, gvg *models.GlobalVirtualGroup) error {
+	return db.session(ctx).Table((&models.GlobalVirtualGroup{}).TableName()).Where("family_id = ?", familyId).Updates(gvg).Error
+}
+
+func (db *Impl) SaveVgSwap(ctx context.Context, swap *models.VgSwap) error {
+	swap.CreateEventIndex = eventPositionFrom(ctx).EventIndex
+	return db.session(ctx).Table((&models.VgSwap{}).TableName()).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "create_at"}, {Name: "create_tx_hash"}, {Name: "create_event_index"},
+			{Name: "global_virtual_group_family_id"}, {Name: "global_virtual_group_id"}},
+		DoNothing: true,
+	}).Create(swap).Error
+}
+
+func (db *Impl) UpdateVgSwap(ctx context.Context, fromStatus string, swap *models.VgSwap) error {
+	pos := eventPositionFrom(ctx)
+	return db.session(ctx).Table((&models.VgSwap{}).TableName()).
+		Where("swap_type = ? AND global_virtual_group_family_id = ? AND global_virtual_group_id = ? AND status = ?",
+			swap.SwapType, swap.GlobalVirtualGroupFamilyId, swap.GlobalVirtualGroupId, fromStatus).
+		Not("create_at = ? AND create_tx_hash = ? AND create_event_index = ?", swap.UpdateAt, swap.UpdateTxHash, pos.EventIndex).
+		Updates(swap).Error
+}
+
 func (db *Impl) SaveDBStatistics(ctx context.Context, ds *models.DataStat) error {
 	return nil
 }
 
-func (db *Impl) Begin(ctx context.Context) *Impl {
+// SaveChallenge keeps the attestation of the challenge if it is already indexed, since blocks are not
+// necessarily processed in order
+func (db *Impl) SaveChallenge(ctx context.Context, challenge *models.Challenge) error {
+	return db.session(ctx).Table((&models.Challenge{}).TableName()).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "challenge_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"object_id", "segment_index", "sp_id", "sp_operator_address",
+			"redundancy_index", "challenger_address", "expired_height", "create_at", "create_tx_hash", "create_time"}),
+	}).Create(challenge).Error
+}
+
+// UpdateChallenge creates the challenge if its start is not indexed, e.g. because it started before
+// the first indexed height
+func (db *Impl) UpdateChallenge(ctx context.Context, challenge *models.Challenge) error {
+	return db.session(ctx).Table((&models.Challenge{}).TableName()).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "challenge_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"attest_result", "slash_amount", "submitter_address",
+			"attest_at", "attest_tx_hash", "attest_time"}),
+	}).Create(challenge).Error
+}
+
+func (db *Impl) GetChallengesBySp(ctx context.Context, spId uint32, offset, limit int) ([]*models.ChallengeDetail, error) {
+	var challenges []*models.ChallengeDetail
+
+	err := db.session(ctx).Table((&models.Challenge{}).TableName()+" AS c").
+		Select("c.*, o.bucket_name, o.object_name, sp.moniker AS sp_moniker, sp.endpoint AS sp_endpoint").
+		Joins("LEFT JOIN "+(&models.Object{}).TableName()+" AS o ON o.object_id = c.object_id").
+		Joins("LEFT JOIN "+(&models.StorageProvider{}).TableName()+" AS sp ON sp.sp_id = c.sp_id").
+		Where("c.sp_id = ?", spId).
+		Order("c.challenge_id DESC").Offset(offset).Limit(limit).
+		Scan(&challenges).Error
+	if err != nil {
+		return nil, err
+	}
+	return challenges, nil
+}
+
+func (db *Impl) Begin(ctx context.Context) Database {
 	return &Impl{
-		Db: db.Db.WithContext(ctx).Begin(),
+		Db:     db.Db.WithContext(ctx).Begin(),
+		Outbox: db.Outbox,
 	}
 }
 
@@ -681,8 +1347,7 @@ func (db *Impl) Commit() error {
 
 // Close implements database.Database
 func (db *Impl) Close() {
-	var err error
-	if err != nil {
+	if err := sqlclient.Close(db.Db); err != nil {
 		log.Errorw("error while closing connection", "err", err)
 	}
 }
@@ -692,34 +1357,30 @@ func (db *Impl) Close() {
 // GetLastPruned implements database.PruningDb
 func (db *Impl) GetLastPruned() (int64, error) {
 	var lastPrunedHeight int64
-	err := db.Db.Raw(`SELECT coalesce(MAX(last_pruned_height),0) FROM pruning LIMIT 1;`).Scan(&lastPrunedHeight).Error
+	err := db.Db.Model(&models.Pruning{}).Select("coalesce(MAX(last_pruned_height), 0)").Scan(&lastPrunedHeight).Error
 	return lastPrunedHeight, err
 }
 
 // StoreLastPruned implements database.PruningDb
 func (db *Impl) StoreLastPruned(height int64) error {
-	err := db.Db.Exec(`DELETE FROM pruning`).Error
-	if err != nil {
-		return err
-	}
-
-	err = db.Db.Exec(`INSERT INTO pruning (last_pruned_height) VALUES ($1)`, height).Error
-	return err
+	return db.Db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Pruning{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&models.Pruning{LastPrunedHeight: height}).Error
+	})
 }
 
 // Prune implements database.PruningDb
 func (db *Impl) Prune(height int64) error {
-	err := db.Db.Exec(`DELETE FROM pre_commit WHERE height = $1`, height).Error
-	if err != nil {
-		return err
-	}
-
-	err = db.Db.Exec(`
-DELETE FROM message 
-USING transaction 
-WHERE message.transaction_hash = transaction.hash AND transaction.height = $1
-`, height).Error
-	return err
+	return db.Db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("height = ?", height).Delete(&models.Tx{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("height = ?", height).Delete(&models.Block{}).Error
+	})
 }
 
 func errIsNotFound(err error) bool {
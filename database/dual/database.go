@@ -0,0 +1,781 @@
+package dual
+
+import (
+	"context"
+
+	"gorm.io/gorm/schema"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/log"
+	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/types"
+)
+
+// PrepareTables implements database.Database. Once the tables exist, the watermarks are loaded from the databases.
+func (db *Database) PrepareTables(ctx context.Context, tables []schema.Tabler) error {
+	err := db.all(ctx, func(s side) error {
+		return s.db.PrepareTables(s.ctx, tables)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = db.LoadWatermarks(ctx); err != nil {
+		log.Debugw("watermarks not loaded", "err", err)
+	}
+	return nil
+}
+
+// AutoMigrate implements database.Database
+func (db *Database) AutoMigrate(ctx context.Context, tables []schema.Tabler) error {
+	return db.all(ctx, func(s side) error {
+		return s.db.AutoMigrate(s.ctx, tables)
+	})
+}
+
+// PrepareArchiveTables implements database.Database
+func (db *Database) PrepareArchiveTables(ctx context.Context) error {
+	return db.all(ctx, func(s side) error {
+		return s.db.PrepareArchiveTables(s.ctx)
+	})
+}
+
+// CheckSchemaVersion implements database.Database
+func (db *Database) CheckSchemaVersion(ctx context.Context) error {
+	return db.all(ctx, func(s side) error {
+		return s.db.CheckSchemaVersion(s.ctx)
+	})
+}
+
+// MigrateUp implements database.Database. The migrations run on the authoritative database are returned.
+func (db *Database) MigrateUp(ctx context.Context, steps int) ([]database.Migration, error) {
+	var applied []database.Migration
+	err := db.all(ctx, func(s side) error {
+		migrations, err := s.db.MigrateUp(s.ctx, steps)
+		if s.authoritative {
+			applied = migrations
+		}
+		return err
+	})
+	return applied, err
+}
+
+// MigrateDown implements database.Database. The migrations reverted on the authoritative database are returned.
+func (db *Database) MigrateDown(ctx context.Context, steps int) ([]database.Migration, error) {
+	var reverted []database.Migration
+	err := db.all(ctx, func(s side) error {
+		migrations, err := s.db.MigrateDown(s.ctx, steps)
+		if s.authoritative {
+			reverted = migrations
+		}
+		return err
+	})
+	return reverted, err
+}
+
+// RemovePoliciesByResources implements database.Database. The count of the authoritative database is returned.
+func (db *Database) RemovePoliciesByResources(ctx context.Context, resourceType string, resourceIDs []common.Hash, updateTime int64) (int64, error) {
+	var removed int64
+	err := db.write(ctx, "RemovePoliciesByResources", func(s side) error {
+		count, err := s.db.RemovePoliciesByResources(s.ctx, resourceType, resourceIDs, updateTime)
+		if s.authoritative {
+			removed = count
+		}
+		return err
+	})
+	return removed, err
+}
+
+// RemoveExpiredPolicies implements database.Database. The counts of the authoritative database are returned.
+func (db *Database) RemoveExpiredPolicies(ctx context.Context, now int64) (int64, int64, error) {
+	var policies, statements int64
+	err := db.write(ctx, "RemoveExpiredPolicies", func(s side) error {
+		removedPolicies, removedStatements, err := s.db.RemoveExpiredPolicies(s.ctx, now)
+		if s.authoritative {
+			policies, statements = removedPolicies, removedStatements
+		}
+		return err
+	})
+	return policies, statements, err
+}
+
+// ArchiveRemoved implements database.Database. The count of the authoritative database is returned.
+func (db *Database) ArchiveRemoved(ctx context.Context, table string, before int64, batchSize int) (int64, error) {
+	var moved int64
+	err := db.write(ctx, "ArchiveRemoved", func(s side) error {
+		count, err := s.db.ArchiveRemoved(s.ctx, table, before, batchSize)
+		if s.authoritative {
+			moved = count
+		}
+		return err
+	})
+	return moved, err
+}
+
+// -------------------------------------------------------------------------------------------------------------------
+
+// GetMigrationStatus implements database.Database
+func (db *Database) GetMigrationStatus(ctx context.Context) ([]*database.MigrationStatus, error) {
+	d, ctx := db.read(ctx)
+	return d.GetMigrationStatus(ctx)
+}
+
+// HasBlock implements database.Database
+func (db *Database) HasBlock(ctx context.Context, height uint64) (bool, error) {
+	d, ctx := db.read(ctx)
+	return d.HasBlock(ctx, height)
+}
+
+// GetLastBlockHeight implements database.Database
+func (db *Database) GetLastBlockHeight(ctx context.Context) (uint64, error) {
+	d, ctx := db.read(ctx)
+	return d.GetLastBlockHeight(ctx)
+}
+
+// GetMissingHeights implements database.Database
+func (db *Database) GetMissingHeights(ctx context.Context, startHeight, endHeight uint64) []uint64 {
+	d, ctx := db.read(ctx)
+	return d.GetMissingHeights(ctx, startHeight, endHeight)
+}
+
+// GetSyncGaps implements database.Database
+func (db *Database) GetSyncGaps(ctx context.Context, startHeight, endHeight uint64) ([]database.HeightRange, error) {
+	d, ctx := db.read(ctx)
+	return d.GetSyncGaps(ctx, startHeight, endHeight)
+}
+
+// GetTotalBlocks implements database.Database
+func (db *Database) GetTotalBlocks(ctx context.Context) int64 {
+	d, ctx := db.read(ctx)
+	return d.GetTotalBlocks(ctx)
+}
+
+// GetBucketSettingHistory implements database.Database
+func (db *Database) GetBucketSettingHistory(ctx context.Context, bucketId common.Hash) ([]*models.BucketSettingHistory, error) {
+	d, ctx := db.read(ctx)
+	return d.GetBucketSettingHistory(ctx, bucketId)
+}
+
+// GetBucketFlowRateLimit implements database.Database
+func (db *Database) GetBucketFlowRateLimit(ctx context.Context, bucketName string, paymentAddress, owner common.Address) (*common.Big, error) {
+	d, ctx := db.read(ctx)
+	return d.GetBucketFlowRateLimit(ctx, bucketName, paymentAddress, owner)
+}
+
+// GetObjectLineage implements database.Database
+func (db *Database) GetObjectLineage(ctx context.Context, objectId common.Hash) ([]*models.ObjectLineage, []*models.ObjectLineage, error) {
+	d, ctx := db.read(ctx)
+	return d.GetObjectLineage(ctx, objectId)
+}
+
+// Snapshot implements database.Repository. The snapshot is taken on the authoritative database.
+func (db *Database) Snapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	d, ctx := db.read(ctx)
+	return d.Snapshot(ctx, fn)
+}
+
+// GetObject implements database.Repository
+func (db *Database) GetObject(ctx context.Context, objectId common.Hash) (*models.Object, error) {
+	d, ctx := db.read(ctx)
+	return d.GetObject(ctx, objectId)
+}
+
+// GetBucket implements database.Repository
+func (db *Database) GetBucket(ctx context.Context, bucketId common.Hash) (*models.Bucket, error) {
+	d, ctx := db.read(ctx)
+	return d.GetBucket(ctx, bucketId)
+}
+
+// GetBucketByName implements database.Repository
+func (db *Database) GetBucketByName(ctx context.Context, bucketName string) (*models.Bucket, error) {
+	d, ctx := db.read(ctx)
+	return d.GetBucketByName(ctx, bucketName)
+}
+
+// GetEpoch implements database.Repository
+func (db *Database) GetEpoch(ctx context.Context) (*models.Epoch, error) {
+	d, ctx := db.read(ctx)
+	return d.GetEpoch(ctx)
+}
+
+// GetSyncedHeight implements database.Repository
+func (db *Database) GetSyncedHeight(ctx context.Context) (uint64, error) {
+	d, ctx := db.read(ctx)
+	return d.GetSyncedHeight(ctx)
+}
+
+// GetStreamRecordHistory implements database.Database
+func (db *Database) GetStreamRecordHistory(ctx context.Context, account common.Address, startTime, endTime int64) ([]*models.StreamRecordHistory, error) {
+	d, ctx := db.read(ctx)
+	return d.GetStreamRecordHistory(ctx, account, startTime, endTime)
+}
+
+// GetStreamRecordOutFlows implements database.Database
+func (db *Database) GetStreamRecordOutFlows(ctx context.Context, from common.Address) ([]*models.StreamRecordOutFlow, error) {
+	d, ctx := db.read(ctx)
+	return d.GetStreamRecordOutFlows(ctx, from)
+}
+
+// GetPaymentLedger implements database.Database
+func (db *Database) GetPaymentLedger(ctx context.Context, account common.Address, startTime, endTime int64) ([]*models.PaymentLedger, error) {
+	d, ctx := db.read(ctx)
+	return d.GetPaymentLedger(ctx, account, startTime, endTime)
+}
+
+// GetPermissionsByResource implements database.Database
+func (db *Database) GetPermissionsByResource(ctx context.Context, resourceType string, resourceID common.Hash) ([]*models.Permission, error) {
+	d, ctx := db.read(ctx)
+	return d.GetPermissionsByResource(ctx, resourceType, resourceID)
+}
+
+// GetStatementsByPolicyIDs implements database.Database
+func (db *Database) GetStatementsByPolicyIDs(ctx context.Context, policyIDs []common.Hash) ([]*models.Statements, error) {
+	d, ctx := db.read(ctx)
+	return d.GetStatementsByPolicyIDs(ctx, policyIDs)
+}
+
+// GetGroup implements database.Repository
+func (db *Database) GetGroup(ctx context.Context, groupID common.Hash) (*models.Group, error) {
+	d, ctx := db.read(ctx)
+	return d.GetGroup(ctx, groupID)
+}
+
+// GetGroupByName implements database.Repository
+func (db *Database) GetGroupByName(ctx context.Context, owner common.Address, groupName string) (*models.Group, error) {
+	d, ctx := db.read(ctx)
+	return d.GetGroupByName(ctx, owner, groupName)
+}
+
+// ListGroupsByOwner implements database.Repository
+func (db *Database) ListGroupsByOwner(ctx context.Context, owner common.Address, page database.Page) ([]*models.Group, string, error) {
+	d, ctx := db.read(ctx)
+	return d.ListGroupsByOwner(ctx, owner, page)
+}
+
+// GetGroupMember implements database.Repository
+func (db *Database) GetGroupMember(ctx context.Context, groupID common.Hash, account common.Address) (*models.Group, error) {
+	d, ctx := db.read(ctx)
+	return d.GetGroupMember(ctx, groupID, account)
+}
+
+// GetStorageProvider implements database.Repository
+func (db *Database) GetStorageProvider(ctx context.Context, spId uint32) (*models.StorageProvider, error) {
+	d, ctx := db.read(ctx)
+	return d.GetStorageProvider(ctx, spId)
+}
+
+// GetSpPriceAt implements database.Database
+func (db *Database) GetSpPriceAt(ctx context.Context, spId uint32, timestamp int64) (*models.SpPriceHistory, error) {
+	d, ctx := db.read(ctx)
+	return d.GetSpPriceAt(ctx, spId, timestamp)
+}
+
+// GetGlobalStorePriceAt implements database.Database
+func (db *Database) GetGlobalStorePriceAt(ctx context.Context, timestamp int64) (*models.GlobalStorePrice, error) {
+	d, ctx := db.read(ctx)
+	return d.GetGlobalStorePriceAt(ctx, timestamp)
+}
+
+// GetGVG implements database.Repository
+func (db *Database) GetGVG(ctx context.Context, gvgId uint32) (*models.GlobalVirtualGroup, error) {
+	d, ctx := db.read(ctx)
+	return d.GetGVG(ctx, gvgId)
+}
+
+// GetChallengesBySp implements database.Database
+func (db *Database) GetChallengesBySp(ctx context.Context, spId uint32, offset, limit int) ([]*models.ChallengeDetail, error) {
+	d, ctx := db.read(ctx)
+	return d.GetChallengesBySp(ctx, spId, offset, limit)
+}
+
+// SaveBlock implements database.Database
+func (db *Database) SaveBlock(ctx context.Context, block *models.Block) error {
+	shadow := clone(block)
+	return db.write(ctx, "SaveBlock", func(s side) error {
+		return s.db.SaveBlock(s.ctx, pick(s, block, shadow))
+	})
+}
+
+// SaveBucket implements database.Database
+func (db *Database) SaveBucket(ctx context.Context, bucket *models.Bucket) error {
+	shadow := clone(bucket)
+	return db.write(ctx, "SaveBucket", func(s side) error {
+		return s.db.SaveBucket(s.ctx, pick(s, bucket, shadow))
+	})
+}
+
+// UpdateBucket implements database.Database
+func (db *Database) UpdateBucket(ctx context.Context, bucket *models.Bucket) error {
+	shadow := clone(bucket)
+	return db.write(ctx, "UpdateBucket", func(s side) error {
+		return s.db.UpdateBucket(s.ctx, pick(s, bucket, shadow))
+	})
+}
+
+// UpdateBucketFlowRateLimit implements database.Database
+func (db *Database) UpdateBucketFlowRateLimit(ctx context.Context, bucket *models.Bucket) error {
+	shadow := clone(bucket)
+	return db.write(ctx, "UpdateBucketFlowRateLimit", func(s side) error {
+		return s.db.UpdateBucketFlowRateLimit(s.ctx, pick(s, bucket, shadow))
+	})
+}
+
+// UpdateBucketFlowRateLimitStatus implements database.Database
+func (db *Database) UpdateBucketFlowRateLimitStatus(ctx context.Context, bucket *models.Bucket) error {
+	shadow := clone(bucket)
+	return db.write(ctx, "UpdateBucketFlowRateLimitStatus", func(s side) error {
+		return s.db.UpdateBucketFlowRateLimitStatus(s.ctx, pick(s, bucket, shadow))
+	})
+}
+
+// UpdateBucketSpAsDelegatedAgent implements database.Database
+func (db *Database) UpdateBucketSpAsDelegatedAgent(ctx context.Context, bucket *models.Bucket) error {
+	shadow := clone(bucket)
+	return db.write(ctx, "UpdateBucketSpAsDelegatedAgent", func(s side) error {
+		return s.db.UpdateBucketSpAsDelegatedAgent(s.ctx, pick(s, bucket, shadow))
+	})
+}
+
+// SaveBucketSettingHistory implements database.Database
+func (db *Database) SaveBucketSettingHistory(ctx context.Context, history *models.BucketSettingHistory) error {
+	shadow := clone(history)
+	return db.write(ctx, "SaveBucketSettingHistory", func(s side) error {
+		return s.db.SaveBucketSettingHistory(s.ctx, pick(s, history, shadow))
+	})
+}
+
+// SaveObject implements database.Database
+func (db *Database) SaveObject(ctx context.Context, object *models.Object) error {
+	shadow := clone(object)
+	return db.write(ctx, "SaveObject", func(s side) error {
+		return s.db.SaveObject(s.ctx, pick(s, object, shadow))
+	})
+}
+
+// UpdateObject implements database.Database
+func (db *Database) UpdateObject(ctx context.Context, object *models.Object) error {
+	shadow := clone(object)
+	return db.write(ctx, "UpdateObject", func(s side) error {
+		return s.db.UpdateObject(s.ctx, pick(s, object, shadow))
+	})
+}
+
+// SaveObjectLineage implements database.Database
+func (db *Database) SaveObjectLineage(ctx context.Context, lineage *models.ObjectLineage) error {
+	shadow := clone(lineage)
+	return db.write(ctx, "SaveObjectLineage", func(s side) error {
+		return s.db.SaveObjectLineage(s.ctx, pick(s, lineage, shadow))
+	})
+}
+
+// SaveEpoch implements database.Database. The epoch is not recorded in the non authoritative database
+// once it diverged.
+func (db *Database) SaveEpoch(ctx context.Context, epoch *models.Epoch) error {
+	shadow := clone(epoch)
+	return db.progress(ctx, "SaveEpoch", func(s side) error {
+		return s.db.SaveEpoch(s.ctx, pick(s, epoch, shadow))
+	})
+}
+
+// SavePaymentAccount implements database.Database
+func (db *Database) SavePaymentAccount(ctx context.Context, paymentAccount *models.PaymentAccount) error {
+	shadow := clone(paymentAccount)
+	return db.write(ctx, "SavePaymentAccount", func(s side) error {
+		return s.db.SavePaymentAccount(s.ctx, pick(s, paymentAccount, shadow))
+	})
+}
+
+// SaveStreamRecord implements database.Database
+func (db *Database) SaveStreamRecord(ctx context.Context, streamRecord *models.StreamRecord) error {
+	shadow := clone(streamRecord)
+	return db.write(ctx, "SaveStreamRecord", func(s side) error {
+		return s.db.SaveStreamRecord(s.ctx, pick(s, streamRecord, shadow))
+	})
+}
+
+// SaveStreamRecordHistory implements database.Database
+func (db *Database) SaveStreamRecordHistory(ctx context.Context, history *models.StreamRecordHistory) error {
+	shadow := clone(history)
+	return db.write(ctx, "SaveStreamRecordHistory", func(s side) error {
+		return s.db.SaveStreamRecordHistory(s.ctx, pick(s, history, shadow))
+	})
+}
+
+// SavePaymentLedger implements database.Database
+func (db *Database) SavePaymentLedger(ctx context.Context, ledger *models.PaymentLedger) error {
+	shadow := clone(ledger)
+	return db.write(ctx, "SavePaymentLedger", func(s side) error {
+		return s.db.SavePaymentLedger(s.ctx, pick(s, ledger, shadow))
+	})
+}
+
+// SavePermission implements database.Database
+func (db *Database) SavePermission(ctx context.Context, permission *models.Permission) error {
+	shadow := clone(permission)
+	return db.write(ctx, "SavePermission", func(s side) error {
+		return s.db.SavePermission(s.ctx, pick(s, permission, shadow))
+	})
+}
+
+// UpdatePermission implements database.Database
+func (db *Database) UpdatePermission(ctx context.Context, permission *models.Permission) error {
+	shadow := clone(permission)
+	return db.write(ctx, "UpdatePermission", func(s side) error {
+		return s.db.UpdatePermission(s.ctx, pick(s, permission, shadow))
+	})
+}
+
+// UpdateGroup implements database.Database
+func (db *Database) UpdateGroup(ctx context.Context, group *models.Group) error {
+	shadow := clone(group)
+	return db.write(ctx, "UpdateGroup", func(s side) error {
+		return s.db.UpdateGroup(s.ctx, pick(s, group, shadow))
+	})
+}
+
+// DeleteGroup implements database.Database
+func (db *Database) DeleteGroup(ctx context.Context, group *models.Group) error {
+	shadow := clone(group)
+	return db.write(ctx, "DeleteGroup", func(s side) error {
+		return s.db.DeleteGroup(s.ctx, pick(s, group, shadow))
+	})
+}
+
+// CreateStorageProvider implements database.Database
+func (db *Database) CreateStorageProvider(ctx context.Context, storageProvider *models.StorageProvider) error {
+	shadow := clone(storageProvider)
+	return db.write(ctx, "CreateStorageProvider", func(s side) error {
+		return s.db.CreateStorageProvider(s.ctx, pick(s, storageProvider, shadow))
+	})
+}
+
+// UpdateStorageProvider implements database.Database
+func (db *Database) UpdateStorageProvider(ctx context.Context, storageProvider *models.StorageProvider) error {
+	shadow := clone(storageProvider)
+	return db.write(ctx, "UpdateStorageProvider", func(s side) error {
+		return s.db.UpdateStorageProvider(s.ctx, pick(s, storageProvider, shadow))
+	})
+}
+
+// SaveSpStatusHistory implements database.Database
+func (db *Database) SaveSpStatusHistory(ctx context.Context, history *models.SpStatusHistory) error {
+	shadow := clone(history)
+	return db.write(ctx, "SaveSpStatusHistory", func(s side) error {
+		return s.db.SaveSpStatusHistory(s.ctx, pick(s, history, shadow))
+	})
+}
+
+// SaveSpPriceHistory implements database.Database
+func (db *Database) SaveSpPriceHistory(ctx context.Context, price *models.SpPriceHistory) error {
+	shadow := clone(price)
+	return db.write(ctx, "SaveSpPriceHistory", func(s side) error {
+		return s.db.SaveSpPriceHistory(s.ctx, pick(s, price, shadow))
+	})
+}
+
+// SaveGlobalStorePrice implements database.Database
+func (db *Database) SaveGlobalStorePrice(ctx context.Context, price *models.GlobalStorePrice) error {
+	shadow := clone(price)
+	return db.write(ctx, "SaveGlobalStorePrice", func(s side) error {
+		return s.db.SaveGlobalStorePrice(s.ctx, pick(s, price, shadow))
+	})
+}
+
+// SaveGVG implements database.Database
+func (db *Database) SaveGVG(ctx context.Context, gvg *models.GlobalVirtualGroup) error {
+	shadow := clone(gvg)
+	return db.write(ctx, "SaveGVG", func(s side) error {
+		return s.db.SaveGVG(s.ctx, pick(s, gvg, shadow))
+	})
+}
+
+// UpdateGVG implements database.Database
+func (db *Database) UpdateGVG(ctx context.Context, gvg *models.GlobalVirtualGroup) error {
+	shadow := clone(gvg)
+	return db.write(ctx, "UpdateGVG", func(s side) error {
+		return s.db.UpdateGVG(s.ctx, pick(s, gvg, shadow))
+	})
+}
+
+// SaveLVG implements database.Database
+func (db *Database) SaveLVG(ctx context.Context, lvg *models.LocalVirtualGroup) error {
+	shadow := clone(lvg)
+	return db.write(ctx, "SaveLVG", func(s side) error {
+		return s.db.SaveLVG(s.ctx, pick(s, lvg, shadow))
+	})
+}
+
+// UpdateLVG implements database.Database
+func (db *Database) UpdateLVG(ctx context.Context, lvg *models.LocalVirtualGroup) error {
+	shadow := clone(lvg)
+	return db.write(ctx, "UpdateLVG", func(s side) error {
+		return s.db.UpdateLVG(s.ctx, pick(s, lvg, shadow))
+	})
+}
+
+// SaveVGF implements database.Database
+func (db *Database) SaveVGF(ctx context.Context, vgf *models.GlobalVirtualGroupFamily) error {
+	shadow := clone(vgf)
+	return db.write(ctx, "SaveVGF", func(s side) error {
+		return s.db.SaveVGF(s.ctx, pick(s, vgf, shadow))
+	})
+}
+
+// UpdateVGF implements database.Database
+func (db *Database) UpdateVGF(ctx context.Context, vgf *models.GlobalVirtualGroupFamily) error {
+	shadow := clone(vgf)
+	return db.write(ctx, "UpdateVGF", func(s side) error {
+		return s.db.UpdateVGF(s.ctx, pick(s, vgf, shadow))
+	})
+}
+
+// UpdateGVGsByFamily implements database.Database
+func (db *Database) UpdateGVGsByFamily(ctx context.Context, familyId uint32, gvg *models.GlobalVirtualGroup) error {
+	shadow := clone(gvg)
+	return db.write(ctx, "UpdateGVGsByFamily", func(s side) error {
+		return s.db.UpdateGVGsByFamily(s.ctx, familyId, pick(s, gvg, shadow))
+	})
+}
+
+// SaveVgSwap implements database.Database
+func (db *Database) SaveVgSwap(ctx context.Context, swap *models.VgSwap) error {
+	shadow := clone(swap)
+	return db.write(ctx, "SaveVgSwap", func(s side) error {
+		return s.db.SaveVgSwap(s.ctx, pick(s, swap, shadow))
+	})
+}
+
+// UpdateVgSwap implements database.Database
+func (db *Database) UpdateVgSwap(ctx context.Context, fromStatus string, swap *models.VgSwap) error {
+	shadow := clone(swap)
+	return db.write(ctx, "UpdateVgSwap", func(s side) error {
+		return s.db.UpdateVgSwap(s.ctx, fromStatus, pick(s, swap, shadow))
+	})
+}
+
+// SaveDBStatistics implements database.Database
+func (db *Database) SaveDBStatistics(ctx context.Context, ds *models.DataStat) error {
+	shadow := clone(ds)
+	return db.write(ctx, "SaveDBStatistics", func(s side) error {
+		return s.db.SaveDBStatistics(s.ctx, pick(s, ds, shadow))
+	})
+}
+
+// SaveChallenge implements database.Database
+func (db *Database) SaveChallenge(ctx context.Context, challenge *models.Challenge) error {
+	shadow := clone(challenge)
+	return db.write(ctx, "SaveChallenge", func(s side) error {
+		return s.db.SaveChallenge(s.ctx, pick(s, challenge, shadow))
+	})
+}
+
+// UpdateChallenge implements database.Database
+func (db *Database) UpdateChallenge(ctx context.Context, challenge *models.Challenge) error {
+	shadow := clone(challenge)
+	return db.write(ctx, "UpdateChallenge", func(s side) error {
+		return s.db.UpdateChallenge(s.ctx, pick(s, challenge, shadow))
+	})
+}
+
+// CreateGroup implements database.Database
+func (db *Database) CreateGroup(ctx context.Context, groupMembers []*models.Group) error {
+	shadow := cloneAll(groupMembers)
+	return db.write(ctx, "CreateGroup", func(s side) error {
+		return s.db.CreateGroup(s.ctx, pick(s, groupMembers, shadow))
+	})
+}
+
+// MultiSaveStatement implements database.Database
+func (db *Database) MultiSaveStatement(ctx context.Context, statements []*models.Statements) error {
+	shadow := cloneAll(statements)
+	return db.write(ctx, "MultiSaveStatement", func(s side) error {
+		return s.db.MultiSaveStatement(s.ctx, pick(s, statements, shadow))
+	})
+}
+
+// SaveStreamRecordOutFlows implements database.Database
+func (db *Database) SaveStreamRecordOutFlows(ctx context.Context, from common.Address, outFlows []*models.StreamRecordOutFlow) error {
+	shadow := cloneAll(outFlows)
+	return db.write(ctx, "SaveStreamRecordOutFlows", func(s side) error {
+		return s.db.SaveStreamRecordOutFlows(s.ctx, from, pick(s, outFlows, shadow))
+	})
+}
+
+// SaveTx implements database.Database
+func (db *Database) SaveTx(ctx context.Context, blockTimestamp uint64, index int, tx *types.Tx) error {
+	return db.write(ctx, "SaveTx", func(s side) error {
+		return s.db.SaveTx(s.ctx, blockTimestamp, index, tx)
+	})
+}
+
+// SaveCommitSignatures implements database.Database
+func (db *Database) SaveCommitSignatures(ctx context.Context, signatures []*types.CommitSig) error {
+	return db.write(ctx, "SaveCommitSignatures", func(s side) error {
+		return s.db.SaveCommitSignatures(s.ctx, signatures)
+	})
+}
+
+// RemoveStatements implements database.Database
+func (db *Database) RemoveStatements(ctx context.Context, policyID common.Hash) error {
+	return db.write(ctx, "RemoveStatements", func(s side) error {
+		return s.db.RemoveStatements(s.ctx, policyID)
+	})
+}
+
+// SearchBuckets implements database.Repository
+func (db *Database) SearchBuckets(ctx context.Context, search database.ArchiveSearch) ([]*models.Bucket, error) {
+	d, ctx := db.read(ctx)
+	return d.SearchBuckets(ctx, search)
+}
+
+// SearchObjects implements database.Repository
+func (db *Database) SearchObjects(ctx context.Context, search database.ArchiveSearch) ([]*models.Object, error) {
+	d, ctx := db.read(ctx)
+	return d.SearchObjects(ctx, search)
+}
+
+// SearchGroups implements database.Repository
+func (db *Database) SearchGroups(ctx context.Context, search database.ArchiveSearch) ([]*models.Group, error) {
+	d, ctx := db.read(ctx)
+	return d.SearchGroups(ctx, search)
+}
+
+// ListBlocks implements database.Repository
+func (db *Database) ListBlocks(ctx context.Context, page database.Page) ([]*models.Block, string, error) {
+	d, ctx := db.read(ctx)
+	return d.ListBlocks(ctx, page)
+}
+
+// GetBlockByHeight implements database.Repository
+func (db *Database) GetBlockByHeight(ctx context.Context, height uint64) (*models.Block, error) {
+	d, ctx := db.read(ctx)
+	return d.GetBlockByHeight(ctx, height)
+}
+
+// GetTxByHash implements database.Repository
+func (db *Database) GetTxByHash(ctx context.Context, hash common.Hash) (*models.Tx, error) {
+	d, ctx := db.read(ctx)
+	return d.GetTxByHash(ctx, hash)
+}
+
+// ListTxsByHeight implements database.Repository
+func (db *Database) ListTxsByHeight(ctx context.Context, height uint64, page database.Page) ([]*models.Tx, string, error) {
+	d, ctx := db.read(ctx)
+	return d.ListTxsByHeight(ctx, height, page)
+}
+
+// GetBucketsByIDs implements database.Repository
+func (db *Database) GetBucketsByIDs(ctx context.Context, bucketIDs []common.Hash) ([]*models.Bucket, error) {
+	d, ctx := db.read(ctx)
+	return d.GetBucketsByIDs(ctx, bucketIDs)
+}
+
+// ListBucketsByOwner implements database.Repository
+func (db *Database) ListBucketsByOwner(ctx context.Context, owner common.Address, page database.Page) ([]*models.Bucket, string, error) {
+	d, ctx := db.read(ctx)
+	return d.ListBucketsByOwner(ctx, owner, page)
+}
+
+// ListObjectsByPrefix implements database.Repository
+func (db *Database) ListObjectsByPrefix(ctx context.Context, bucketName, prefix string, page database.Page) ([]*models.Object, string, error) {
+	d, ctx := db.read(ctx)
+	return d.ListObjectsByPrefix(ctx, bucketName, prefix, page)
+}
+
+// GetObjectByName implements database.Repository
+func (db *Database) GetObjectByName(ctx context.Context, bucketName, objectName string) (*models.Object, error) {
+	d, ctx := db.read(ctx)
+	return d.GetObjectByName(ctx, bucketName, objectName)
+}
+
+// ListGroupMembers implements database.Repository
+func (db *Database) ListGroupMembers(ctx context.Context, groupID common.Hash, page database.Page) ([]*models.Group, string, error) {
+	d, ctx := db.read(ctx)
+	return d.ListGroupMembers(ctx, groupID, page)
+}
+
+// ListPermissionsByResource implements database.Repository
+func (db *Database) ListPermissionsByResource(ctx context.Context, resourceType string, resourceID common.Hash, page database.Page) ([]*models.Permission, string, error) {
+	d, ctx := db.read(ctx)
+	return d.ListPermissionsByResource(ctx, resourceType, resourceID, page)
+}
+
+// ListStorageProviders implements database.Repository
+func (db *Database) ListStorageProviders(ctx context.Context, page database.Page) ([]*models.StorageProvider, string, error) {
+	d, ctx := db.read(ctx)
+	return d.ListStorageProviders(ctx, page)
+}
+
+// GetStorageProvidersByIDs implements database.Repository
+func (db *Database) GetStorageProvidersByIDs(ctx context.Context, spIds []uint32) ([]*models.StorageProvider, error) {
+	d, ctx := db.read(ctx)
+	return d.GetStorageProvidersByIDs(ctx, spIds)
+}
+
+// GetStorageProviderByOperator implements database.Repository
+func (db *Database) GetStorageProviderByOperator(ctx context.Context, operator common.Address) (*models.StorageProvider, error) {
+	d, ctx := db.read(ctx)
+	return d.GetStorageProviderByOperator(ctx, operator)
+}
+
+// GetGVGsByIDs implements database.Repository
+func (db *Database) GetGVGsByIDs(ctx context.Context, gvgIds []uint32) ([]*models.GlobalVirtualGroup, error) {
+	d, ctx := db.read(ctx)
+	return d.GetGVGsByIDs(ctx, gvgIds)
+}
+
+// ListGVGsByFamily implements database.Repository
+func (db *Database) ListGVGsByFamily(ctx context.Context, familyId uint32, page database.Page) ([]*models.GlobalVirtualGroup, string, error) {
+	d, ctx := db.read(ctx)
+	return d.ListGVGsByFamily(ctx, familyId, page)
+}
+
+// ListLVGsByBucket implements database.Repository
+func (db *Database) ListLVGsByBucket(ctx context.Context, bucketID common.Hash, page database.Page) ([]*models.LocalVirtualGroup, string, error) {
+	d, ctx := db.read(ctx)
+	return d.ListLVGsByBucket(ctx, bucketID, page)
+}
+
+// GetLVGsByBucketIDs implements database.Repository
+func (db *Database) GetLVGsByBucketIDs(ctx context.Context, bucketIDs []common.Hash) ([]*models.LocalVirtualGroup, error) {
+	d, ctx := db.read(ctx)
+	return d.GetLVGsByBucketIDs(ctx, bucketIDs)
+}
+
+// GetPaymentAccount implements database.Repository
+func (db *Database) GetPaymentAccount(ctx context.Context, addr common.Address) (*models.PaymentAccount, error) {
+	d, ctx := db.read(ctx)
+	return d.GetPaymentAccount(ctx, addr)
+}
+
+// ListPaymentAccountsByOwner implements database.Repository
+func (db *Database) ListPaymentAccountsByOwner(ctx context.Context, owner common.Address, page database.Page) ([]*models.PaymentAccount, string, error) {
+	d, ctx := db.read(ctx)
+	return d.ListPaymentAccountsByOwner(ctx, owner, page)
+}
+
+// GetStreamRecord implements database.Repository
+func (db *Database) GetStreamRecord(ctx context.Context, account common.Address) (*models.StreamRecord, error) {
+	d, ctx := db.read(ctx)
+	return d.GetStreamRecord(ctx, account)
+}
+
+// GetStreamRecordsByAccounts implements database.Repository
+func (db *Database) GetStreamRecordsByAccounts(ctx context.Context, accounts []common.Address) ([]*models.StreamRecord, error) {
+	d, ctx := db.read(ctx)
+	return d.GetStreamRecordsByAccounts(ctx, accounts)
+}
+
+// GetUnpublishedChanges implements database.Database. The records of the authoritative database are relayed.
+func (db *Database) GetUnpublishedChanges(ctx context.Context, limit int) ([]*models.Outbox, error) {
+	d, ctx := db.read(ctx)
+	return d.GetUnpublishedChanges(ctx, limit)
+}
+
+// MarkChangesPublished implements database.Database. Only the authoritative database is written,
+// as the ids of the outbox records are assigned by each database.
+func (db *Database) MarkChangesPublished(ctx context.Context, ids []uint64, publishTime int64) error {
+	d, ctx := db.read(ctx)
+	return d.MarkChangesPublished(ctx, ids, publishTime)
+}
@@ -0,0 +1,559 @@
+package dual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/log"
+)
+
+const (
+	// Primary is the database of the DSN of the config
+	Primary = iota
+	// Secondary is the database of the switched DSN of the config
+	Secondary
+)
+
+var names = [2]string{"primary", "secondary"}
+
+// Builder creates the primary and the secondary databases with build, and returns the Database writing to both.
+// The secondary one uses the config of the primary one, with its DSN and type replaced by the switched ones.
+// When the config is switched, the secondary database is made authoritative unless Switch refuses it. The config
+// is only read here, the running indexer is switched with Switch, which the telemetry module serves.
+func Builder(ctx *database.Context, build database.Builder) (database.Database, error) {
+	if ctx.Cfg.DsnSwitched == "" && ctx.Cfg.SecretsSwitched == nil {
+		return nil, errors.New("dual database is enabled, but no switched dsn is set")
+	}
+
+	primary, err := build(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the primary database: %s", err)
+	}
+
+	cfg := ctx.Cfg
+	cfg.DSN = cfg.DsnSwitched
+	cfg.Secrets = cfg.SecretsSwitched
+	cfg.EnableDualDB = false
+	if cfg.TypeSwitched != "" {
+		cfg.Type = cfg.TypeSwitched
+	}
+	secondary, err := build(database.NewContext(cfg, ctx.EncodingConfig))
+	if err != nil {
+		primary.Close()
+		return nil, fmt.Errorf("failed to build the secondary database: %s", err)
+	}
+
+	db := New(primary, secondary, Primary)
+	if ctx.Cfg.Switched {
+		// the secondary database is made authoritative with the checks of Switch, against the watermarks
+		// of the databases stored before the restart
+		err = db.LoadWatermarks(context.Background())
+		if err == nil {
+			err = db.Switch(Secondary, false)
+		}
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("refusing to make the secondary database authoritative: %s", err)
+		}
+	}
+	log.Infow("writing to two databases", "authoritative", names[db.state.getAuthoritative()])
+	return db, nil
+}
+
+// type check to ensure interface is properly implemented
+var _ database.Database = &Database{}
+var _ database.PruningDb = &Database{}
+
+// Database writes every block to two databases, so that the indexer can move from one database to another
+// without downtime. One of them is authoritative: it serves the reads, and its errors are the ones returned.
+// The errors of the other one are logged, and stop its watermark, the last processed height fully written to it:
+// the progress of the indexing is no longer recorded in it, so that the divergence is found again after a restart.
+// Switching the authoritative database is refused while the other one is behind or has diverged.
+type Database struct {
+	dbs   [2]database.Database
+	state *state
+
+	// the fields below are only set for the transactions returned by Begin
+	tx            bool
+	authoritative int
+	failed        [2]bool
+	processed     uint64
+	hasProcessed  bool
+}
+
+// state is shared by a Database and its transactions
+type state struct {
+	mu            sync.RWMutex
+	authoritative int
+	watermarks    [2]uint64
+	diverged      [2]error
+}
+
+// Watermark describes the indexing state of one of the databases
+type Watermark struct {
+	Name          string
+	Height        uint64
+	Lag           uint64
+	Authoritative bool
+	// Diverged is the first write error of the database, if any
+	Diverged error
+}
+
+// New returns a Database writing to primary and secondary, with the given authoritative one
+func New(primary, secondary database.Database, authoritative int) *Database {
+	db := &Database{
+		dbs:   [2]database.Database{primary, secondary},
+		state: &state{authoritative: authoritative},
+	}
+	db.state.updateMetrics()
+	return db
+}
+
+// Switch makes the database to, either Primary or Secondary, the authoritative one. Unless forced, it refuses
+// to switch to a database which diverged or has a lower watermark than the current authoritative one.
+func (db *Database) Switch(to int, force bool) error {
+	if db.tx {
+		return errors.New("cannot switch the authoritative database inside a transaction")
+	}
+	if to != Primary && to != Secondary {
+		return fmt.Errorf("unknown database %d", to)
+	}
+
+	s := db.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if to == s.authoritative {
+		return nil
+	}
+	if !force {
+		if s.diverged[to] != nil {
+			return fmt.Errorf("the %s database diverged: %s", names[to], s.diverged[to])
+		}
+		if s.watermarks[to] < s.watermarks[s.authoritative] {
+			return fmt.Errorf("the %s database is at height %d, behind the %s database at height %d",
+				names[to], s.watermarks[to], names[s.authoritative], s.watermarks[s.authoritative])
+		}
+	}
+
+	s.authoritative = to
+	s.updateMetrics()
+	log.Infow("switched the authoritative database", "authoritative", names[to], "forced", force)
+	return nil
+}
+
+// Watermarks returns the indexing state of the primary and the secondary databases
+func (db *Database) Watermarks() []Watermark {
+	s := db.state
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]Watermark, len(db.dbs))
+	for i := range db.dbs {
+		result[i] = Watermark{
+			Name:          names[i],
+			Height:        s.watermarks[i],
+			Lag:           s.lag(i),
+			Authoritative: i == s.authoritative,
+			Diverged:      s.diverged[i],
+		}
+	}
+	return result
+}
+
+// LoadWatermarks initializes the watermarks with the epochs stored in the databases. A database whose sync ranges
+// lack heights processed by the other one below its epoch missed writes before a restart: it is marked as diverged,
+// with its watermark before the first missed height.
+func (db *Database) LoadWatermarks(ctx context.Context) error {
+	for i, d := range db.dbs {
+		epoch, err := d.GetEpoch(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get the epoch of the %s database: %s", names[i], err)
+		}
+		if epoch.BlockHeight <= 0 {
+			continue
+		}
+
+		missed, err := db.firstMissed(ctx, i, uint64(epoch.BlockHeight))
+		if err != nil {
+			return fmt.Errorf("failed to get the sync gaps of the %s database: %s", names[i], err)
+		}
+		if missed == 0 {
+			db.state.advance(i, uint64(epoch.BlockHeight))
+			continue
+		}
+		db.state.advance(i, missed-1)
+		db.state.diverge(i, fmt.Errorf("height %d is processed by the %s database only", missed, names[1-i]))
+	}
+	return nil
+}
+
+// firstMissed returns the first height up to epoch which the database i did not process but the other one did,
+// 0 if there is none
+func (db *Database) firstMissed(ctx context.Context, i int, epoch uint64) (uint64, error) {
+	gaps, err := db.dbs[i].GetSyncGaps(ctx, 1, epoch)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, gap := range gaps {
+		otherGaps, err := db.dbs[1-i].GetSyncGaps(ctx, gap.Start, gap.End)
+		if err != nil {
+			return 0, err
+		}
+
+		// the heights of the gap between the gaps of the other database are processed by it
+		next := gap.Start
+		for _, otherGap := range otherGaps {
+			if otherGap.Start > next {
+				return next, nil
+			}
+			next = otherGap.End + 1
+		}
+		if next <= gap.End {
+			return next, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *state) getAuthoritative() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.authoritative
+}
+
+// advance moves the watermark of the database i to height, unless the database diverged
+func (s *state) advance(i int, height uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.diverged[i] != nil || height <= s.watermarks[i] {
+		return
+	}
+	s.watermarks[i] = height
+	s.updateMetrics()
+}
+
+// isDiverged tells whether a write to the database i failed
+func (s *state) isDiverged(i int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.diverged[i] != nil
+}
+
+// diverge records the first write error of the database i
+func (s *state) diverge(i int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.diverged[i] == nil {
+		s.diverged[i] = err
+	}
+}
+
+// lag returns the number of heights the database i is behind the other one
+func (s *state) lag(i int) uint64 {
+	if other := s.watermarks[1-i]; other > s.watermarks[i] {
+		return other - s.watermarks[i]
+	}
+	return 0
+}
+
+func (s *state) updateMetrics() {
+	for i, name := range names {
+		log.DualDBHeight.WithLabelValues(name).Set(float64(s.watermarks[i]))
+		log.DualDBLag.WithLabelValues(name).Set(float64(s.lag(i)))
+		authoritative := 0.0
+		if i == s.authoritative {
+			authoritative = 1
+		}
+		log.DualDBAuthoritative.WithLabelValues(name).Set(authoritative)
+	}
+}
+
+// -------------------------------------------------------------------------------------------------------------------
+
+// side is one of the databases a call runs on
+type side struct {
+	index         int
+	db            database.Database
+	ctx           context.Context
+	authoritative bool
+}
+
+func (db *Database) getAuthoritative() int {
+	if db.tx {
+		return db.authoritative
+	}
+	return db.state.getAuthoritative()
+}
+
+// contextOf returns the context to call the database i with. The secondary database gets its own write buffer.
+func contextOf(ctx context.Context, i int) context.Context {
+	if i == Secondary {
+		return database.WithShadowWriteBuffer(ctx)
+	}
+	return ctx
+}
+
+// sides returns the databases to run a call on, the authoritative one first
+func (db *Database) sides(ctx context.Context) []side {
+	authoritative := db.getAuthoritative()
+	result := make([]side, 0, len(db.dbs))
+	for _, i := range [2]int{authoritative, 1 - authoritative} {
+		if db.failed[i] {
+			continue
+		}
+		result = append(result, side{index: i, db: db.dbs[i], ctx: contextOf(ctx, i), authoritative: i == authoritative})
+	}
+	return result
+}
+
+// read returns the authoritative database with the context to call it with
+func (db *Database) read(ctx context.Context) (database.Database, context.Context) {
+	i := db.getAuthoritative()
+	return db.dbs[i], contextOf(ctx, i)
+}
+
+// write runs fn on the authoritative database, then on the other one. The error of the authoritative database
+// is returned, the one of the other database marks it as diverged. Inside a transaction, the diverged database
+// is rolled back and left out of the following calls.
+func (db *Database) write(ctx context.Context, op string, fn func(s side) error) error {
+	for _, s := range db.sides(ctx) {
+		err := fn(s)
+		if err == nil {
+			continue
+		}
+		if s.authoritative {
+			return err
+		}
+
+		db.fail(s.index, op, err)
+		if db.tx {
+			s.db.Rollback()
+		}
+	}
+	return nil
+}
+
+// progress runs fn, which records the progress of the indexing, like write. The non authoritative database is left
+// out once it diverged: the heights it missed writes of, and the ones after them, must stay unprocessed in its sync
+// ranges, so that LoadWatermarks finds the divergence again after a restart.
+func (db *Database) progress(ctx context.Context, op string, fn func(s side) error) error {
+	return db.write(ctx, op, func(s side) error {
+		if !s.authoritative && db.state.isDiverged(s.index) {
+			return nil
+		}
+		return fn(s)
+	})
+}
+
+// all runs fn on both databases, and returns the first error of any of them
+func (db *Database) all(ctx context.Context, fn func(s side) error) error {
+	for _, s := range db.sides(ctx) {
+		if err := fn(s); err != nil {
+			return fmt.Errorf("%s database: %w", names[s.index], err)
+		}
+	}
+	return nil
+}
+
+func (db *Database) fail(i int, op string, err error) {
+	log.Errorw("failed to write to the non authoritative database", "database", names[i], "op", op, "err", err)
+	log.DualDBWriteErrors.WithLabelValues(names[i]).Inc()
+	db.state.diverge(i, fmt.Errorf("%s: %w", op, err))
+	if db.tx {
+		db.failed[i] = true
+	}
+}
+
+// pick returns v for the authoritative database, and its copy shadow for the other one, so that the values
+// set by a database on a model, e.g. its auto increment id, are not written to the other one
+func pick[T any](s side, v, shadow T) T {
+	if s.authoritative {
+		return v
+	}
+	return shadow
+}
+
+// clone returns a shallow copy of the model
+func clone[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
+// cloneAll returns shallow copies of the models
+func cloneAll[T any](vs []*T) []*T {
+	result := make([]*T, len(vs))
+	for i, v := range vs {
+		result[i] = clone(v)
+	}
+	return result
+}
+
+// -------------------------------------------------------------------------------------------------------------------
+
+// Begin begins a transaction on both databases. The transaction keeps the authoritative database
+// it was started with.
+func (db *Database) Begin(ctx context.Context) database.Database {
+	tx := &Database{
+		state:         db.state,
+		tx:            true,
+		authoritative: db.getAuthoritative(),
+		failed:        db.failed,
+	}
+	for i, d := range db.dbs {
+		if !tx.failed[i] {
+			tx.dbs[i] = d.Begin(ctx)
+		}
+	}
+	return tx
+}
+
+// Rollback rollbacks the transaction on both databases
+func (db *Database) Rollback() {
+	for i, d := range db.dbs {
+		if !db.failed[i] {
+			d.Rollback()
+		}
+	}
+}
+
+// Commit commits the authoritative database first, so that the other one is rolled back if it fails.
+// The watermarks of the committed databases are then moved to the height processed by the transaction, if any.
+func (db *Database) Commit() error {
+	authoritative, other := db.authoritative, 1-db.authoritative
+	if err := db.dbs[authoritative].Commit(); err != nil {
+		if !db.failed[other] {
+			db.dbs[other].Rollback()
+		}
+		return err
+	}
+
+	committed := [2]bool{}
+	committed[authoritative] = true
+	if !db.failed[other] {
+		if err := db.dbs[other].Commit(); err != nil {
+			db.fail(other, "Commit", err)
+		} else {
+			committed[other] = true
+		}
+	}
+
+	if db.hasProcessed {
+		for i := range committed {
+			if committed[i] {
+				db.state.advance(i, db.processed)
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes both databases
+func (db *Database) Close() {
+	for _, d := range db.dbs {
+		d.Close()
+	}
+}
+
+// SaveProcessedHeight implements database.Database. Outside a transaction, the watermarks of the databases
+// are moved to height at once, otherwise when the transaction is committed. The height is not recorded
+// in the non authoritative database once it diverged.
+func (db *Database) SaveProcessedHeight(ctx context.Context, height uint64) error {
+	var saved [2]bool
+	err := db.progress(ctx, "SaveProcessedHeight", func(s side) error {
+		if err := s.db.SaveProcessedHeight(s.ctx, height); err != nil {
+			return err
+		}
+		saved[s.index] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if db.tx {
+		db.processed, db.hasProcessed = height, true
+		return nil
+	}
+	for i := range saved {
+		if saved[i] {
+			db.state.advance(i, height)
+		}
+	}
+	return nil
+}
+
+// FlushWriteBuffer implements database.Database
+func (db *Database) FlushWriteBuffer(ctx context.Context) error {
+	return db.write(ctx, "FlushWriteBuffer", func(s side) error {
+		return s.db.FlushWriteBuffer(s.ctx)
+	})
+}
+
+// -------------------------------------------------------------------------------------------------------------------
+
+// pruningDb returns the database i as a database.PruningDb
+func (db *Database) pruningDb(i int) (database.PruningDb, error) {
+	pruningDb, ok := db.dbs[i].(database.PruningDb)
+	if !ok {
+		return nil, fmt.Errorf("the %s database does not implement PruningDb", names[i])
+	}
+	return pruningDb, nil
+}
+
+// Prune implements database.PruningDb
+func (db *Database) Prune(height int64) error {
+	return db.write(context.Background(), "Prune", func(s side) error {
+		pruningDb, err := db.pruningDb(s.index)
+		if err != nil {
+			return err
+		}
+		return pruningDb.Prune(height)
+	})
+}
+
+// StoreLastPruned implements database.PruningDb
+func (db *Database) StoreLastPruned(height int64) error {
+	return db.write(context.Background(), "StoreLastPruned", func(s side) error {
+		pruningDb, err := db.pruningDb(s.index)
+		if err != nil {
+			return err
+		}
+		return pruningDb.StoreLastPruned(height)
+	})
+}
+
+// ApplyRetention implements database.PruningDb. The count of the authoritative database is returned.
+func (db *Database) ApplyRetention(ctx context.Context, policy database.RetentionPolicy, latestHeight uint64, now int64, batchSize int) (int64, error) {
+	var deleted int64
+	err := db.write(ctx, "ApplyRetention", func(s side) error {
+		pruningDb, err := db.pruningDb(s.index)
+		if err != nil {
+			return err
+		}
+		count, err := pruningDb.ApplyRetention(s.ctx, policy, latestHeight, now, batchSize)
+		if s.authoritative {
+			deleted = count
+		}
+		return err
+	})
+	return deleted, err
+}
+
+// GetLastPruned implements database.PruningDb
+func (db *Database) GetLastPruned() (int64, error) {
+	pruningDb, err := db.pruningDb(db.getAuthoritative())
+	if err != nil {
+		return 0, err
+	}
+	return pruningDb.GetLastPruned()
+}
@@ -0,0 +1,176 @@
+package dual
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	databaseconfig "github.com/forbole/juno/v4/database/config"
+	"github.com/forbole/juno/v4/models"
+)
+
+func newSqliteImpl(t *testing.T, name string) *database.Impl {
+	db, err := gorm.Open(sqlite.Open("file:dual_"+name+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Bucket{}, &models.SyncRange{}, &models.Epoch{}, &models.Block{}))
+	return &database.Impl{Db: db}
+}
+
+func processBlock(t *testing.T, db database.Database, height uint64) {
+	tx := db.Begin(context.Background())
+	require.NoError(t, tx.SaveProcessedHeight(context.Background(), height))
+	require.NoError(t, tx.SaveEpoch(context.Background(), &models.Epoch{OneRowId: true, BlockHeight: int64(height)}))
+	require.NoError(t, tx.Commit())
+}
+
+func TestDual_WritesBothDatabases(t *testing.T) {
+	primary, secondary := newSqliteImpl(t, "writes_primary"), newSqliteImpl(t, "writes_secondary")
+	require.NoError(t, secondary.Db.Create(&models.Bucket{BucketID: common.HexToHash("0x10"), BucketName: "secondary-only"}).Error)
+	db := New(primary, secondary, Primary)
+
+	bucket := &models.Bucket{BucketID: common.HexToHash("0x01"), BucketName: "bucket-1"}
+	require.NoError(t, db.SaveBucket(context.Background(), bucket))
+
+	// each database assigns its own id
+	var fromPrimary, fromSecondary models.Bucket
+	require.NoError(t, primary.Db.Where("bucket_id = ?", bucket.BucketID).Take(&fromPrimary).Error)
+	require.NoError(t, secondary.Db.Where("bucket_id = ?", bucket.BucketID).Take(&fromSecondary).Error)
+	require.Equal(t, bucket.ID, fromPrimary.ID)
+	require.NotEqual(t, fromPrimary.ID, fromSecondary.ID)
+
+	// buffered writes are flushed to both databases
+	ctx := database.WithWriteBuffer(context.Background())
+	require.NoError(t, db.SaveBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x02"), BucketName: "bucket-2"}))
+	require.NoError(t, db.FlushWriteBuffer(ctx))
+	for _, d := range []*database.Impl{primary, secondary} {
+		got, err := d.GetBucket(context.Background(), common.HexToHash("0x02"))
+		require.NoError(t, err)
+		require.NotNil(t, got)
+	}
+
+	// reads are served by the authoritative database
+	got, err := db.GetBucket(context.Background(), common.HexToHash("0x10"))
+	require.NoError(t, err)
+	require.Nil(t, got)
+	require.NoError(t, db.Switch(Secondary, false))
+	got, err = db.GetBucket(context.Background(), common.HexToHash("0x10"))
+	require.NoError(t, err)
+	require.NotNil(t, got)
+}
+
+func TestDual_Watermarks(t *testing.T) {
+	primary, secondary := newSqliteImpl(t, "watermarks_primary"), newSqliteImpl(t, "watermarks_secondary")
+	db := New(primary, secondary, Primary)
+
+	processBlock(t, db, 1)
+	processBlock(t, db, 2)
+	require.Equal(t, uint64(2), db.Watermarks()[Primary].Height)
+	require.Equal(t, uint64(2), db.Watermarks()[Secondary].Height)
+
+	// a failed write leaves the secondary database behind, and it is not switched to anymore
+	require.NoError(t, secondary.Db.Migrator().DropTable(&models.SyncRange{}))
+	processBlock(t, db, 3)
+	processBlock(t, db, 4)
+
+	watermarks := db.Watermarks()
+	require.Equal(t, uint64(4), watermarks[Primary].Height)
+	require.Equal(t, uint64(2), watermarks[Secondary].Height)
+	require.Equal(t, uint64(2), watermarks[Secondary].Lag)
+	require.Error(t, watermarks[Secondary].Diverged)
+	require.True(t, watermarks[Primary].Authoritative)
+
+	require.Error(t, db.Switch(Secondary, false))
+	require.NoError(t, db.Switch(Secondary, true))
+	require.True(t, db.Watermarks()[Secondary].Authoritative)
+
+	// the errors of the authoritative database are returned
+	tx := db.Begin(context.Background())
+	require.Error(t, tx.SaveProcessedHeight(context.Background(), 5))
+	tx.Rollback()
+}
+
+func TestDual_LoadWatermarks(t *testing.T) {
+	primary, secondary := newSqliteImpl(t, "load_primary"), newSqliteImpl(t, "load_secondary")
+	for height := uint64(1); height <= 7; height++ {
+		processBlock(t, primary, height)
+		if height <= 5 {
+			processBlock(t, secondary, height)
+		}
+	}
+
+	db := New(primary, secondary, Secondary)
+	require.NoError(t, db.LoadWatermarks(context.Background()))
+	require.Equal(t, uint64(7), db.Watermarks()[Primary].Height)
+	require.Equal(t, uint64(2), db.Watermarks()[Secondary].Lag)
+
+	require.NoError(t, db.Switch(Primary, false))
+	require.Error(t, db.Switch(Secondary, false))
+}
+
+func TestDual_DivergenceSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := newSqliteImpl(t, "restart_primary"), newSqliteImpl(t, "restart_secondary")
+	db := New(primary, secondary, Primary)
+	processBlock(t, db, 1)
+
+	// a write of the block 2 fails on the secondary database, outside the transaction recording the progress
+	require.NoError(t, secondary.Db.Migrator().DropTable(&models.Bucket{}))
+	require.NoError(t, db.SaveBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x02"), BucketName: "bucket-2"}))
+	processBlock(t, db, 2)
+	require.NoError(t, secondary.Db.Migrator().CreateTable(&models.Bucket{}))
+	processBlock(t, db, 3)
+
+	epoch, err := secondary.GetEpoch(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), epoch.BlockHeight)
+
+	// the secondary database is behind after a restart
+	restarted := New(primary, secondary, Primary)
+	require.NoError(t, restarted.LoadWatermarks(ctx))
+	watermarks := restarted.Watermarks()
+	require.Equal(t, uint64(3), watermarks[Primary].Height)
+	require.Equal(t, uint64(1), watermarks[Secondary].Height)
+	require.Error(t, restarted.Switch(Secondary, false))
+
+	// the missed height is found below the epoch too, e.g. in an epoch saved past the gaps by an older version
+	require.NoError(t, secondary.SaveProcessedHeight(ctx, 3))
+	require.NoError(t, secondary.Db.Model(&models.Epoch{}).Where("one_row_id = ?", true).Update("block_height", 3).Error)
+
+	restarted = New(primary, secondary, Primary)
+	require.NoError(t, restarted.LoadWatermarks(ctx))
+	watermarks = restarted.Watermarks()
+	require.Equal(t, uint64(1), watermarks[Secondary].Height)
+	require.Error(t, watermarks[Secondary].Diverged)
+	require.NoError(t, watermarks[Primary].Diverged)
+	require.Error(t, restarted.Switch(Secondary, false))
+}
+
+func TestDual_BuilderSwitched(t *testing.T) {
+	primary, secondary := newSqliteImpl(t, "builder_primary"), newSqliteImpl(t, "builder_secondary")
+	for height := uint64(1); height <= 3; height++ {
+		processBlock(t, primary, height)
+		if height <= 2 {
+			processBlock(t, secondary, height)
+		}
+	}
+
+	// the databases of the DSNs are opened again, like after a restart
+	build := func(ctx *database.Context) (database.Database, error) {
+		return newSqliteImpl(t, ctx.Cfg.DSN), nil
+	}
+	cfg := databaseconfig.Config{DSN: "builder_primary", DsnSwitched: "builder_secondary", Switched: true}
+
+	// the secondary database is behind, it is not made authoritative
+	_, err := Builder(database.NewContext(cfg, nil), build)
+	require.ErrorContains(t, err, "behind")
+
+	processBlock(t, secondary, 3)
+	db, err := Builder(database.NewContext(cfg, nil), build)
+	require.NoError(t, err)
+	require.True(t, db.(*Database).Watermarks()[Secondary].Authoritative)
+}
@@ -0,0 +1,199 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/models"
+)
+
+type eventPositionKey struct{}
+
+// EventPosition locates the event the writes of a context are done for
+type EventPosition struct {
+	Height     uint64
+	TxHash     common.Hash
+	EventIndex int
+}
+
+// WithEventPosition returns a context carrying the position of the event being handled.
+// The outbox records of the writes done with this context are located at it.
+func WithEventPosition(ctx context.Context, position EventPosition) context.Context {
+	return context.WithValue(ctx, eventPositionKey{}, position)
+}
+
+func eventPositionFrom(ctx context.Context) EventPosition {
+	position, _ := ctx.Value(eventPositionKey{}).(EventPosition)
+	return position
+}
+
+// changeEntity describes how the changes of the rows of a model are recorded in the outbox
+type changeEntity[T any] struct {
+	// rowID returns the primary key of the row
+	rowID func(*T) uint64
+	// entity returns the entity type and id of the row
+	entity func(*T) (string, string)
+	// removed tells whether the row is marked as removed
+	removed func(*T) bool
+}
+
+var (
+	bucketChanges = changeEntity[models.Bucket]{
+		rowID:   func(b *models.Bucket) uint64 { return b.ID },
+		entity:  func(b *models.Bucket) (string, string) { return models.OutboxBucket, b.BucketID.Hex() },
+		removed: func(b *models.Bucket) bool { return b.Removed },
+	}
+	objectChanges = changeEntity[models.Object]{
+		rowID:   func(o *models.Object) uint64 { return o.ID },
+		entity:  func(o *models.Object) (string, string) { return models.OutboxObject, o.ObjectID.Hex() },
+		removed: func(o *models.Object) bool { return o.Removed },
+	}
+	groupChanges = changeEntity[models.Group]{
+		rowID: func(g *models.Group) uint64 { return g.ID },
+		entity: func(g *models.Group) (string, string) {
+			// the row of the group itself has no account
+			if g.AccountID == (common.Address{}) {
+				return models.OutboxGroup, g.GroupID.Hex()
+			}
+			return models.OutboxGroupMember, g.GroupID.Hex() + "/" + g.AccountID.Hex()
+		},
+		removed: func(g *models.Group) bool { return g.Removed },
+	}
+	policyChanges = changeEntity[models.Permission]{
+		rowID:   func(p *models.Permission) uint64 { return p.ID },
+		entity:  func(p *models.Permission) (string, string) { return models.OutboxPolicy, p.PolicyID.Hex() },
+		removed: func(p *models.Permission) bool { return p.Removed },
+	}
+)
+
+// where returns a scope selecting the rows matching the given conditions
+func where(query interface{}, args ...interface{}) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(query, args...)
+	}
+}
+
+// recordChanges runs write. When the outbox is enabled, the changes write made to the rows selected by scope
+// are appended to the outbox, in the same transaction. The changes already recorded, which have the same
+// idempotency key, are skipped.
+//
+// The deletions which are not done through recordChanges are not recorded:
+//   - the statements removed along with their policy, which is recorded as deleted by the same event
+//   - the removed rows moved out by ArchiveRemoved or deleted by ApplyRetention, which were recorded as
+//     deleted when they were marked as removed
+//   - the rows of the blocks, txs and other tables, which are not entities of the outbox
+func recordChanges[T any](ctx context.Context, db *Impl, entity changeEntity[T], scope func(*gorm.DB) *gorm.DB, write func(*gorm.DB) error) error {
+	if !db.Outbox {
+		return write(db.session(ctx))
+	}
+
+	return db.session(ctx).Transaction(func(tx *gorm.DB) error {
+		var before []*T
+		if err := scope(tx.Model(new(T))).Find(&before).Error; err != nil {
+			return err
+		}
+
+		if err := write(tx); err != nil {
+			return err
+		}
+
+		// the rows are selected by id as well, as the write may move them out of the scope
+		var after []*T
+		query := scope(tx.Model(new(T)))
+		if len(before) > 0 {
+			ids := make([]uint64, len(before))
+			for i, row := range before {
+				ids[i] = entity.rowID(row)
+			}
+			query = query.Or("id IN ?", ids)
+		}
+		if err := query.Order("id").Find(&after).Error; err != nil {
+			return err
+		}
+
+		changes, err := entity.changes(before, after, eventPositionFrom(ctx))
+		if err != nil || len(changes) == 0 {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(changes, writeBatchSize).Error
+	})
+}
+
+// changes returns the outbox records of the rows which differ between before and after
+func (e changeEntity[T]) changes(before, after []*T, position EventPosition) ([]*models.Outbox, error) {
+	previous := make(map[uint64]*T, len(before))
+	for _, row := range before {
+		previous[e.rowID(row)] = row
+	}
+
+	var changes []*models.Outbox
+	for _, row := range after {
+		change := &models.Outbox{
+			Operation:  models.OutboxUpdate,
+			Height:     position.Height,
+			TxHash:     position.TxHash,
+			EventIndex: position.EventIndex,
+		}
+		change.EntityType, change.EntityID = e.entity(row)
+
+		var err error
+		if change.After, err = json.Marshal(row); err != nil {
+			return nil, err
+		}
+
+		old, ok := previous[e.rowID(row)]
+		switch {
+		case !ok:
+			change.Operation = models.OutboxCreate
+		case e.removed(row) && !e.removed(old):
+			change.Operation = models.OutboxDelete
+		}
+		if ok {
+			if change.Before, err = json.Marshal(old); err != nil {
+				return nil, err
+			}
+			if bytes.Equal(change.Before, change.After) {
+				continue
+			}
+		}
+		change.IdempotencyKey = idempotencyKey(position, change)
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// idempotencyKey returns the key of change, the hash of the event position, the entity and the rows before and after
+func idempotencyKey(position EventPosition, change *models.Outbox) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d/%s/%d/%s/%s/", position.Height, position.TxHash.Hex(), position.EventIndex, change.EntityType, change.EntityID)
+	h.Write(change.Before)
+	h.Write([]byte("/"))
+	h.Write(change.After)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (db *Impl) GetUnpublishedChanges(ctx context.Context, limit int) ([]*models.Outbox, error) {
+	var changes []*models.Outbox
+
+	err := db.session(ctx).Where("published IS NOT TRUE").Order("id").Limit(limit).Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (db *Impl) MarkChangesPublished(ctx context.Context, ids []uint64, publishTime int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return db.session(ctx).Model(&models.Outbox{}).Where("id IN ?", ids).
+		Updates(map[string]interface{}{"published": true, "publish_time": publishTime}).Error
+}
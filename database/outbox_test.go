@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/forbole/juno/v4/common"
+	databaseconfig "github.com/forbole/juno/v4/database/config"
+	"github.com/forbole/juno/v4/database/sqlclient"
+	"github.com/forbole/juno/v4/models"
+)
+
+func newOutboxImpl(t *testing.T, name string, outbox bool) *Impl {
+	db, err := sqlclient.New(&databaseconfig.Config{Type: databaseconfig.SQLite, DSN: "file:" + name + "?mode=memory&cache=shared"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Bucket{}, &models.Group{}, &models.Permission{}, &models.Statements{}, &models.Outbox{}))
+	return &Impl{Db: db, Outbox: outbox}
+}
+
+func outboxRecords(t *testing.T, db *Impl) []*models.Outbox {
+	var changes []*models.Outbox
+	require.NoError(t, db.Db.Order("id").Find(&changes).Error)
+	return changes
+}
+
+func TestOutbox_Buckets(t *testing.T) {
+	db := newOutboxImpl(t, "outbox_buckets", true)
+	position := EventPosition{Height: 10, TxHash: common.HexToHash("0xaa"), EventIndex: 2}
+	ctx := WithEventPosition(context.Background(), position)
+
+	bucketID := common.HexToHash("0x01")
+	require.NoError(t, db.SaveBucket(ctx, &models.Bucket{BucketID: bucketID, BucketName: "bucket", Visibility: "private"}))
+	require.NoError(t, db.UpdateBucket(ctx, &models.Bucket{BucketID: bucketID, Visibility: "public-read"}))
+	// an update which changes nothing is not recorded
+	require.NoError(t, db.UpdateBucket(ctx, &models.Bucket{BucketID: bucketID, Visibility: "public-read"}))
+	require.NoError(t, db.UpdateBucket(ctx, &models.Bucket{BucketID: bucketID, Removed: true}))
+
+	changes := outboxRecords(t, db)
+	require.Len(t, changes, 3)
+	for i, operation := range []string{models.OutboxCreate, models.OutboxUpdate, models.OutboxDelete} {
+		require.Equal(t, operation, changes[i].Operation)
+		require.Equal(t, models.OutboxBucket, changes[i].EntityType)
+		require.Equal(t, bucketID.Hex(), changes[i].EntityID)
+		require.Equal(t, position.Height, changes[i].Height)
+		require.Equal(t, position.TxHash, changes[i].TxHash)
+		require.Equal(t, position.EventIndex, changes[i].EventIndex)
+	}
+	require.Empty(t, changes[0].Before)
+
+	var before, after models.Bucket
+	require.NoError(t, json.Unmarshal(changes[1].Before, &before))
+	require.NoError(t, json.Unmarshal(changes[1].After, &after))
+	require.Equal(t, "private", before.Visibility)
+	require.Equal(t, "public-read", after.Visibility)
+
+	// the buffered writes are written right away, along with their records
+	buffered := WithWriteBuffer(ctx)
+	require.NoError(t, db.SaveBucket(buffered, &models.Bucket{BucketID: common.HexToHash("0x02"), BucketName: "buffered"}))
+	require.Len(t, outboxRecords(t, db), 4)
+	bucket, err := db.GetBucketByName(context.Background(), "buffered")
+	require.NoError(t, err)
+	require.NotNil(t, bucket)
+}
+
+func TestOutbox_GroupsAndPolicies(t *testing.T) {
+	db := newOutboxImpl(t, "outbox_groups", true)
+	ctx := context.Background()
+
+	groupID := common.HexToHash("0x01")
+	require.NoError(t, db.CreateGroup(ctx, []*models.Group{
+		{GroupID: groupID, GroupName: "group"},
+		{GroupID: groupID, AccountID: common.HexToAddress("0x0a")},
+	}))
+	require.NoError(t, db.DeleteGroup(ctx, &models.Group{GroupID: groupID, Removed: true}))
+
+	changes := outboxRecords(t, db)
+	require.Len(t, changes, 4)
+	require.Equal(t, models.OutboxGroup, changes[0].EntityType)
+	require.Equal(t, groupID.Hex(), changes[0].EntityID)
+	require.Equal(t, models.OutboxGroupMember, changes[1].EntityType)
+	require.Equal(t, groupID.Hex()+"/"+common.HexToAddress("0x0a").Hex(), changes[1].EntityID)
+	require.Equal(t, models.OutboxDelete, changes[2].Operation)
+	require.Equal(t, models.OutboxDelete, changes[3].Operation)
+
+	resourceID := common.HexToHash("0x10")
+	policyID := common.HexToHash("0x20")
+	require.NoError(t, db.SavePermission(ctx, &models.Permission{
+		PrincipalValue: "a", ResourceType: "RESOURCE_TYPE_BUCKET", ResourceID: resourceID, PolicyID: policyID,
+	}))
+	removed, err := db.RemovePoliciesByResources(ctx, "RESOURCE_TYPE_BUCKET", []common.Hash{resourceID}, 100)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), removed)
+
+	changes = outboxRecords(t, db)[4:]
+	require.Len(t, changes, 2)
+	require.Equal(t, models.OutboxPolicy, changes[0].EntityType)
+	require.Equal(t, policyID.Hex(), changes[0].EntityID)
+	require.Equal(t, models.OutboxCreate, changes[0].Operation)
+	require.Equal(t, models.OutboxDelete, changes[1].Operation)
+}
+
+func TestOutbox_IdempotencyKey(t *testing.T) {
+	db := newOutboxImpl(t, "outbox_idempotency", true)
+	ctx := WithEventPosition(context.Background(), EventPosition{Height: 10, TxHash: common.HexToHash("0xaa"), EventIndex: 2})
+
+	bucket := func() *models.Bucket {
+		return &models.Bucket{ID: 1, BucketID: common.HexToHash("0x01"), BucketName: "bucket", Visibility: "private"}
+	}
+	require.NoError(t, db.SaveBucket(ctx, bucket()))
+	require.NoError(t, db.UpdateBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x01"), Visibility: "public-read"}))
+	// the same change made back is another change
+	require.NoError(t, db.UpdateBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x01"), Visibility: "private"}))
+
+	changes := outboxRecords(t, db)
+	require.Len(t, changes, 3)
+	keys := make(map[string]bool)
+	for _, change := range changes {
+		require.Len(t, change.IdempotencyKey, 64)
+		keys[change.IdempotencyKey] = true
+	}
+	require.Len(t, keys, 3)
+
+	// the block is indexed again from scratch: the changes are not recorded twice
+	require.NoError(t, db.Db.Where("id = ?", 1).Delete(&models.Bucket{}).Error)
+	require.NoError(t, db.SaveBucket(ctx, bucket()))
+	require.Len(t, outboxRecords(t, db), 3)
+
+	// the same change made by another event is recorded
+	require.NoError(t, db.Db.Where("id = ?", 1).Delete(&models.Bucket{}).Error)
+	other := WithEventPosition(context.Background(), EventPosition{Height: 11, TxHash: common.HexToHash("0xbb")})
+	require.NoError(t, db.SaveBucket(other, bucket()))
+	changes = outboxRecords(t, db)
+	require.Len(t, changes, 4)
+	require.NotEqual(t, changes[0].IdempotencyKey, changes[3].IdempotencyKey)
+}
+
+func TestOutbox_Unpublished(t *testing.T) {
+	db := newOutboxImpl(t, "outbox_unpublished", true)
+	ctx := context.Background()
+
+	for i := byte(1); i <= 3; i++ {
+		require.NoError(t, db.SaveBucket(ctx, &models.Bucket{BucketID: common.BytesToHash([]byte{i}), BucketName: string('a' + rune(i))}))
+	}
+
+	changes, err := db.GetUnpublishedChanges(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.NoError(t, db.MarkChangesPublished(ctx, []uint64{changes[0].ID, changes[1].ID}, 100))
+
+	changes, err = db.GetUnpublishedChanges(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, common.BytesToHash([]byte{3}).Hex(), changes[0].EntityID)
+}
+
+func TestOutbox_Disabled(t *testing.T) {
+	db := newOutboxImpl(t, "outbox_disabled", false)
+	ctx := context.Background()
+
+	require.NoError(t, db.SaveBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x01"), BucketName: "bucket"}))
+	removed, err := db.RemovePoliciesByResources(ctx, "RESOURCE_TYPE_BUCKET", []common.Hash{common.HexToHash("0x01")}, 100)
+	require.NoError(t, err)
+	require.Zero(t, removed)
+	require.Empty(t, outboxRecords(t, db))
+}
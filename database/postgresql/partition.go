@@ -0,0 +1,415 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/forbole/juno/v4/common"
+	databaseconfig "github.com/forbole/juno/v4/database/config"
+	"github.com/forbole/juno/v4/log"
+)
+
+// partitionKey is the column the partitioned tables are split by
+const partitionKey = "height"
+
+// defaultPartitionsAhead is the number of partitions created ahead of the write head when not configured
+const defaultPartitionsAhead = 2
+
+var (
+	// ErrRetiredPartition is returned when writing heights of a partition retired out of the retention window.
+	// A detached partition is not re-attached: the rows are to be written to it, or the retention raised, by hand.
+	ErrRetiredPartition = errors.New("partition is retired")
+
+	// ErrDuplicateTxHash is returned when saving a tx whose hash is already saved at another height
+	ErrDuplicateTxHash = errors.New("tx hash is already saved")
+)
+
+// txHash holds the hash of every tx saved in the partitioned txs table. The unique indexes of a partitioned
+// table include the height, so the uniqueness of the hashes across heights is enforced by this table.
+// Its rows are kept when the partitions of the txs are retired.
+type txHash struct {
+	Hash   common.Hash `gorm:"column:hash;type:bytea;primaryKey"`
+	Height uint64      `gorm:"column:height;not null"`
+}
+
+func (*txHash) TableName() string {
+	return "tx_hashes"
+}
+
+// partitioner keeps the height range partitions of the configured tables ahead of the write head,
+// and retires the ones falling out of the retention window
+type partitioner struct {
+	tables    []string
+	size      int64
+	ahead     int64
+	retention int64
+	drop      bool
+
+	mu      sync.Mutex
+	created map[int64]bool
+	head    int64
+}
+
+// newPartitioner returns the partitioner of the given configuration, or nil if partitioning is disabled
+func newPartitioner(cfg databaseconfig.Config) *partitioner {
+	if len(cfg.PartitionTables) == 0 || cfg.PartitionSize <= 0 {
+		return nil
+	}
+
+	ahead := cfg.PartitionsAhead
+	if ahead <= 0 {
+		ahead = defaultPartitionsAhead
+	}
+
+	return &partitioner{
+		tables:    cfg.PartitionTables,
+		size:      cfg.PartitionSize,
+		ahead:     ahead,
+		retention: cfg.PartitionRetention,
+		drop:      cfg.DropPartitions,
+		created:   make(map[int64]bool),
+		head:      -1,
+	}
+}
+
+func (p *partitioner) isPartitioned(table string) bool {
+	if p == nil {
+		return false
+	}
+	for _, t := range p.tables {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+// partitionName returns the name of the partition of table holding the given partition id
+func partitionName(table string, partitionID int64) string {
+	return fmt.Sprintf("%s_%d", table, partitionID)
+}
+
+// partitionID returns the id of the partition named name of table, or false if name is not one of its partitions
+func partitionID(table, name string) (int64, bool) {
+	suffix := strings.TrimPrefix(name, table+"_")
+	if suffix == name {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(suffix, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// partitionedTableStatements returns the statements creating table partitioned by ranges of height.
+// PostgreSQL requires the partition key to be part of the primary key and of every unique index,
+// so height is added to them when missing: the uniqueness of e.g. a hash is then enforced per height,
+// the one of the tx hashes is enforced by the tx_hashes table.
+func partitionedTableStatements(db *gorm.DB, table schema.Tabler) ([]clause.Expr, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(table); err != nil {
+		return nil, err
+	}
+	if stmt.Schema.LookUpField(partitionKey) == nil {
+		return nil, fmt.Errorf("table %s has no %s column to be partitioned by", table.TableName(), partitionKey)
+	}
+
+	migrator := db.Migrator()
+	createTableSQL := "CREATE TABLE ? ("
+	values := []interface{}{clause.Table{Name: table.TableName()}}
+	for _, dbName := range stmt.Schema.DBNames {
+		field := stmt.Schema.FieldsByDBName[dbName]
+		if field.IgnoreMigration {
+			continue
+		}
+		createTableSQL += "? ?,"
+		values = append(values, clause.Column{Name: dbName}, migrator.FullDataTypeOf(field))
+	}
+
+	var primaryKeys []string
+	for _, field := range stmt.Schema.PrimaryFields {
+		primaryKeys = append(primaryKeys, field.DBName)
+	}
+	createTableSQL += "PRIMARY KEY ?) PARTITION BY RANGE ?"
+	values = append(values, columnList(withPartitionKey(primaryKeys)), columnList([]string{partitionKey}))
+
+	statements := []clause.Expr{{SQL: createTableSQL, Vars: values}}
+
+	indexes := stmt.Schema.ParseIndexes()
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		idx := indexes[name]
+		columns := make([]string, len(idx.Fields))
+		for i, field := range idx.Fields {
+			columns[i] = field.DBName
+		}
+		if idx.Class == "UNIQUE" {
+			columns = withPartitionKey(columns)
+		}
+
+		createIndexSQL := "CREATE "
+		if idx.Class != "" {
+			createIndexSQL += idx.Class + " "
+		}
+		createIndexSQL += "INDEX IF NOT EXISTS ? ON ? ?"
+		statements = append(statements, clause.Expr{
+			SQL: createIndexSQL,
+			Vars: []interface{}{
+				// index names are unique within a schema
+				clause.Column{Name: fmt.Sprintf("%s_%s", table.TableName(), idx.Name)},
+				clause.Table{Name: table.TableName()},
+				columnList(columns),
+			},
+		})
+	}
+
+	return statements, nil
+}
+
+// withPartitionKey returns the given columns, with the partition key appended if missing
+func withPartitionKey(columns []string) []string {
+	for _, column := range columns {
+		if column == partitionKey {
+			return columns
+		}
+	}
+	return append(columns, partitionKey)
+}
+
+// columnList returns the columns as a statement variable, rendered as a parenthesized list
+func columnList(columns []string) []interface{} {
+	result := make([]interface{}, len(columns))
+	for i, column := range columns {
+		result[i] = clause.Column{Name: column}
+	}
+	return result
+}
+
+// createPartitionedTable creates the given table partitioned by height
+func (db *Database) createPartitionedTable(ctx context.Context, table schema.Tabler) error {
+	statements, err := partitionedTableStatements(db.Db, table)
+	if err != nil {
+		return err
+	}
+
+	return db.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, s := range statements {
+			if err := tx.Exec(s.SQL, s.Vars...).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// isPartitionedTable tells whether table exists as a partitioned table
+func (db *Database) isPartitionedTable(ctx context.Context, table string) (bool, error) {
+	var partitioned bool
+	err := db.Db.WithContext(ctx).
+		Raw(`SELECT EXISTS(SELECT 1 FROM pg_partitioned_table WHERE partrelid = to_regclass(?))`, table).
+		Scan(&partitioned).Error
+	return partitioned, err
+}
+
+// createPartitionIfNotExists creates the partition of table holding the heights of the given partition id.
+// ErrRetiredPartition is returned when the partition exists detached from table.
+func (db *Database) createPartitionIfNotExists(ctx context.Context, table string, partitionID int64) error {
+	var detached bool
+	err := db.Db.WithContext(ctx).
+		Raw(`SELECT to_regclass(?) IS NOT NULL AND NOT EXISTS(SELECT 1 FROM pg_inherits WHERE inhrelid = to_regclass(?) AND inhparent = to_regclass(?))`,
+			partitionName(table, partitionID), partitionName(table, partitionID), table).
+		Scan(&detached).Error
+	if err != nil {
+		return err
+	}
+	if detached {
+		return fmt.Errorf("%w: %s is detached from %s", ErrRetiredPartition, partitionName(table, partitionID), table)
+	}
+
+	stmt := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM (%d) TO (%d)",
+		partitionName(table, partitionID),
+		table,
+		partitionID*db.partitions.size,
+		(partitionID+1)*db.partitions.size,
+	)
+	return db.Db.WithContext(ctx).Exec(stmt).Error
+}
+
+// getPartitions returns the ids of the partitions attached to table
+func (db *Database) getPartitions(ctx context.Context, table string) ([]int64, error) {
+	var names []string
+	err := db.Db.WithContext(ctx).
+		Raw(`SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid WHERE i.inhparent = to_regclass(?)`, table).
+		Scan(&names).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	for _, name := range names {
+		if id, ok := partitionID(table, name); ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// DetachPartition detaches the partition of table holding the given partition id. The partition is kept as a
+// standalone table, so that it can be archived.
+func (db *Database) DetachPartition(ctx context.Context, table string, partitionID int64) error {
+	stmt := fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s", table, partitionName(table, partitionID))
+	return db.Db.WithContext(ctx).Exec(stmt).Error
+}
+
+// DropPartition drops the partition of table holding the given partition id, with its rows
+func (db *Database) DropPartition(ctx context.Context, table string, partitionID int64) error {
+	stmt := fmt.Sprintf("DROP TABLE IF EXISTS %s", partitionName(table, partitionID))
+	return db.Db.WithContext(ctx).Exec(stmt).Error
+}
+
+// ensurePartitions makes sure that the partitions holding height and the following ones exist.
+// When the write head moves to a new partition, the partitions out of the retention window are retired.
+// Writing heights out of the retention window is refused with ErrRetiredPartition, since their partitions
+// are retired, or are about to be.
+func (db *Database) ensurePartitions(ctx context.Context, height uint64) error {
+	p := db.partitions
+	if p == nil {
+		return nil
+	}
+
+	id := int64(height) / p.size
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.head < 0 {
+		if err := db.loadHead(ctx); err != nil {
+			return err
+		}
+	}
+	if p.retention > 0 && p.head >= 0 && id < p.head-p.retention {
+		return fmt.Errorf("%w: height %d is in partition %d, the partitions before %d are out of the retention window",
+			ErrRetiredPartition, height, id, p.head-p.retention)
+	}
+
+	for next := id; next <= id+p.ahead; next++ {
+		if p.created[next] {
+			continue
+		}
+		for _, table := range p.tables {
+			if err := db.createPartitionIfNotExists(ctx, table, next); err != nil {
+				return fmt.Errorf("failed to create partition %s: %s", partitionName(table, next), err)
+			}
+		}
+		p.created[next] = true
+		log.Infow("created partitions", "tables", p.tables, "partition", next)
+	}
+
+	if id <= p.head {
+		return nil
+	}
+	p.head = id
+
+	return db.retirePartitions(ctx, id-p.retention)
+}
+
+// loadHead sets the write head from the partitions attached to the first table, the last ones being
+// the ones created ahead of it. The head is left unset when there is no partition yet.
+func (db *Database) loadHead(ctx context.Context) error {
+	p := db.partitions
+	ids, err := db.getPartitions(ctx, p.tables[0])
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	p.head = ids[len(ids)-1] - p.ahead
+	if p.head < ids[0] {
+		p.head = ids[0]
+	}
+	return nil
+}
+
+// prepareTxHashes creates the tx_hashes table, filled with the hashes of the txs already saved
+func (db *Database) prepareTxHashes(ctx context.Context) error {
+	if db.Db.Migrator().HasTable((&txHash{}).TableName()) {
+		return nil
+	}
+
+	return db.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().CreateTable(&txHash{}); err != nil {
+			return err
+		}
+		return tx.Exec(`INSERT INTO tx_hashes (hash, height) SELECT hash, MIN(height) FROM txs GROUP BY hash`).Error
+	})
+}
+
+// saveTxHash records the hash of a tx saved at height. ErrDuplicateTxHash is returned when the hash is
+// already saved at another height.
+func saveTxHash(tx *gorm.DB, hash common.Hash, height uint64) error {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&txHash{Hash: hash, Height: height}).Error
+	if err != nil {
+		return err
+	}
+
+	var saved txHash
+	if err := tx.Where("hash = ?", hash).Take(&saved).Error; err != nil {
+		return err
+	}
+	if saved.Height != height {
+		return fmt.Errorf("%w: tx %s is saved at height %d", ErrDuplicateTxHash, hash, saved.Height)
+	}
+	return nil
+}
+
+// retirePartitions detaches or drops the partitions with an id lower than before
+func (db *Database) retirePartitions(ctx context.Context, before int64) error {
+	p := db.partitions
+	if p.retention <= 0 {
+		return nil
+	}
+
+	for _, table := range p.tables {
+		ids, err := db.getPartitions(ctx, table)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if id >= before {
+				break
+			}
+
+			if p.drop {
+				err = db.DropPartition(ctx, table, id)
+			} else {
+				err = db.DetachPartition(ctx, table, id)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to retire partition %s: %s", partitionName(table, id), err)
+			}
+			delete(p.created, id)
+			log.Infow("retired partition", "table", table, "partition", id, "dropped", p.drop)
+		}
+	}
+	return nil
+}
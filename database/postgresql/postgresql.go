@@ -3,8 +3,15 @@ package postgresql
 import (
 	"context"
 
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/forbole/juno/v4/common"
 	"github.com/forbole/juno/v4/database"
 	"github.com/forbole/juno/v4/database/sqlclient"
+	"github.com/forbole/juno/v4/log"
+	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/types"
 )
 
 // Builder creates a database connection with the given database connection info
@@ -19,7 +26,9 @@ func Builder(ctx *database.Context) (database.Database, error) {
 		Impl: database.Impl{
 			Db:             db,
 			EncodingConfig: ctx.EncodingConfig,
+			Outbox:         ctx.Cfg.EnableOutbox,
 		},
+		partitions: newPartitioner(ctx.Cfg),
 	}, nil
 }
 
@@ -30,20 +39,73 @@ var _ database.Database = &Database{}
 // for data aggregation and exporting.
 type Database struct {
 	database.Impl
+
+	partitions *partitioner
 }
 
-// GetMissingHeights returns a slice of missing block heights between startHeight and endHeight
-func (db *Database) GetMissingHeights(ctx context.Context, startHeight, endHeight uint64) []uint64 {
-	var result []uint64
-	stmt := `SELECT generate_series($1::int,$2::int) EXCEPT SELECT height FROM blocks ORDER BY 1;`
-	err := db.Db.Select(&result, stmt, startHeight, endHeight)
-	if err != nil {
-		return nil
+// PrepareTables implements database.Database. The tables configured to be partitioned are created
+// partitioned by height ranges, the other ones as usual. The tx_hashes table is created along with the
+// partitioned txs table.
+func (db *Database) PrepareTables(ctx context.Context, tables []schema.Tabler) error {
+	var regular []schema.Tabler
+	for _, t := range tables {
+		if !db.partitions.isPartitioned(t.TableName()) {
+			regular = append(regular, t)
+			continue
+		}
+
+		if db.Db.Migrator().HasTable(t.TableName()) {
+			partitioned, err := db.isPartitionedTable(ctx, t.TableName())
+			if err != nil {
+				return err
+			}
+			if !partitioned {
+				log.Warnw("table exists and is not partitioned, its rows must be migrated to a partitioned table",
+					"table", t.TableName())
+			}
+			continue
+		}
+
+		if err := db.createPartitionedTable(ctx, t); err != nil {
+			log.Errorw("create partitioned table failed", "table", t.TableName(), "err", err)
+			return err
+		}
+	}
+
+	if db.partitions.isPartitioned((&models.Tx{}).TableName()) {
+		if err := db.prepareTxHashes(ctx); err != nil {
+			log.Errorw("create tx hashes table failed", "err", err)
+			return err
+		}
 	}
 
-	if len(result) == 0 {
-		return nil
+	return db.Impl.PrepareTables(ctx, regular)
+}
+
+// SaveBlock implements database.Database
+func (db *Database) SaveBlock(ctx context.Context, block *models.Block) error {
+	if err := db.ensurePartitions(ctx, block.Height); err != nil {
+		return err
+	}
+	return db.Impl.SaveBlock(ctx, block)
+}
+
+// SaveTx implements database.Database. The hash of a tx saved in the partitioned txs table is recorded
+// in the same transaction, so that a hash saved at another height is refused.
+func (db *Database) SaveTx(ctx context.Context, blockTimestamp uint64, index int, tx *types.Tx) error {
+	if err := db.ensurePartitions(ctx, uint64(tx.Height)); err != nil {
+		return err
+	}
+	if !db.partitions.isPartitioned((&models.Tx{}).TableName()) {
+		return db.Impl.SaveTx(ctx, blockTimestamp, index, tx)
 	}
 
-	return result
+	return db.Db.WithContext(ctx).Transaction(func(dbTx *gorm.DB) error {
+		if err := saveTxHash(dbTx, common.HexToHash(tx.TxHash), uint64(tx.Height)); err != nil {
+			return err
+		}
+		impl := db.Impl
+		impl.Db = dbTx
+		return impl.SaveTx(ctx, blockTimestamp, index, tx)
+	})
 }
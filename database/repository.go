@@ -0,0 +1,485 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/models"
+)
+
+const (
+	// DefaultPageLimit is the number of rows of a page when its limit is not set
+	DefaultPageLimit = 100
+	// MaxPageLimit is the maximum number of rows of a page
+	MaxPageLimit = 1000
+)
+
+// ErrInvalidCursor is returned when the cursor of a page was not returned by a list
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// Repository lists and gets the indexed resources. The lists are paginated with cursors: each page
+// is returned along with the cursor of the next one, which is empty when the page is the last one.
+// Removed resources are left out.
+type Repository interface {
+	// Snapshot runs fn in a read-only transaction: the reads made with the context given to fn all observe
+	// the same state of the index. The transaction is repeatable read, a snapshot on PostgreSQL and MySQL.
+	Snapshot(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// GetEpoch returns the last fully indexed block, the end of the first gap-free sync range.
+	// A zero value model is returned before the first block is processed.
+	GetEpoch(ctx context.Context) (*models.Epoch, error)
+
+	// GetSyncedHeight returns the end of the first gap-free sync range: every block from its start up to
+	// the returned height is processed. 0 is returned before the first block is processed.
+	GetSyncedHeight(ctx context.Context) (uint64, error)
+
+	// ListBlocks returns the blocks, newest first.
+	ListBlocks(ctx context.Context, page Page) ([]*models.Block, string, error)
+
+	// GetBlockByHeight returns the block at the given height.
+	// Nil is returned if the block is not indexed.
+	GetBlockByHeight(ctx context.Context, height uint64) (*models.Block, error)
+
+	// GetTxByHash returns the tx with the given hash.
+	// Nil is returned if the tx is not indexed.
+	GetTxByHash(ctx context.Context, hash common.Hash) (*models.Tx, error)
+
+	// ListTxsByHeight returns the txs of the block at the given height, in block order.
+	ListTxsByHeight(ctx context.Context, height uint64, page Page) ([]*models.Tx, string, error)
+
+	// GetBucket returns the bucket with the given id.
+	// Nil is returned if the bucket does not exist or is deleted.
+	GetBucket(ctx context.Context, bucketId common.Hash) (*models.Bucket, error)
+
+	// GetBucketByName returns the bucket with the given name.
+	// Nil is returned if the bucket does not exist or is deleted.
+	GetBucketByName(ctx context.Context, bucketName string) (*models.Bucket, error)
+
+	// GetBucketsByIDs returns the buckets with the given ids, in no particular order.
+	// The buckets which do not exist or are deleted are left out.
+	GetBucketsByIDs(ctx context.Context, bucketIDs []common.Hash) ([]*models.Bucket, error)
+
+	// ListBucketsByOwner returns the buckets of the owner, oldest first.
+	ListBucketsByOwner(ctx context.Context, owner common.Address, page Page) ([]*models.Bucket, string, error)
+
+	// ListObjectsByPrefix returns the objects of the bucket whose name starts with prefix, oldest first.
+	// On MySQL, the prefix is matched following the collation of the object_name column.
+	ListObjectsByPrefix(ctx context.Context, bucketName, prefix string, page Page) ([]*models.Object, string, error)
+
+	// SearchBuckets, SearchObjects and SearchGroups return the rows matching the search among both
+	// the rows of the table, removed ones included, and its archived rows. Rows are ordered by id.
+	SearchBuckets(ctx context.Context, search ArchiveSearch) ([]*models.Bucket, error)
+	SearchObjects(ctx context.Context, search ArchiveSearch) ([]*models.Object, error)
+	SearchGroups(ctx context.Context, search ArchiveSearch) ([]*models.Group, error)
+
+	// GetObject returns the object with the given id.
+	// Nil is returned if the object does not exist or is deleted.
+	GetObject(ctx context.Context, objectId common.Hash) (*models.Object, error)
+
+	// GetObjectByName returns the object of the bucket with the given name.
+	// Nil is returned if the object does not exist or is deleted.
+	GetObjectByName(ctx context.Context, bucketName, objectName string) (*models.Object, error)
+
+	// GetGroup returns the group itself, without its members.
+	// Nil is returned if the group does not exist or is deleted.
+	GetGroup(ctx context.Context, groupID common.Hash) (*models.Group, error)
+
+	// GetGroupByName returns the group of the owner with the given name, without its members.
+	// Nil is returned if the group does not exist or is deleted.
+	GetGroupByName(ctx context.Context, owner common.Address, groupName string) (*models.Group, error)
+
+	// ListGroupsByOwner returns the groups of the owner, without their members, oldest first.
+	ListGroupsByOwner(ctx context.Context, owner common.Address, page Page) ([]*models.Group, string, error)
+
+	// GetGroupMember returns the membership of the account in the group.
+	// Nil is returned if the account is not a member of the group.
+	GetGroupMember(ctx context.Context, groupID common.Hash, account common.Address) (*models.Group, error)
+
+	// ListGroupMembers returns the members of the group, oldest first.
+	ListGroupMembers(ctx context.Context, groupID common.Hash, page Page) ([]*models.Group, string, error)
+
+	// ListPermissionsByResource returns the policies of the resource, oldest first.
+	ListPermissionsByResource(ctx context.Context, resourceType string, resourceID common.Hash, page Page) ([]*models.Permission, string, error)
+
+	// ListStorageProviders returns the sps, ordered by id.
+	ListStorageProviders(ctx context.Context, page Page) ([]*models.StorageProvider, string, error)
+
+	// GetStorageProvider returns the sp with given spId.
+	// Nil is returned if the sp does not exist or is removed.
+	GetStorageProvider(ctx context.Context, spId uint32) (*models.StorageProvider, error)
+
+	// GetStorageProvidersByIDs returns the sps with the given ids, in no particular order.
+	// The sps which do not exist or are removed are left out.
+	GetStorageProvidersByIDs(ctx context.Context, spIds []uint32) ([]*models.StorageProvider, error)
+
+	// GetStorageProviderByOperator returns the sp with the given operator address.
+	// Nil is returned if the sp does not exist or is removed.
+	GetStorageProviderByOperator(ctx context.Context, operator common.Address) (*models.StorageProvider, error)
+
+	// GetGVG returns the global virtual group with the given id.
+	// Nil is returned if it does not exist or is removed.
+	GetGVG(ctx context.Context, gvgId uint32) (*models.GlobalVirtualGroup, error)
+
+	// GetGVGsByIDs returns the global virtual groups with the given ids, in no particular order.
+	// The groups which do not exist or are removed are left out.
+	GetGVGsByIDs(ctx context.Context, gvgIds []uint32) ([]*models.GlobalVirtualGroup, error)
+
+	// ListGVGsByFamily returns the global virtual groups of the family, ordered by id.
+	ListGVGsByFamily(ctx context.Context, familyId uint32, page Page) ([]*models.GlobalVirtualGroup, string, error)
+
+	// ListLVGsByBucket returns the local virtual groups of the bucket, ordered by id.
+	ListLVGsByBucket(ctx context.Context, bucketID common.Hash, page Page) ([]*models.LocalVirtualGroup, string, error)
+
+	// GetLVGsByBucketIDs returns the local virtual groups of the buckets, ordered by id.
+	GetLVGsByBucketIDs(ctx context.Context, bucketIDs []common.Hash) ([]*models.LocalVirtualGroup, error)
+
+	// GetPaymentAccount returns the payment account with the given address.
+	// Nil is returned if the payment account does not exist.
+	GetPaymentAccount(ctx context.Context, addr common.Address) (*models.PaymentAccount, error)
+
+	// ListPaymentAccountsByOwner returns the payment accounts of the owner, oldest first.
+	ListPaymentAccountsByOwner(ctx context.Context, owner common.Address, page Page) ([]*models.PaymentAccount, string, error)
+
+	// GetStreamRecord returns the stream record of the account.
+	// Nil is returned if the account has no stream record.
+	GetStreamRecord(ctx context.Context, account common.Address) (*models.StreamRecord, error)
+
+	// GetStreamRecordsByAccounts returns the stream records of the accounts, in no particular order.
+	// The accounts which have no stream record are left out.
+	GetStreamRecordsByAccounts(ctx context.Context, accounts []common.Address) ([]*models.StreamRecord, error)
+}
+
+// readOnly is a Repository without the other methods of the database it reads from
+type readOnly struct {
+	Repository
+}
+
+// ReadOnly returns the reads of repo alone, so that the servers given it cannot reach the writes of the
+// database through a type assertion
+func ReadOnly(repo Repository) Repository {
+	return readOnly{Repository: repo}
+}
+
+// Page selects a page of a list
+type Page struct {
+	// Cursor is the cursor returned along with the previous page, empty for the first page
+	Cursor string
+	// Limit is the maximum number of rows of the page, DefaultPageLimit when 0, at most MaxPageLimit
+	Limit int
+}
+
+func (p Page) limit() int {
+	if p.Limit <= 0 {
+		return DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return p.Limit
+}
+
+// encodeCursor returns the cursor of the page starting after the row with the given key
+func encodeCursor(key uint64) string {
+	bz := make([]byte, 8)
+	binary.BigEndian.PutUint64(bz, key)
+	return base64.RawURLEncoding.EncodeToString(bz)
+}
+
+// decodeCursor returns the key of the row the page of the cursor starts after
+func decodeCursor(cursor string) (uint64, error) {
+	bz, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(bz) != 8 {
+		return 0, ErrInvalidCursor
+	}
+	return binary.BigEndian.Uint64(bz), nil
+}
+
+// paginate returns the page of the rows selected by query, ordered by the column holding their key,
+// and the cursor of the next page
+func paginate[T any](query *gorm.DB, page Page, column string, key func(*T) uint64) ([]*T, string, error) {
+	return seek(query, page, column, false, key)
+}
+
+// paginateDesc is paginate with the rows in descending order
+func paginateDesc[T any](query *gorm.DB, page Page, column string, key func(*T) uint64) ([]*T, string, error) {
+	return seek(query, page, column, true, key)
+}
+
+func seek[T any](query *gorm.DB, page Page, column string, desc bool, key func(*T) uint64) ([]*T, string, error) {
+	if page.Cursor != "" {
+		after, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if desc {
+			query = query.Where(clause.Lt{Column: clause.Column{Name: column}, Value: after})
+		} else {
+			query = query.Where(clause.Gt{Column: clause.Column{Name: column}, Value: after})
+		}
+	}
+
+	// one more row is read to tell whether there is a next page
+	limit := page.limit()
+	var rows []*T
+	err := query.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc}).Limit(limit + 1).Find(&rows).Error
+	if err != nil {
+		return nil, "", err
+	}
+	if len(rows) <= limit {
+		return rows, "", nil
+	}
+
+	rows = rows[:limit]
+	return rows, encodeCursor(key(rows[limit-1])), nil
+}
+
+// prefixEscaper escapes the wildcards of a LIKE pattern, with ! as escape character. Backslash is not used,
+// as it is also the escape character of the MySQL string literals.
+var prefixEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// snapshotKey is the context key of the transaction started by Snapshot
+type snapshotKey struct{}
+
+type snapshotTx struct {
+	// root is the connection the transaction was started from, so that the databases of a dual setup
+	// don't serve their reads from the transaction of the other one
+	root *gorm.DB
+	tx   *gorm.DB
+}
+
+// Snapshot implements Repository. A snapshot started inside another one runs in the outer transaction.
+func (db *Impl) Snapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	if db.snapshot(ctx) != nil {
+		return fn(ctx)
+	}
+	if err := db.FlushWriteBuffer(ctx); err != nil {
+		return err
+	}
+
+	return db.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, snapshotKey{}, &snapshotTx{root: db.Db, tx: tx}))
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+}
+
+// snapshot returns the transaction of the snapshot of ctx, nil when ctx is not in a snapshot of this database
+func (db *Impl) snapshot(ctx context.Context) *gorm.DB {
+	s, ok := ctx.Value(snapshotKey{}).(*snapshotTx)
+	if !ok || s.root != db.Db {
+		return nil
+	}
+	return s.tx
+}
+
+func (db *Impl) ListBlocks(ctx context.Context, page Page) ([]*models.Block, string, error) {
+	return paginateDesc(db.session(ctx).Model(&models.Block{}), page, "height", func(b *models.Block) uint64 { return b.Height })
+}
+
+func (db *Impl) GetBlockByHeight(ctx context.Context, height uint64) (*models.Block, error) {
+	var block models.Block
+
+	err := db.session(ctx).Where("height = ?", height).Take(&block).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+func (db *Impl) GetTxByHash(ctx context.Context, hash common.Hash) (*models.Tx, error) {
+	var tx models.Tx
+
+	err := db.session(ctx).Where("hash = ?", hash).Take(&tx).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+func (db *Impl) ListTxsByHeight(ctx context.Context, height uint64, page Page) ([]*models.Tx, string, error) {
+	query := db.session(ctx).Where("height = ?", height)
+	return paginate(query, page, "tx_index", func(t *models.Tx) uint64 { return uint64(t.TxIndex) })
+}
+
+func (db *Impl) GetBucketsByIDs(ctx context.Context, bucketIDs []common.Hash) ([]*models.Bucket, error) {
+	var buckets []*models.Bucket
+	if len(bucketIDs) == 0 {
+		return buckets, nil
+	}
+
+	err := db.session(ctx).Where("bucket_id IN ? AND removed IS NOT TRUE", bucketIDs).Find(&buckets).Error
+	return buckets, err
+}
+
+func (db *Impl) ListBucketsByOwner(ctx context.Context, owner common.Address, page Page) ([]*models.Bucket, string, error) {
+	query := db.session(ctx).Where("owner = ? AND removed IS NOT TRUE", owner)
+	return paginate(query, page, "id", func(b *models.Bucket) uint64 { return b.ID })
+}
+
+func (db *Impl) ListObjectsByPrefix(ctx context.Context, bucketName, prefix string, page Page) ([]*models.Object, string, error) {
+	query := db.session(ctx).Where("bucket_name = ? AND removed IS NOT TRUE", bucketName)
+	if prefix != "" {
+		query = query.Where("object_name LIKE ? ESCAPE '!'", prefixEscaper.Replace(prefix)+"%")
+	}
+	return paginate(query, page, "id", func(o *models.Object) uint64 { return o.ID })
+}
+
+func (db *Impl) GetObjectByName(ctx context.Context, bucketName, objectName string) (*models.Object, error) {
+	var object models.Object
+
+	err := db.session(ctx).Where("bucket_name = ? AND object_name = ? AND removed IS NOT TRUE", bucketName, objectName).
+		Take(&object).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &object, nil
+}
+
+func (db *Impl) ListGroupMembers(ctx context.Context, groupID common.Hash, page Page) ([]*models.Group, string, error) {
+	// the row of the group itself has no account
+	query := db.session(ctx).Where("group_id = ? AND account_id <> ? AND removed IS NOT TRUE", groupID, common.HexToAddress("0"))
+	return paginate(query, page, "id", func(g *models.Group) uint64 { return g.ID })
+}
+
+func (db *Impl) GetGroupByName(ctx context.Context, owner common.Address, groupName string) (*models.Group, error) {
+	var group models.Group
+
+	err := db.session(ctx).Where("owner = ? AND group_name = ? AND account_id = ? AND removed IS NOT TRUE",
+		owner, groupName, common.HexToAddress("0")).Take(&group).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (db *Impl) ListGroupsByOwner(ctx context.Context, owner common.Address, page Page) ([]*models.Group, string, error) {
+	query := db.session(ctx).Where("owner = ? AND account_id = ? AND removed IS NOT TRUE", owner, common.HexToAddress("0"))
+	return paginate(query, page, "id", func(g *models.Group) uint64 { return g.ID })
+}
+
+func (db *Impl) ListPermissionsByResource(ctx context.Context, resourceType string, resourceID common.Hash, page Page) ([]*models.Permission, string, error) {
+	query := db.session(ctx).Where("resource_type = ? AND resource_id = ? AND removed IS NOT TRUE", resourceType, resourceID)
+	return paginate(query, page, "id", func(p *models.Permission) uint64 { return p.ID })
+}
+
+func (db *Impl) ListStorageProviders(ctx context.Context, page Page) ([]*models.StorageProvider, string, error) {
+	query := db.session(ctx).Where("removed IS NOT TRUE")
+	return paginate(query, page, "sp_id", func(sp *models.StorageProvider) uint64 { return uint64(sp.SpId) })
+}
+
+func (db *Impl) GetStorageProvidersByIDs(ctx context.Context, spIds []uint32) ([]*models.StorageProvider, error) {
+	var storageProviders []*models.StorageProvider
+	if len(spIds) == 0 {
+		return storageProviders, nil
+	}
+
+	err := db.session(ctx).Where("sp_id IN ? AND removed IS NOT TRUE", spIds).Find(&storageProviders).Error
+	return storageProviders, err
+}
+
+func (db *Impl) GetStorageProviderByOperator(ctx context.Context, operator common.Address) (*models.StorageProvider, error) {
+	var storageProvider models.StorageProvider
+
+	err := db.session(ctx).Where("operator_address = ? AND removed IS NOT TRUE", operator).Take(&storageProvider).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &storageProvider, nil
+}
+
+func (db *Impl) GetGVGsByIDs(ctx context.Context, gvgIds []uint32) ([]*models.GlobalVirtualGroup, error) {
+	var gvgs []*models.GlobalVirtualGroup
+	if len(gvgIds) == 0 {
+		return gvgs, nil
+	}
+
+	err := db.session(ctx).Where("global_virtual_group_id IN ? AND removed IS NOT TRUE", gvgIds).Find(&gvgs).Error
+	return gvgs, err
+}
+
+func (db *Impl) ListGVGsByFamily(ctx context.Context, familyId uint32, page Page) ([]*models.GlobalVirtualGroup, string, error) {
+	query := db.session(ctx).Where("family_id = ? AND removed IS NOT TRUE", familyId)
+	return paginate(query, page, "global_virtual_group_id", func(g *models.GlobalVirtualGroup) uint64 {
+		return uint64(g.GlobalVirtualGroupId)
+	})
+}
+
+func (db *Impl) ListLVGsByBucket(ctx context.Context, bucketID common.Hash, page Page) ([]*models.LocalVirtualGroup, string, error) {
+	query := db.session(ctx).Where("bucket_id = ? AND removed IS NOT TRUE", bucketID)
+	return paginate(query, page, "local_virtual_group_id", func(l *models.LocalVirtualGroup) uint64 {
+		return uint64(l.LocalVirtualGroupId)
+	})
+}
+
+func (db *Impl) GetLVGsByBucketIDs(ctx context.Context, bucketIDs []common.Hash) ([]*models.LocalVirtualGroup, error) {
+	var lvgs []*models.LocalVirtualGroup
+	if len(bucketIDs) == 0 {
+		return lvgs, nil
+	}
+
+	err := db.session(ctx).Where("bucket_id IN ? AND removed IS NOT TRUE", bucketIDs).
+		Order("local_virtual_group_id").Find(&lvgs).Error
+	return lvgs, err
+}
+
+func (db *Impl) GetPaymentAccount(ctx context.Context, addr common.Address) (*models.PaymentAccount, error) {
+	var paymentAccount models.PaymentAccount
+
+	err := db.session(ctx).Where("addr = ?", addr).Take(&paymentAccount).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &paymentAccount, nil
+}
+
+func (db *Impl) ListPaymentAccountsByOwner(ctx context.Context, owner common.Address, page Page) ([]*models.PaymentAccount, string, error) {
+	query := db.session(ctx).Where("owner = ?", owner)
+	return paginate(query, page, "id", func(p *models.PaymentAccount) uint64 { return p.ID })
+}
+
+func (db *Impl) GetStreamRecord(ctx context.Context, account common.Address) (*models.StreamRecord, error) {
+	var streamRecord models.StreamRecord
+
+	err := db.session(ctx).Where("account = ?", account).Take(&streamRecord).Error
+	if errIsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &streamRecord, nil
+}
+
+func (db *Impl) GetStreamRecordsByAccounts(ctx context.Context, accounts []common.Address) ([]*models.StreamRecord, error) {
+	var streamRecords []*models.StreamRecord
+	if len(accounts) == 0 {
+		return streamRecords, nil
+	}
+
+	err := db.session(ctx).Where("account IN ?", accounts).Find(&streamRecords).Error
+	return streamRecords, err
+}
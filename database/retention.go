@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/forbole/juno/v4/models"
+)
+
+// secondsPerDay is the number of seconds of a retention day
+const secondsPerDay = 24 * 60 * 60
+
+// RetentionPolicy describes which rows of a table are kept. When both KeepHeights and KeepDays are set,
+// a row is kept as long as it is inside any of the two windows.
+type RetentionPolicy struct {
+	Table string `yaml:"table"`
+	// KeepHeights keeps the rows of the last KeepHeights heights
+	KeepHeights uint64 `yaml:"keep_heights"`
+	// KeepDays keeps the rows of the last KeepDays days
+	KeepDays uint64 `yaml:"keep_days"`
+	// KeepEvery keeps the rows of every KeepEvery-th height, whatever their age. Not supported by tombstone tables.
+	KeepEvery uint64 `yaml:"keep_every"`
+}
+
+// retentionTable describes the columns a retention policy of a table applies to
+type retentionTable struct {
+	model        schema.Tabler
+	heightColumn string
+	timeColumn   string
+	// tombstone tells that only the rows marked as removed are pruned
+	tombstone bool
+}
+
+// retentionTables are the tables which can be pruned, by name
+var retentionTables = map[string]retentionTable{
+	(&models.Block{}).TableName():  {model: &models.Block{}, heightColumn: "height", timeColumn: "timestamp"},
+	(&models.Tx{}).TableName():     {model: &models.Tx{}, heightColumn: "height", timeColumn: "timestamp"},
+	(&models.Bucket{}).TableName(): {model: &models.Bucket{}, heightColumn: "update_at", timeColumn: "update_time", tombstone: true},
+	(&models.Object{}).TableName(): {model: &models.Object{}, heightColumn: "update_at", timeColumn: "update_time", tombstone: true},
+	(&models.Group{}).TableName():  {model: &models.Group{}, heightColumn: "update_at", timeColumn: "update_time", tombstone: true},
+
+	(&models.BucketArchive{}).TableName(): {model: &models.BucketArchive{}, heightColumn: "update_at", timeColumn: "update_time", tombstone: true},
+	(&models.ObjectArchive{}).TableName(): {model: &models.ObjectArchive{}, heightColumn: "update_at", timeColumn: "update_time", tombstone: true},
+	(&models.GroupArchive{}).TableName():  {model: &models.GroupArchive{}, heightColumn: "update_at", timeColumn: "update_time", tombstone: true},
+}
+
+// Validate returns an error if the policy cannot be applied
+func (p RetentionPolicy) Validate() error {
+	table, ok := retentionTables[p.Table]
+	if !ok {
+		return fmt.Errorf("retention of table %q is not supported", p.Table)
+	}
+	if p.KeepHeights == 0 && p.KeepDays == 0 {
+		return fmt.Errorf("retention of table %s keeps all the rows, set keep_heights or keep_days", p.Table)
+	}
+	if table.tombstone && p.KeepEvery > 0 {
+		return fmt.Errorf("retention of table %s does not support keep_every", p.Table)
+	}
+	return nil
+}
+
+// expired returns the query selecting the rows of the table falling out of the policy
+func (p RetentionPolicy) expired(db *gorm.DB, latestHeight uint64, now int64) *gorm.DB {
+	table := retentionTables[p.Table]
+	query := db.Table(p.Table)
+	if table.tombstone {
+		query = query.Where("removed IS TRUE")
+	}
+
+	if p.KeepHeights > 0 {
+		var cutoff uint64
+		if latestHeight > p.KeepHeights {
+			cutoff = latestHeight - p.KeepHeights
+		}
+		query = query.Where(fmt.Sprintf("%s <= ?", table.heightColumn), cutoff)
+	}
+	if p.KeepDays > 0 {
+		query = query.Where(fmt.Sprintf("%s < ?", table.timeColumn), now-int64(p.KeepDays)*secondsPerDay)
+	}
+	if p.KeepEvery > 0 {
+		query = query.Where(fmt.Sprintf("%s %% ? <> 0", table.heightColumn), p.KeepEvery)
+	}
+	return query
+}
+
+// ApplyRetention deletes at most batchSize rows of the table of the policy which fall out of it, and returns
+// the number of deleted rows. The windows of the policy end at latestHeight and now (seconds).
+func (db *Impl) ApplyRetention(ctx context.Context, policy RetentionPolicy, latestHeight uint64, now int64, batchSize int) (int64, error) {
+	if err := policy.Validate(); err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	err := db.session(ctx).Transaction(func(tx *gorm.DB) error {
+		// the ids are selected first, as MySQL does not support LIMIT in a subquery of DELETE
+		var ids []uint64
+		err := policy.expired(tx, latestHeight, now).Order("id").Limit(batchSize).Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		result := tx.Where("id IN ?", ids).Delete(retentionTables[policy.Table].model)
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/models"
+)
+
+func TestApplyRetention_Heights(t *testing.T) {
+	db := newSqliteImpl(t, "retention_heights")
+	require.NoError(t, db.Db.AutoMigrate(&models.Block{}))
+	for height := uint64(1); height <= 10; height++ {
+		require.NoError(t, db.Db.Create(&models.Block{
+			BlockID: models.BlockID{Hash: common.BigToHash(new(big.Int).SetUint64(height))},
+			Header:  models.Header{Height: height, Timestamp: height},
+		}).Error)
+	}
+
+	policy := RetentionPolicy{Table: "blocks", KeepHeights: 3, KeepEvery: 4}
+	deleted, err := db.ApplyRetention(context.Background(), policy, 10, 0, 4)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), deleted)
+	deleted, err = db.ApplyRetention(context.Background(), policy, 10, 0, 4)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), deleted)
+	deleted, err = db.ApplyRetention(context.Background(), policy, 10, 0, 4)
+	require.NoError(t, err)
+	require.Zero(t, deleted)
+
+	var heights []uint64
+	require.NoError(t, db.Db.Model(&models.Block{}).Order("height").Pluck("height", &heights).Error)
+	require.Equal(t, []uint64{4, 8, 9, 10}, heights)
+
+	// rows are kept while inside any of the windows
+	policy = RetentionPolicy{Table: "blocks", KeepHeights: 1, KeepDays: 1}
+	deleted, err = db.ApplyRetention(context.Background(), policy, 10, secondsPerDay+9, 100)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), deleted)
+}
+
+func TestApplyRetention_Tombstones(t *testing.T) {
+	db := newSqliteImpl(t, "retention_tombstones")
+	now := int64(100 * secondsPerDay)
+	buckets := []*models.Bucket{
+		{BucketID: common.HexToHash("0x01"), BucketName: "removed-old", Removed: true, UpdateTime: now - 10*secondsPerDay},
+		{BucketID: common.HexToHash("0x02"), BucketName: "removed-recent", Removed: true, UpdateTime: now - secondsPerDay/2},
+		{BucketID: common.HexToHash("0x03"), BucketName: "live-old", UpdateTime: now - 10*secondsPerDay},
+	}
+	require.NoError(t, db.Db.Create(buckets).Error)
+
+	deleted, err := db.ApplyRetention(context.Background(), RetentionPolicy{Table: "buckets", KeepDays: 7}, 0, now, 100)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	var names []string
+	require.NoError(t, db.Db.Model(&models.Bucket{}).Order("bucket_name").Pluck("bucket_name", &names).Error)
+	require.Equal(t, []string{"live-old", "removed-recent"}, names)
+}
+
+func TestRetentionPolicy_Validate(t *testing.T) {
+	require.NoError(t, RetentionPolicy{Table: "txs", KeepDays: 30}.Validate())
+	require.Error(t, RetentionPolicy{Table: "messages", KeepDays: 30}.Validate())
+	require.Error(t, RetentionPolicy{Table: "txs"}.Validate())
+	require.Error(t, RetentionPolicy{Table: "objects", KeepDays: 30, KeepEvery: 10}.Validate())
+}
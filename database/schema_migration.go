@@ -0,0 +1,244 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/forbole/juno/v4/log"
+	"github.com/forbole/juno/v4/models"
+)
+
+// AnyDialect is the key of the migration steps used for the dialects without steps of their own
+const AnyDialect = ""
+
+// MigrationStep applies, or reverts, a migration within tx
+type MigrationStep func(tx *gorm.DB) error
+
+// MigrationSteps are the steps of a migration per dialect, keyed by gorm.Dialector.Name ("mysql", "postgres", "sqlite")
+type MigrationSteps map[string]MigrationStep
+
+// Migration is a numbered change of the database schema, applied in version order (see schema_versions.go).
+// On start, the missing tables are first created from the models, then the pending migrations run, and only
+// then are the existing tables auto migrated. A step therefore runs against tables in the schema of the previous
+// version, or created up to date from the models, and the tables of the disabled modules may not exist: it must
+// work in all three cases, e.g. by checking tx.Migrator().HasTable or HasIndex before changing them.
+type Migration struct {
+	Version     uint64
+	Description string
+	Up          MigrationSteps
+	Down        MigrationSteps
+}
+
+// MigrationStatus describes a migration and whether it is applied to the database
+type MigrationStatus struct {
+	Version     uint64
+	Description string
+	Applied     bool
+	AppliedAt   int64
+	// Unknown is set when the migration is applied to the database but not registered in this binary
+	Unknown bool
+}
+
+var migrations []Migration
+
+// RegisterMigrations adds the given migrations to the ones applied by MigrateUp. It panics if a version is
+// already registered.
+func RegisterMigrations(ms ...Migration) {
+	for _, m := range ms {
+		for _, registered := range migrations {
+			if registered.Version == m.Version {
+				panic(fmt.Sprintf("migration %d is already registered", m.Version))
+			}
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+}
+
+// SQLStep returns a step executing the given statements in order
+func SQLStep(statements ...string) MigrationStep {
+	return func(tx *gorm.DB) error {
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// NoopStep is the step of the dialects a migration doesn't apply to
+func NoopStep(*gorm.DB) error {
+	return nil
+}
+
+func (s MigrationSteps) forDialect(dialect string) MigrationStep {
+	if step, ok := s[dialect]; ok {
+		return step
+	}
+	return s[AnyDialect]
+}
+
+// type check to ensure interface is properly implemented
+var _ Migrator = &Impl{}
+
+// Migrate implements Migrator, it applies all the pending migrations
+func (db *Impl) Migrate() error {
+	_, err := db.MigrateUp(context.Background(), 0)
+	return err
+}
+
+func (db *Impl) appliedMigrations(ctx context.Context) (map[uint64]*models.SchemaMigration, error) {
+	if err := db.PrepareTables(ctx, []schema.Tabler{&models.SchemaMigration{}}); err != nil {
+		return nil, err
+	}
+
+	var applied []*models.SchemaMigration
+	if err := db.session(ctx).Order("version ASC").Find(&applied).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint64]*models.SchemaMigration, len(applied))
+	for _, m := range applied {
+		result[m.Version] = m
+	}
+	return result, nil
+}
+
+// GetMigrationStatus returns the status of the registered migrations and of the unknown ones applied
+// to the database, ordered by version
+func (db *Impl) GetMigrationStatus(ctx context.Context) ([]*MigrationStatus, error) {
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*MigrationStatus
+	for _, m := range migrations {
+		status := &MigrationStatus{Version: m.Version, Description: m.Description}
+		if a, ok := applied[m.Version]; ok {
+			status.Applied, status.AppliedAt = true, a.AppliedAt
+			delete(applied, m.Version)
+		}
+		result = append(result, status)
+	}
+	for _, a := range applied {
+		result = append(result, &MigrationStatus{
+			Version:     a.Version,
+			Description: a.Description,
+			Applied:     true,
+			AppliedAt:   a.AppliedAt,
+			Unknown:     true,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// CheckSchemaVersion returns an error if the database has migrations applied that are unknown to this binary,
+// which happens when the database was migrated by a newer version
+func (db *Impl) CheckSchemaVersion(ctx context.Context) error {
+	statuses, err := db.GetMigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range statuses {
+		if s.Unknown {
+			return fmt.Errorf("database schema has the unknown migration %d (%s), it was migrated by a newer version",
+				s.Version, s.Description)
+		}
+	}
+	return nil
+}
+
+// MigrateUp applies up to steps pending migrations in version order, all of them if steps is 0.
+// It returns the applied migrations.
+func (db *Impl) MigrateUp(ctx context.Context, steps int) ([]Migration, error) {
+	if err := db.CheckSchemaVersion(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if steps > 0 && len(done) == steps {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		err = db.runMigration(ctx, m, m.Up, func(tx *gorm.DB) error {
+			return tx.Create(&models.SchemaMigration{
+				Version:     m.Version,
+				Description: m.Description,
+				AppliedAt:   time.Now().Unix(),
+			}).Error
+		})
+		if err != nil {
+			return done, err
+		}
+		log.Infow("applied migration", "version", m.Version, "description", m.Description)
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrateDown reverts the last steps applied migrations, newest first. It returns the reverted migrations.
+func (db *Impl) MigrateDown(ctx context.Context, steps int) ([]Migration, error) {
+	if err := db.CheckSchemaVersion(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		err = db.runMigration(ctx, m, m.Down, func(tx *gorm.DB) error {
+			return tx.Delete(&models.SchemaMigration{}, "version = ?", m.Version).Error
+		})
+		if err != nil {
+			return done, err
+		}
+		log.Infow("reverted migration", "version", m.Version, "description", m.Description)
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// runMigration runs the step of the dialect of the database and records it in a single transaction
+func (db *Impl) runMigration(ctx context.Context, m Migration, steps MigrationSteps, record func(tx *gorm.DB) error) error {
+	dialect := db.Db.Dialector.Name()
+	step := steps.forDialect(dialect)
+	if step == nil {
+		return fmt.Errorf("migration %d (%s) has no step for %s", m.Version, m.Description, dialect)
+	}
+
+	err := db.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := step(tx); err != nil {
+			return err
+		}
+		return record(tx)
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %s", m.Version, m.Description, err)
+	}
+	return nil
+}
@@ -0,0 +1,364 @@
+package database
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/models"
+)
+
+type migrationNote struct {
+	ID   uint64 `gorm:"column:id;primaryKey"`
+	Note string `gorm:"column:note"`
+}
+
+func (*migrationNote) TableName() string {
+	return "migration_notes"
+}
+
+// withMigrations replaces the registered migrations for the duration of the test
+func withMigrations(t *testing.T, ms ...Migration) {
+	registered := migrations
+	migrations = nil
+	RegisterMigrations(ms...)
+	t.Cleanup(func() { migrations = registered })
+}
+
+func TestMigrations_UpDownStatus(t *testing.T) {
+	withMigrations(t,
+		Migration{
+			Version:     2,
+			Description: "add a note",
+			Up:          MigrationSteps{AnyDialect: SQLStep("INSERT INTO migration_notes (note) VALUES ('second')")},
+			Down:        MigrationSteps{AnyDialect: SQLStep("DELETE FROM migration_notes WHERE note = 'second'")},
+		},
+		Migration{
+			Version:     1,
+			Description: "create the notes",
+			Up: MigrationSteps{
+				"mysql":  SQLStep("CREATE TABLE migration_notes (id BIGINT AUTO_INCREMENT PRIMARY KEY, note TEXT)"),
+				"sqlite": func(tx *gorm.DB) error { return tx.Migrator().CreateTable(&migrationNote{}) },
+			},
+			Down: MigrationSteps{AnyDialect: func(tx *gorm.DB) error { return tx.Migrator().DropTable(&migrationNote{}) }},
+		},
+	)
+
+	ctx := context.Background()
+	db := newSqliteImpl(t, "migrations_up_down")
+
+	done, err := db.MigrateUp(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	require.Equal(t, uint64(1), done[0].Version)
+
+	done, err = db.MigrateUp(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	require.Equal(t, uint64(2), done[0].Version)
+
+	var notes []*migrationNote
+	require.NoError(t, db.Db.Find(&notes).Error)
+	require.Len(t, notes, 1)
+
+	statuses, err := db.GetMigrationStatus(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	require.True(t, statuses[0].Applied && statuses[1].Applied)
+
+	done, err = db.MigrateDown(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	require.Equal(t, uint64(2), done[0].Version)
+	require.NoError(t, db.Db.Find(&notes).Error)
+	require.Empty(t, notes)
+
+	done, err = db.MigrateDown(ctx, 5)
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	require.False(t, db.Db.Migrator().HasTable(&migrationNote{}))
+
+	statuses, err = db.GetMigrationStatus(ctx)
+	require.NoError(t, err)
+	require.False(t, statuses[0].Applied || statuses[1].Applied)
+}
+
+func TestMigrations_FailedStepIsNotRecorded(t *testing.T) {
+	withMigrations(t,
+		Migration{Version: 1, Description: "mysql only", Up: MigrationSteps{"mysql": NoopStep}},
+	)
+
+	ctx := context.Background()
+	db := newSqliteImpl(t, "migrations_failed")
+
+	_, err := db.MigrateUp(ctx, 0)
+	require.Error(t, err)
+
+	statuses, err := db.GetMigrationStatus(ctx)
+	require.NoError(t, err)
+	require.False(t, statuses[0].Applied)
+}
+
+func TestMigrations_RefusesNewerSchema(t *testing.T) {
+	withMigrations(t,
+		Migration{Version: 1, Description: "noop", Up: MigrationSteps{AnyDialect: NoopStep}},
+	)
+
+	ctx := context.Background()
+	db := newSqliteImpl(t, "migrations_newer")
+
+	_, err := db.MigrateUp(ctx, 0)
+	require.NoError(t, err)
+	require.NoError(t, db.CheckSchemaVersion(ctx))
+
+	// a newer binary applied a migration this one doesn't know about
+	require.NoError(t, db.Db.Create(&models.SchemaMigration{Version: 7, Description: "from the future"}).Error)
+	require.Error(t, db.CheckSchemaVersion(ctx))
+
+	statuses, err := db.GetMigrationStatus(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	require.True(t, statuses[1].Unknown)
+
+	_, err = db.MigrateUp(ctx, 0)
+	require.Error(t, err)
+}
+
+func TestRegisteredMigrations(t *testing.T) {
+	ctx := context.Background()
+	db := newSqliteImpl(t, "migrations_registered")
+	require.NoError(t, db.Db.AutoMigrate(&models.Statements{}))
+
+	done, err := db.MigrateUp(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, done, len(migrations))
+
+	done, err = db.MigrateDown(ctx, len(migrations))
+	require.NoError(t, err)
+	require.Len(t, done, len(migrations))
+}
+
+func TestMigrations_SeedSyncRanges(t *testing.T) {
+	ctx := context.Background()
+	db := newSqliteImpl(t, "migrations_seed_sync_ranges")
+	require.NoError(t, db.Db.AutoMigrate(&models.Statements{}, &models.Block{}))
+	for _, height := range []uint64{1, 2, 3, 5, 7, 8} {
+		require.NoError(t, db.Db.Create(&models.Block{
+			BlockID: models.BlockID{Hash: common.BigToHash(new(big.Int).SetUint64(height))},
+			Header:  models.Header{Height: height},
+		}).Error)
+	}
+
+	_, err := db.MigrateUp(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, []HeightRange{{1, 3}, {5, 5}, {7, 8}}, syncRanges(t, db))
+
+	// the progress survives reverting and applying the migration again, once the blocks are pruned
+	require.NoError(t, db.SaveProcessedHeight(ctx, 4))
+	require.NoError(t, db.Db.Where("height < ?", 7).Delete(&models.Block{}).Error)
+	_, err = db.MigrateDown(ctx, len(migrations)-2)
+	require.NoError(t, err)
+	_, err = db.MigrateUp(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, []HeightRange{{1, 5}, {7, 8}}, syncRanges(t, db))
+}
+
+// legacyStorageProvider is the storage provider table before its sp_id index was made unique
+type legacyStorageProvider struct {
+	ID   uint64 `gorm:"column:id;primaryKey"`
+	SpId uint32 `gorm:"column:sp_id;index:idx_sp_id"`
+
+	CreateTxHash common.Hash `gorm:"column:create_tx_hash;type:BINARY(32);not null"`
+	UpdateTxHash common.Hash `gorm:"column:update_tx_hash;type:BINARY(32);not null"`
+}
+
+func (*legacyStorageProvider) TableName() string {
+	return "storage_providers"
+}
+
+func TestMigrations_UniqueStorageProviderIndex(t *testing.T) {
+	ctx := context.Background()
+	db := newSqliteImpl(t, "migrations_unique_sp_id")
+	require.NoError(t, db.Db.AutoMigrate(&legacyStorageProvider{}))
+	for _, spID := range []uint32{1, 2, 1} {
+		require.NoError(t, db.Db.Create(&legacyStorageProvider{SpId: spID}).Error)
+	}
+
+	_, err := db.MigrateUp(ctx, 0)
+	require.NoError(t, err)
+	// the startup auto migration follows, it makes the column unique too
+	require.NoError(t, db.Db.AutoMigrate(&models.StorageProvider{}))
+
+	var ids []uint64
+	require.NoError(t, db.Db.Model(&models.StorageProvider{}).Order("id").Pluck("id", &ids).Error)
+	require.Equal(t, []uint64{2, 3}, ids)
+	require.Error(t, db.Db.Create(&models.StorageProvider{SpId: 1}).Error)
+}
+
+// legacyGlobalVirtualGroupFamily is the global virtual group family table before its primary sp index was renamed
+type legacyGlobalVirtualGroupFamily struct {
+	GlobalVirtualGroupFamilyId uint32 `gorm:"column:global_virtual_group_family_id;primaryKey"`
+	PrimarySpId                uint32 `gorm:"column:primary_sp_id;index:idx_primary_sp_id"`
+}
+
+func (*legacyGlobalVirtualGroupFamily) TableName() string {
+	return "global_virtual_group_families"
+}
+
+func TestMigrations_DropGlobalVirtualGroupFamilyIndex(t *testing.T) {
+	ctx := context.Background()
+
+	// the legacy index could only be created without the global virtual group table, its name collides
+	db := newSqliteImpl(t, "migrations_vgf_index")
+	require.NoError(t, db.Db.Migrator().DropTable(&models.GlobalVirtualGroup{}))
+	require.NoError(t, db.Db.AutoMigrate(&legacyGlobalVirtualGroupFamily{}))
+	_, err := db.MigrateUp(ctx, 0)
+	require.NoError(t, err)
+	require.False(t, db.Db.Migrator().HasIndex(&models.GlobalVirtualGroupFamily{}, "idx_primary_sp_id"))
+
+	// the index of the same name on the global virtual group table is kept
+	db = newSqliteImpl(t, "migrations_vgf_index_gvg")
+	require.NoError(t, db.Db.Exec("CREATE TABLE global_virtual_group_families (global_virtual_group_family_id integer PRIMARY KEY, primary_sp_id integer)").Error)
+	_, err = db.MigrateUp(ctx, 0)
+	require.NoError(t, err)
+	require.True(t, db.Db.Migrator().HasIndex(&models.GlobalVirtualGroup{}, "idx_primary_sp_id"))
+}
+
+// legacyPaymentLedger is the payment ledger table before its entries were located at their event
+type legacyPaymentLedger struct {
+	ID      uint64         `gorm:"column:id;primaryKey"`
+	Account common.Address `gorm:"column:account;type:BINARY(20);not null"`
+	Kind    string         `gorm:"column:kind;type:VARCHAR(20)"`
+	Height  int64          `gorm:"column:height"`
+	TxHash  common.Hash    `gorm:"column:tx_hash;type:BINARY(32);not null"`
+}
+
+func (*legacyPaymentLedger) TableName() string {
+	return "payment_ledger"
+}
+
+func TestMigrations_PaymentLedgerEventIndex(t *testing.T) {
+	ctx := context.Background()
+	db := newSqliteImpl(t, "migrations_ledger_event_index")
+	require.NoError(t, db.Db.AutoMigrate(&legacyPaymentLedger{}))
+	// a deposit and a withdrawal of the same tx, then a deposit of another one
+	for _, entry := range []*legacyPaymentLedger{
+		{Kind: models.PaymentLedgerKindDeposit, Height: 10, TxHash: common.HexToHash("0x01")},
+		{Kind: models.PaymentLedgerKindWithdraw, Height: 10, TxHash: common.HexToHash("0x01")},
+		{Kind: models.PaymentLedgerKindDeposit, Height: 11, TxHash: common.HexToHash("0x02")},
+	} {
+		require.NoError(t, db.Db.Create(entry).Error)
+	}
+
+	_, err := db.MigrateUp(ctx, 0)
+	require.NoError(t, err)
+	// the startup auto migration follows, it creates the unique index
+	require.NoError(t, db.Db.AutoMigrate(&models.PaymentLedger{}))
+
+	var indexes []int
+	require.NoError(t, db.Db.Model(&models.PaymentLedger{}).Order("id").Pluck("event_index", &indexes).Error)
+	require.Equal(t, []int{0, 1, 0}, indexes)
+
+	// the entry of an event saved again is kept once
+	position := WithEventPosition(ctx, EventPosition{Height: 11, TxHash: common.HexToHash("0x02")})
+	require.NoError(t, db.SavePaymentLedger(position, &models.PaymentLedger{Kind: models.PaymentLedgerKindDeposit, Height: 11, TxHash: common.HexToHash("0x02")}))
+	var count int64
+	require.NoError(t, db.Db.Model(&models.PaymentLedger{}).Count(&count).Error)
+	require.Equal(t, int64(3), count)
+}
+
+// legacySpStatusHistory is the sp status history table before its transitions were located at their event
+type legacySpStatusHistory struct {
+	ID     uint64      `gorm:"column:id;primaryKey"`
+	SpId   uint32      `gorm:"column:sp_id"`
+	Height int64       `gorm:"column:height"`
+	TxHash common.Hash `gorm:"column:tx_hash;type:BINARY(32);not null"`
+}
+
+func (*legacySpStatusHistory) TableName() string {
+	return "sp_status_history"
+}
+
+func TestMigrations_SpStatusHistoryEventIndex(t *testing.T) {
+	ctx := context.Background()
+	db := newSqliteImpl(t, "migrations_sp_status_event_index")
+	require.NoError(t, db.Db.AutoMigrate(&legacySpStatusHistory{}))
+	// two transitions of the sp 1 in the same tx, one of the sp 2
+	for _, spID := range []uint32{1, 1, 2} {
+		require.NoError(t, db.Db.Create(&legacySpStatusHistory{SpId: spID, Height: 10, TxHash: common.HexToHash("0x01")}).Error)
+	}
+
+	_, err := db.MigrateUp(ctx, 0)
+	require.NoError(t, err)
+	require.NoError(t, db.Db.AutoMigrate(&models.SpStatusHistory{}))
+
+	var indexes []int
+	require.NoError(t, db.Db.Model(&models.SpStatusHistory{}).Order("id").Pluck("event_index", &indexes).Error)
+	require.Equal(t, []int{0, 1, 0}, indexes)
+}
+
+// legacyBucketSettingHistory is the bucket setting history table before its changes were located at their event
+type legacyBucketSettingHistory struct {
+	ID       uint64      `gorm:"column:id;primaryKey"`
+	BucketID common.Hash `gorm:"column:bucket_id;type:BINARY(32)"`
+	Height   int64       `gorm:"column:height"`
+	TxHash   common.Hash `gorm:"column:tx_hash;type:BINARY(32);not null"`
+}
+
+func (*legacyBucketSettingHistory) TableName() string {
+	return "bucket_setting_history"
+}
+
+func TestMigrations_BucketSettingHistoryEventIndex(t *testing.T) {
+	ctx := context.Background()
+	db := newSqliteImpl(t, "migrations_bucket_setting_event_index")
+	require.NoError(t, db.Db.AutoMigrate(&legacyBucketSettingHistory{}))
+	// two changes of the same bucket in a tx, one of a bucket not indexed yet
+	for _, bucketID := range []common.Hash{common.HexToHash("0x0a"), common.HexToHash("0x0a"), {}} {
+		require.NoError(t, db.Db.Create(&legacyBucketSettingHistory{BucketID: bucketID, Height: 10, TxHash: common.HexToHash("0x01")}).Error)
+	}
+
+	_, err := db.MigrateUp(ctx, 0)
+	require.NoError(t, err)
+	require.NoError(t, db.Db.AutoMigrate(&models.BucketSettingHistory{}))
+
+	var indexes []int
+	require.NoError(t, db.Db.Model(&models.BucketSettingHistory{}).Order("id").Pluck("event_index", &indexes).Error)
+	require.Equal(t, []int{0, 1, 0}, indexes)
+}
+
+// legacyVgSwap is the vg swap table before the swaps were keyed on the event starting them
+type legacyVgSwap struct {
+	ID                         uint64      `gorm:"column:id;primaryKey"`
+	GlobalVirtualGroupFamilyId uint32      `gorm:"column:global_virtual_group_family_id"`
+	GlobalVirtualGroupId       uint32      `gorm:"column:global_virtual_group_id"`
+	CreateAt                   int64       `gorm:"column:create_at"`
+	CreateTxHash               common.Hash `gorm:"column:create_tx_hash;type:BINARY(32);not null"`
+	UpdateTxHash               common.Hash `gorm:"column:update_tx_hash;type:BINARY(32);not null"`
+}
+
+func (*legacyVgSwap) TableName() string {
+	return "vg_swaps"
+}
+
+func TestMigrations_VgSwapCreateEventIndex(t *testing.T) {
+	ctx := context.Background()
+	db := newSqliteImpl(t, "migrations_vg_swap_event_index")
+	require.NoError(t, db.Db.AutoMigrate(&legacyVgSwap{}))
+	// a swap started twice on the same gvg in a tx, one of another gvg
+	for _, gvgId := range []uint32{200, 200, 201} {
+		require.NoError(t, db.Db.Create(&legacyVgSwap{GlobalVirtualGroupId: gvgId, CreateAt: 10, CreateTxHash: common.HexToHash("0x01")}).Error)
+	}
+
+	_, err := db.MigrateUp(ctx, 0)
+	require.NoError(t, err)
+	require.NoError(t, db.Db.AutoMigrate(&models.VgSwap{}))
+
+	var indexes []int
+	require.NoError(t, db.Db.Model(&models.VgSwap{}).Order("id").Pluck("create_event_index", &indexes).Error)
+	require.Equal(t, []int{0, 1, 0}, indexes)
+}
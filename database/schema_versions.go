@@ -0,0 +1,246 @@
+package database
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/forbole/juno/v4/models"
+)
+
+func init() {
+	RegisterMigrations(
+		Migration{
+			Version:     1,
+			Description: "drop the statements index replaced by idx_statement_policy_id",
+			Up: MigrationSteps{
+				AnyDialect: func(tx *gorm.DB) error {
+					if !tx.Migrator().HasIndex(&models.Statements{}, "idx_policy_id") {
+						return nil
+					}
+					return tx.Migrator().DropIndex(&models.Statements{}, "idx_policy_id")
+				},
+			},
+			Down: MigrationSteps{
+				// the index could only be created on MySQL, elsewhere its name collided with the one of the permission table
+				"mysql":    SQLStep("CREATE INDEX idx_policy_id ON statements (policy_id)"),
+				AnyDialect: NoopStep,
+			},
+		},
+		Migration{
+			Version:     2,
+			Description: "migrate the validator tables, which are not auto migrated",
+			Up: MigrationSteps{
+				AnyDialect: func(tx *gorm.DB) error {
+					tables := []schema.Tabler{
+						&models.Validator{},
+						&models.ValidatorInfo{},
+						&models.ValidatorDescription{},
+						&models.ValidatorCommission{},
+						&models.ValidatorVotingPower{},
+						&models.ValidatorStatus{},
+						&models.ValidatorSigningInfo{},
+					}
+					for _, t := range tables {
+						// the tables only exist when the validator module is enabled
+						if !tx.Migrator().HasTable(t.TableName()) {
+							continue
+						}
+						if err := tx.Migrator().AutoMigrate(t); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			},
+			Down: MigrationSteps{
+				// auto migration only adds columns and indexes, which the previous schema ignores
+				AnyDialect: NoopStep,
+			},
+		},
+		Migration{
+			Version:     3,
+			Description: "seed the sync ranges from the stored blocks",
+			Up: MigrationSteps{
+				AnyDialect: func(tx *gorm.DB) error {
+					if !tx.Migrator().HasTable(&models.Block{}) {
+						return nil
+					}
+					if err := tx.Migrator().AutoMigrate(&models.SyncRange{}); err != nil {
+						return err
+					}
+					// the ranges recorded since a previous run of the migration are kept, the blocks may be pruned
+					var count int64
+					if err := tx.Model(&models.SyncRange{}).Count(&count).Error; err != nil {
+						return err
+					}
+					if count > 0 {
+						return nil
+					}
+					// each island of consecutive heights has a constant difference with its row number
+					return tx.Exec(`INSERT INTO sync_ranges (start_height, end_height)
+SELECT MIN(height), MAX(height) FROM (
+	SELECT height, height - ROW_NUMBER() OVER (ORDER BY height) AS island FROM blocks
+) islands GROUP BY island`).Error
+				},
+			},
+			Down: MigrationSteps{
+				// the sync ranges hold the progress recorded since the migration too, which the blocks, pruned
+				// by the retention, cannot restore: they are kept
+				AnyDialect: NoopStep,
+			},
+		},
+		Migration{
+			Version:     4,
+			Description: "make the storage provider sp_id index unique",
+			Up: MigrationSteps{
+				AnyDialect: func(tx *gorm.DB) error {
+					if !tx.Migrator().HasTable(&models.StorageProvider{}) {
+						return nil
+					}
+					// the last written row of each storage provider is kept, the derived table lets MySQL
+					// select from the table it deletes from
+					err := tx.Exec(`DELETE FROM storage_providers WHERE id NOT IN (
+	SELECT id FROM (SELECT MAX(id) AS id FROM storage_providers GROUP BY sp_id) latest
+)`).Error
+					if err != nil {
+						return err
+					}
+					// the auto migration leaves the non unique index in place since it has the same name
+					if tx.Migrator().HasIndex(&models.StorageProvider{}, "idx_sp_id") {
+						if err := tx.Migrator().DropIndex(&models.StorageProvider{}, "idx_sp_id"); err != nil {
+							return err
+						}
+					}
+					return tx.Migrator().CreateIndex(&models.StorageProvider{}, "idx_sp_id")
+				},
+			},
+			Down: MigrationSteps{
+				// the previous schema ignores the uniqueness of the index, the removed duplicates are not restored
+				AnyDialect: NoopStep,
+			},
+		},
+		Migration{
+			Version:     5,
+			Description: "drop the global virtual group family index replaced by idx_vgf_primary_sp_id",
+			Up: MigrationSteps{
+				AnyDialect: func(tx *gorm.DB) error {
+					// the global virtual group table has an index of the same name, only the one of the family table is dropped
+					if !tx.Migrator().HasIndex(&models.GlobalVirtualGroupFamily{}, "idx_primary_sp_id") {
+						return nil
+					}
+					return tx.Migrator().DropIndex(&models.GlobalVirtualGroupFamily{}, "idx_primary_sp_id")
+				},
+			},
+			Down: MigrationSteps{
+				// the index could only be created on MySQL, elsewhere its name collided with the one of the global virtual group table
+				"mysql":    SQLStep("CREATE INDEX idx_primary_sp_id ON global_virtual_group_families (primary_sp_id)"),
+				AnyDialect: NoopStep,
+			},
+		},
+		Migration{
+			Version:     6,
+			Description: "locate the payment ledger entries at their event",
+			Up: MigrationSteps{
+				AnyDialect: func(tx *gorm.DB) error {
+					return addEventIndex(tx, &models.PaymentLedger{}, "event_index", "height", "tx_hash")
+				},
+			},
+			Down: MigrationSteps{
+				// the previous schema records the entries of a tx without their event index
+				AnyDialect: func(tx *gorm.DB) error {
+					return dropIndexIfExists(tx, &models.PaymentLedger{}, "idx_ledger_position")
+				},
+			},
+		},
+		Migration{
+			Version:     7,
+			Description: "locate the sp status transitions at their event",
+			Up: MigrationSteps{
+				AnyDialect: func(tx *gorm.DB) error {
+					return addEventIndex(tx, &models.SpStatusHistory{}, "event_index", "sp_id", "height", "tx_hash")
+				},
+			},
+			Down: MigrationSteps{
+				// the previous schema records the transitions of a tx without their event index
+				AnyDialect: func(tx *gorm.DB) error {
+					return dropIndexIfExists(tx, &models.SpStatusHistory{}, "idx_sp_status_position")
+				},
+			},
+		},
+		Migration{
+			Version:     8,
+			Description: "locate the bucket setting changes at their event",
+			Up: MigrationSteps{
+				AnyDialect: func(tx *gorm.DB) error {
+					return addEventIndex(tx, &models.BucketSettingHistory{}, "event_index", "bucket_id", "height", "tx_hash")
+				},
+			},
+			Down: MigrationSteps{
+				// the previous schema records the changes of a tx without their event index
+				AnyDialect: func(tx *gorm.DB) error {
+					return dropIndexIfExists(tx, &models.BucketSettingHistory{}, "idx_setting_position")
+				},
+			},
+		},
+		Migration{
+			Version:     9,
+			Description: "key the vg swaps on the event starting them",
+			Up: MigrationSteps{
+				AnyDialect: func(tx *gorm.DB) error {
+					return addEventIndex(tx, &models.VgSwap{}, "create_event_index", "create_at", "create_tx_hash",
+						"global_virtual_group_family_id", "global_virtual_group_id")
+				},
+			},
+			Down: MigrationSteps{
+				// the previous schema records the swaps without the event starting them
+				AnyDialect: func(tx *gorm.DB) error {
+					return dropIndexIfExists(tx, &models.VgSwap{}, "idx_vg_swap_position")
+				},
+			},
+		},
+	)
+}
+
+// addEventIndex adds the event index column to the table of model, when it exists without it. The rows recorded
+// before, sharing the values of the columns of key, are numbered in id order, so that the unique index of key and
+// the event index, created by the auto migration which follows, does not fail on them.
+func addEventIndex(tx *gorm.DB, model schema.Tabler, column string, key ...string) error {
+	if !tx.Migrator().HasTable(model) || tx.Migrator().HasColumn(model, column) {
+		return nil
+	}
+	if err := tx.Migrator().AddColumn(model, column); err != nil {
+		return err
+	}
+	err := tx.Table(model.TableName()).Session(&gorm.Session{AllowGlobalUpdate: true}).Update(column, 0).Error
+	if err != nil {
+		return err
+	}
+
+	var groups []map[string]interface{}
+	err = tx.Table(model.TableName()).Select(key).Group(strings.Join(key, ", ")).Having("COUNT(*) > 1").Find(&groups).Error
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		var ids []uint64
+		if err := tx.Table(model.TableName()).Where(group).Order("id").Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		for index, id := range ids {
+			if err := tx.Table(model.TableName()).Where("id = ?", id).Update(column, index).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dropIndexIfExists drops the index of the table of model, if any
+func dropIndexIfExists(tx *gorm.DB, model schema.Tabler, name string) error {
+	if !tx.Migrator().HasIndex(model, name) {
+		return nil
+	}
+	return tx.Migrator().DropIndex(model, name)
+}
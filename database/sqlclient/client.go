@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
 	"gorm.io/driver/mysql"
@@ -15,34 +16,63 @@ import (
 	"github.com/forbole/juno/v4/log"
 )
 
+// New opens the database of the given config. When the DSN is read from a secrets provider, it is fetched
+// again periodically and on authentication failures, and the connection pool is rebuilt when it changed.
 func New(cfg *databaseconfig.Config) (*gorm.DB, error) {
+	var pool *rotatingPool
 	if cfg.Secrets != nil {
-		secret, err := databaseconfig.GetString(cfg.Secrets)
+		provider, err := databaseconfig.NewSecretsProvider(cfg.Secrets)
 		if err != nil {
-			log.Errorf("invalid secrets %+v err:%v", cfg.Secrets, err)
+			log.Errorw("invalid secrets", "provider", cfg.Secrets.Provider, "secret_id", cfg.Secrets.SecretId, "err", err)
+			return nil, err
+		}
+		pool, err = newRotatingPool(*cfg, provider)
+		if err != nil {
+			log.Errorw("failed to open database", "err", err)
 			return nil, err
 		}
-		cfg.DSN = secret
 	}
 
 	var db *gorm.DB
 	var err error
 	switch cfg.Type {
 	case databaseconfig.MySQL:
-		db, err = gorm.Open(mysql.Open(cfg.DSN),
+		dialector := mysql.Open(cfg.DSN)
+		if pool != nil {
+			dialector = mysql.New(mysql.Config{Conn: pool})
+		}
+		db, err = gorm.Open(dialector,
 			&gorm.Config{
 				Logger:                                   &loggerAdaptor{slowThreshold: time.Duration(cfg.SlowThreshold)},
 				DisableForeignKeyConstraintWhenMigrating: true,
 			},
 		)
 	case databaseconfig.PostgreSQL:
-		db, err = gorm.Open(postgres.Open(cfg.DSN),
+		dialector := postgres.Open(cfg.DSN)
+		if pool != nil {
+			dialector = postgres.New(postgres.Config{Conn: pool})
+		}
+		db, err = gorm.Open(dialector,
 			&gorm.Config{
 				Logger:                                   &loggerAdaptor{slowThreshold: time.Duration(cfg.SlowThreshold)},
 				DisableForeignKeyConstraintWhenMigrating: true,
 				SkipDefaultTransaction:                   true,
 			},
 		)
+	case databaseconfig.SQLite:
+		dialector := openSqlite(cfg.DSN)
+		if pool != nil {
+			dialector.Conn = pool
+		}
+		db, err = gorm.Open(dialector,
+			&gorm.Config{
+				Logger:                                   &loggerAdaptor{slowThreshold: time.Duration(cfg.SlowThreshold)},
+				DisableForeignKeyConstraintWhenMigrating: true,
+				SkipDefaultTransaction:                   true,
+			},
+		)
+	default:
+		err = fmt.Errorf("unsupported database type %q", cfg.Type)
 	}
 
 	if err != nil {
@@ -50,12 +80,36 @@ func New(cfg *databaseconfig.Config) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	if pool != nil {
+		go pool.watch(time.Duration(cfg.Secrets.RefreshInterval))
+		return db, nil
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		log.Errorw("failed to get database", "err", err)
 		return nil, err
 	}
+	setPoolLimits(cfg, sqlDB)
+
+	return db, nil
+}
 
+// Close closes the connections of a database opened with New, and stops the rotation of its credentials if any
+func Close(db *gorm.DB) error {
+	if pool, ok := db.ConnPool.(*rotatingPool); ok {
+		return pool.Close()
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// setPoolLimits applies the connection limits of cfg to the pool, with their defaults when not set
+func setPoolLimits(cfg *databaseconfig.Config, sqlDB *sql.DB) {
 	if cfg.MaxOpenConnections <= 0 {
 		cfg.MaxOpenConnections = 256
 	}
@@ -73,8 +127,6 @@ func New(cfg *databaseconfig.Config) (*gorm.DB, error) {
 	sqlDB.SetMaxOpenConns(cfg.MaxOpenConnections)
 	sqlDB.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTime))
 	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
-
-	return db, nil
 }
 
 type loggerAdaptor struct {
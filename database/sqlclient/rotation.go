@@ -0,0 +1,249 @@
+package sqlclient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	databaseconfig "github.com/forbole/juno/v4/database/config"
+	"github.com/forbole/juno/v4/log"
+)
+
+// minRefreshInterval is the minimum interval between two fetches of the secret triggered by authentication failures
+const minRefreshInterval = 5 * time.Second
+
+// secretsTimeout is the timeout of a fetch of the secret
+const secretsTimeout = 30 * time.Second
+
+var (
+	_ gorm.ConnPool       = &rotatingPool{}
+	_ gorm.TxBeginner     = &rotatingPool{}
+	_ gorm.GetDBConnector = &rotatingPool{}
+)
+
+// rotatingPool is the connection pool of a database whose DSN is read from a secrets provider.
+// The secret is fetched again periodically, and when the database rejects the credentials. When it changed,
+// a new pool is opened with it and replaces the current one, whose connections are closed once released.
+// Statements and transactions started before the rotation complete on the old pool.
+// Close stops the refreshes and closes the current pool.
+type rotatingPool struct {
+	cfg      databaseconfig.Config
+	provider databaseconfig.SecretsProvider
+
+	mu     sync.RWMutex
+	db     *sql.DB
+	dsn    string
+	closed bool
+
+	refreshes   chan struct{}
+	done        chan struct{}
+	lastRefresh time.Time
+}
+
+// newRotatingPool fetches the secret and opens the first pool with it
+func newRotatingPool(cfg databaseconfig.Config, provider databaseconfig.SecretsProvider) (*rotatingPool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+
+	dsn, err := provider.GetSecret(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the database secret: %s", err)
+	}
+
+	p := &rotatingPool{
+		cfg:         cfg,
+		provider:    provider,
+		dsn:         dsn,
+		refreshes:   make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		lastRefresh: time.Now(),
+	}
+	p.db, err = openPool(&p.cfg, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// openPool opens a pool of connections to the database of dsn, of the type of cfg
+func openPool(cfg *databaseconfig.Config, dsn string) (*sql.DB, error) {
+	var driverName string
+	switch cfg.Type {
+	case databaseconfig.MySQL:
+		driverName = "mysql"
+	case databaseconfig.PostgreSQL:
+		driverName = "pgx"
+	case databaseconfig.SQLite:
+		driverName, dsn = "sqlite3", sqliteDSN(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported database type %q", cfg.Type)
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	setPoolLimits(cfg, db)
+	return db, nil
+}
+
+func (p *rotatingPool) current() *sql.DB {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.db
+}
+
+// check requests a refresh of the secret when err tells that the database rejected the credentials
+func (p *rotatingPool) check(err error) {
+	if err == nil || !isAuthError(err) {
+		return
+	}
+	select {
+	case p.refreshes <- struct{}{}:
+	default:
+	}
+}
+
+// isAuthError tells whether err is an authentication failure reported by the database
+func isAuthError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// ER_ACCESS_DENIED_ERROR
+		return mysqlErr.Number == 1045
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// invalid_authorization_specification and invalid_password
+		return pgErr.Code == "28000" || pgErr.Code == "28P01"
+	}
+	return false
+}
+
+// watch refreshes the secret every interval, if not 0, and when an authentication failure is reported,
+// until the pool is closed
+func (p *rotatingPool) watch(interval time.Duration) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-tick:
+		case <-p.refreshes:
+			if time.Since(p.lastRefresh) < minRefreshInterval {
+				continue
+			}
+			log.Warnw("database rejected the credentials, fetching the secret again")
+		}
+
+		if err := p.refresh(); err != nil {
+			log.Errorw("failed to rotate the database credentials", "err", err)
+		}
+	}
+}
+
+// refresh fetches the secret, and replaces the pool when it changed. The current pool is kept
+// if the new one cannot connect.
+func (p *rotatingPool) refresh() error {
+	p.lastRefresh = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+
+	dsn, err := p.provider.GetSecret(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the database secret: %s", err)
+	}
+
+	p.mu.RLock()
+	unchanged := dsn == p.dsn
+	p.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	db, err := openPool(&p.cfg, dsn)
+	if err != nil {
+		return err
+	}
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to connect with the new credentials: %s", err)
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return db.Close()
+	}
+	old := p.db
+	p.db, p.dsn = db, dsn
+	p.mu.Unlock()
+
+	log.Infow("rotated the database credentials")
+	return old.Close()
+}
+
+// Close stops the refreshes of the secret and closes the current pool
+func (p *rotatingPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	return p.db.Close()
+}
+
+// PrepareContext implements gorm.ConnPool
+func (p *rotatingPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	stmt, err := p.current().PrepareContext(ctx, query)
+	p.check(err)
+	return stmt, err
+}
+
+// ExecContext implements gorm.ConnPool
+func (p *rotatingPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := p.current().ExecContext(ctx, query, args...)
+	p.check(err)
+	return result, err
+}
+
+// QueryContext implements gorm.ConnPool
+func (p *rotatingPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := p.current().QueryContext(ctx, query, args...)
+	p.check(err)
+	return rows, err
+}
+
+// QueryRowContext implements gorm.ConnPool
+func (p *rotatingPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	row := p.current().QueryRowContext(ctx, query, args...)
+	p.check(row.Err())
+	return row
+}
+
+// BeginTx implements gorm.TxBeginner
+func (p *rotatingPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	tx, err := p.current().BeginTx(ctx, opts)
+	p.check(err)
+	return tx, err
+}
+
+// GetDBConn implements gorm.GetDBConnector
+func (p *rotatingPool) GetDBConn() (*sql.DB, error) {
+	return p.current(), nil
+}
@@ -0,0 +1,99 @@
+package sqlclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	databaseconfig "github.com/forbole/juno/v4/database/config"
+)
+
+func TestRotatingPool_Refresh(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "dsn")
+	require.NoError(t, os.WriteFile(secret, []byte("file:"+filepath.Join(dir, "first.db")), 0o600))
+
+	db, err := New(&databaseconfig.Config{
+		Type:    databaseconfig.SQLite,
+		Secrets: &databaseconfig.Params{Provider: databaseconfig.FileSecrets, File: secret},
+	})
+	require.NoError(t, err)
+	defer Close(db)
+	pool, ok := db.ConnPool.(*rotatingPool)
+	require.True(t, ok)
+
+	require.NoError(t, db.Exec("CREATE TABLE rotation (name TEXT)").Error)
+	require.NoError(t, db.Exec("INSERT INTO rotation VALUES ('first')").Error)
+
+	// an unchanged secret keeps the pool
+	first := pool.current()
+	require.NoError(t, pool.refresh())
+	require.Same(t, first, pool.current())
+
+	// a new secret replaces the pool, statements then run against the new database
+	require.NoError(t, os.WriteFile(secret, []byte("file:"+filepath.Join(dir, "second.db")), 0o600))
+	require.NoError(t, pool.refresh())
+	require.NotSame(t, first, pool.current())
+	require.False(t, db.Migrator().HasTable("rotation"))
+
+	require.NoError(t, db.Exec("CREATE TABLE rotation (name TEXT)").Error)
+	require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		return tx.Exec("INSERT INTO rotation VALUES ('second')").Error
+	}))
+	var names []string
+	require.NoError(t, db.Raw("SELECT name FROM rotation").Scan(&names).Error)
+	require.Equal(t, []string{"second"}, names)
+
+	// a secret which cannot be read keeps the current pool
+	require.NoError(t, os.Remove(secret))
+	require.Error(t, pool.refresh())
+	require.True(t, db.Migrator().HasTable("rotation"))
+}
+
+func TestRotatingPool_Close(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "dsn")
+	require.NoError(t, os.WriteFile(secret, []byte("file:"+filepath.Join(dir, "close.db")), 0o600))
+
+	db, err := New(&databaseconfig.Config{
+		Type:    databaseconfig.SQLite,
+		Secrets: &databaseconfig.Params{Provider: databaseconfig.FileSecrets, File: secret},
+	})
+	require.NoError(t, err)
+	pool, ok := db.ConnPool.(*rotatingPool)
+	require.True(t, ok)
+
+	stopped := make(chan struct{})
+	go func() {
+		pool.watch(time.Millisecond)
+		close(stopped)
+	}()
+
+	require.NoError(t, Close(db))
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the refreshes did not stop once the pool was closed")
+	}
+	require.Error(t, db.Exec("SELECT 1").Error)
+
+	// a refresh running when the pool is closed does not open a new pool
+	require.NoError(t, os.WriteFile(secret, []byte("file:"+filepath.Join(dir, "other.db")), 0o600))
+	require.NoError(t, pool.refresh())
+	require.Error(t, db.Exec("SELECT 1").Error)
+	require.NoError(t, Close(db))
+}
+
+func TestIsAuthError(t *testing.T) {
+	require.True(t, isAuthError(&mysql.MySQLError{Number: 1045}))
+	require.False(t, isAuthError(&mysql.MySQLError{Number: 1062}))
+	require.True(t, isAuthError(&pgconn.PgError{Code: "28P01"}))
+	require.False(t, isAuthError(&pgconn.PgError{Code: "23505"}))
+	require.False(t, isAuthError(os.ErrNotExist))
+}
@@ -0,0 +1,128 @@
+package sqlclient
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// sqliteDefaultParams are added to the sqlite DSN when not set by the user. WAL lets readers run
+// while a block is written, the busy timeout makes concurrent writers wait for each other instead
+// of failing, and immediate transactions avoid deadlocks when two transactions upgrade to writes.
+var sqliteDefaultParams = map[string]string{
+	"_journal_mode": "WAL",
+	"_busy_timeout": "10000",
+	"_txlock":       "immediate",
+}
+
+var (
+	sqliteBlobType    = regexp.MustCompile(`^(?i)(var)?binary(\(\d+\))?$`)
+	sqliteTextType    = regexp.MustCompile(`^(?i)((var)?char(\(\d+\))?|(medium|long|tiny)?text|json|decimal(\(\d+,\s*\d+\))?)$`)
+	sqliteIntegerType = regexp.MustCompile(`^(?i)(big|small|tiny)?int(\(\d+\))?$`)
+)
+
+// sqliteDSN returns the given file DSN with the default connection parameters
+func sqliteDSN(dsn string) string {
+	path, rawQuery, _ := strings.Cut(dsn, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return dsn
+	}
+
+	for key, value := range sqliteDefaultParams {
+		if query.Get(key) == "" {
+			query.Set(key, value)
+		}
+	}
+	return path + "?" + query.Encode()
+}
+
+// sqliteDialector adapts the sqlite driver to the schema of the models, which is written for MySQL:
+//   - column types are mapped to their sqlite storage class, so that e.g. DECIMAL(65, 0) keeps all its digits
+//   - index names are scoped to their table, as sqlite requires them to be unique across the database
+type sqliteDialector struct {
+	*sqlite.Dialector
+}
+
+func openSqlite(dsn string) *sqliteDialector {
+	return &sqliteDialector{Dialector: sqlite.Open(sqliteDSN(dsn)).(*sqlite.Dialector)}
+}
+
+func (d sqliteDialector) DataTypeOf(field *schema.Field) string {
+	dataType := strings.TrimSpace(d.Dialector.DataTypeOf(field))
+	switch {
+	case sqliteBlobType.MatchString(dataType):
+		return "blob"
+	case sqliteTextType.MatchString(dataType):
+		return "text"
+	case sqliteIntegerType.MatchString(dataType):
+		return "integer"
+	default:
+		return dataType
+	}
+}
+
+func (d sqliteDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	m := d.Dialector.Migrator(db).(sqlite.Migrator)
+	m.Dialector = d
+	return sqliteMigrator{Migrator: m}
+}
+
+type sqliteMigrator struct {
+	sqlite.Migrator
+}
+
+// indexName returns the name of the index in the database, prefixed with its table
+func (m sqliteMigrator) indexName(stmt *gorm.Statement, name string) string {
+	if idx := stmt.Schema.LookIndex(name); idx != nil {
+		name = idx.Name
+	}
+	if strings.HasPrefix(name, stmt.Table+"_") {
+		return name
+	}
+	return fmt.Sprintf("%s_%s", stmt.Table, name)
+}
+
+func (m sqliteMigrator) CreateIndex(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		idx := stmt.Schema.LookIndex(name)
+		if idx == nil {
+			return fmt.Errorf("failed to create index with name %v", name)
+		}
+
+		createIndexSQL := "CREATE "
+		if idx.Class != "" {
+			createIndexSQL += idx.Class + " "
+		}
+		createIndexSQL += "INDEX ? ON ??"
+		if idx.Where != "" {
+			createIndexSQL += " WHERE " + idx.Where
+		}
+
+		return m.DB.Exec(createIndexSQL,
+			clause.Column{Name: m.indexName(stmt, name)}, clause.Table{Name: stmt.Table}, m.BuildIndexOptions(idx.Fields, stmt),
+		).Error
+	})
+}
+
+func (m sqliteMigrator) HasIndex(value interface{}, name string) bool {
+	var count int
+	_ = m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		return m.DB.Raw(
+			"SELECT count(*) FROM sqlite_master WHERE type = ? AND tbl_name = ? AND name = ?", "index", stmt.Table, m.indexName(stmt, name),
+		).Row().Scan(&count)
+	})
+	return count > 0
+}
+
+func (m sqliteMigrator) DropIndex(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		return m.DB.Exec("DROP INDEX ?", clause.Column{Name: m.indexName(stmt, name)}).Error
+	})
+}
@@ -0,0 +1,105 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"cosmossdk.io/simapp/params"
+	"github.com/cosmos/cosmos-sdk/types/module/testutil"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm/schema"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/database/builder"
+	databaseconfig "github.com/forbole/juno/v4/database/config"
+	"github.com/forbole/juno/v4/database/sqlite"
+	"github.com/forbole/juno/v4/models"
+)
+
+func TestDatabaseTestSuite(t *testing.T) {
+	suite.Run(t, new(DbTestSuite))
+}
+
+type DbTestSuite struct {
+	suite.Suite
+
+	database *sqlite.Database
+	tables   []schema.Tabler
+}
+
+func (suite *DbTestSuite) SetupTest() {
+	// Create the codec
+	codec := testutil.MakeTestEncodingConfig()
+	paramsCodec := params.EncodingConfig{
+		InterfaceRegistry: codec.InterfaceRegistry,
+		Codec:             codec.Codec,
+		TxConfig:          codec.TxConfig,
+		Amino:             codec.Amino,
+	}
+
+	// Build the database on a new file
+	dbCfg := databaseconfig.NewDatabaseConfig(
+		"file:"+filepath.Join(suite.T().TempDir(), "juno.db"),
+		-1,
+		-1,
+		100000,
+		100,
+	)
+	dbCfg.Type = databaseconfig.SQLite
+	db, err := builder.Builder(database.NewContext(dbCfg, &paramsCodec))
+	suite.Require().NoError(err)
+
+	sqliteDb, ok := (db).(*sqlite.Database)
+	suite.Require().True(ok)
+
+	// These tables share index names, which must be unique in a sqlite database
+	suite.tables = []schema.Tabler{&models.Block{}, &models.Tx{}, &models.Bucket{}, &models.Object{}, &models.Group{}, &models.SyncRange{}}
+	suite.Require().NoError(sqliteDb.PrepareTables(context.Background(), suite.tables))
+
+	suite.database = sqliteDb
+}
+
+func (suite *DbTestSuite) TestJournalMode() {
+	var mode string
+	suite.Require().NoError(suite.database.Db.Raw("PRAGMA journal_mode").Scan(&mode).Error)
+	suite.Require().Equal("wal", mode)
+}
+
+func (suite *DbTestSuite) TestAutoMigrate_Idempotent() {
+	suite.Require().NoError(suite.database.AutoMigrate(context.Background(), suite.tables))
+	suite.Require().True(suite.database.Db.Migrator().HasIndex(&models.Tx{}, "idx_hash"))
+	suite.Require().True(suite.database.Db.Migrator().HasIndex(&models.Block{}, "idx_hash"))
+}
+
+func (suite *DbTestSuite) TestGetMissingHeights() {
+	ctx := context.Background()
+	for _, height := range []uint64{2, 3, 5} {
+		suite.Require().NoError(suite.database.SaveProcessedHeight(ctx, height))
+	}
+
+	suite.Require().Equal([]uint64{1, 4, 6}, suite.database.GetMissingHeights(ctx, 1, 6))
+	suite.Require().Nil(suite.database.GetMissingHeights(ctx, 2, 3))
+}
+
+func (suite *DbTestSuite) TestColumnTypes() {
+	ctx := context.Background()
+	size, err := decimal.NewFromString("123456789012345678901234567890")
+	suite.Require().NoError(err)
+
+	err = suite.database.SaveBucket(ctx, &models.Bucket{
+		BucketID:    common.HexToHash("0x01"),
+		BucketName:  "sqlite-bucket",
+		Owner:       common.HexToAddress("0x02"),
+		StorageSize: size,
+		ChargeSize:  decimal.Zero,
+	})
+	suite.Require().NoError(err)
+
+	bucket, err := suite.database.GetBucket(ctx, common.HexToHash("0x01"))
+	suite.Require().NoError(err)
+	suite.Require().Equal(common.HexToAddress("0x02"), bucket.Owner)
+	suite.Require().True(size.Equal(bucket.StorageSize), bucket.StorageSize.String())
+}
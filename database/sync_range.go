@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+
+	"github.com/forbole/juno/v4/log"
+	"github.com/forbole/juno/v4/models"
+)
+
+// HeightRange is an interval of heights, both ends included
+type HeightRange struct {
+	Start uint64
+	End   uint64
+}
+
+// SaveProcessedHeight records height as processed, merging it with the adjacent and overlapping sync ranges.
+// The workers save their heights concurrently without a lock, which can leave adjacent or overlapping ranges
+// apart: the window of the merge is widened until no other range touches it, so that they are merged along
+// by the following saves.
+func (db *Impl) SaveProcessedHeight(ctx context.Context, height uint64) error {
+	merged := &models.SyncRange{StartHeight: height, EndHeight: height}
+	var found []*models.SyncRange
+	seen := make(map[uint64]bool)
+	for widened := true; widened; {
+		var ranges []*models.SyncRange
+		err := db.session(ctx).
+			Where("start_height <= ? AND end_height + 1 >= ?", merged.EndHeight+1, merged.StartHeight).
+			Find(&ranges).Error
+		if err != nil {
+			return err
+		}
+
+		widened = false
+		for _, r := range ranges {
+			if seen[r.ID] {
+				continue
+			}
+			seen[r.ID] = true
+			found = append(found, r)
+			if r.StartHeight < merged.StartHeight {
+				merged.StartHeight = r.StartHeight
+				widened = true
+			}
+			if r.EndHeight > merged.EndHeight {
+				merged.EndHeight = r.EndHeight
+				widened = true
+			}
+		}
+	}
+
+	// already processed, and its range has nothing to merge
+	if len(found) == 1 && found[0].StartHeight == merged.StartHeight && found[0].EndHeight == merged.EndHeight {
+		return nil
+	}
+
+	ids := make([]uint64, 0, len(found))
+	for _, r := range found {
+		ids = append(ids, r.ID)
+	}
+
+	// the merged range is inserted before the ranges it replaces are deleted, so that an interrupted
+	// merge leaves overlapping ranges, which GetSyncGaps handles, instead of losing processed heights
+	if err := db.session(ctx).Create(merged).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return db.session(ctx).Where("id IN ?", ids).Delete(&models.SyncRange{}).Error
+}
+
+// GetSyncGaps returns the ranges of heights between startHeight and endHeight that are not processed yet
+func (db *Impl) GetSyncGaps(ctx context.Context, startHeight, endHeight uint64) ([]HeightRange, error) {
+	if startHeight > endHeight {
+		return nil, nil
+	}
+
+	var ranges []*models.SyncRange
+	err := db.session(ctx).
+		Where("end_height >= ? AND start_height <= ?", startHeight, endHeight).
+		Order("start_height ASC").
+		Find(&ranges).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var gaps []HeightRange
+	next := startHeight
+	for _, r := range ranges {
+		if r.StartHeight > next {
+			gaps = append(gaps, HeightRange{Start: next, End: r.StartHeight - 1})
+		}
+		if r.EndHeight >= endHeight {
+			return gaps, nil
+		}
+		if r.EndHeight+1 > next {
+			next = r.EndHeight + 1
+		}
+	}
+	return append(gaps, HeightRange{Start: next, End: endHeight}), nil
+}
+
+// GetMissingHeights implements database.Database
+func (db *Impl) GetMissingHeights(ctx context.Context, startHeight, endHeight uint64) []uint64 {
+	gaps, err := db.GetSyncGaps(ctx, startHeight, endHeight)
+	if err != nil {
+		log.Errorw("failed to get sync gaps", "err", err)
+		return nil
+	}
+
+	var result []uint64
+	for _, gap := range gaps {
+		for height := gap.Start; height <= gap.End; height++ {
+			result = append(result, height)
+		}
+	}
+	return result
+}
+
+// GetSyncedHeight implements database.Repository. The sync ranges are walked from the lowest one while they
+// touch each other, since an interrupted merge can leave overlapping or adjacent ranges apart.
+func (db *Impl) GetSyncedHeight(ctx context.Context) (uint64, error) {
+	var ranges []*models.SyncRange
+	err := db.session(ctx).Order("start_height ASC").Find(&ranges).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(ranges) == 0 {
+		return 0, nil
+	}
+
+	synced := ranges[0].EndHeight
+	for _, r := range ranges[1:] {
+		if r.StartHeight > synced+1 {
+			break
+		}
+		if r.EndHeight > synced {
+			synced = r.EndHeight
+		}
+	}
+	return synced, nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/forbole/juno/v4/models"
+)
+
+func syncRanges(t *testing.T, db *Impl) []HeightRange {
+	var ranges []*models.SyncRange
+	require.NoError(t, db.Db.Order("start_height ASC").Find(&ranges).Error)
+
+	result := make([]HeightRange, len(ranges))
+	for i, r := range ranges {
+		result[i] = HeightRange{Start: r.StartHeight, End: r.EndHeight}
+	}
+	return result
+}
+
+func TestSaveProcessedHeight_MergesRanges(t *testing.T) {
+	ctx := context.Background()
+	db := newSqliteImpl(t, "sync_ranges_merge")
+	require.NoError(t, db.Db.AutoMigrate(&models.SyncRange{}))
+
+	for _, height := range []uint64{5, 3, 4, 9, 10, 4, 1} {
+		require.NoError(t, db.SaveProcessedHeight(ctx, height))
+	}
+	require.Equal(t, []HeightRange{{1, 1}, {3, 5}, {9, 10}}, syncRanges(t, db))
+
+	// filling a hole merges both sides
+	require.NoError(t, db.SaveProcessedHeight(ctx, 2))
+	require.Equal(t, []HeightRange{{1, 5}, {9, 10}}, syncRanges(t, db))
+
+	gaps, err := db.GetSyncGaps(ctx, 1, 12)
+	require.NoError(t, err)
+	require.Equal(t, []HeightRange{{6, 8}, {11, 12}}, gaps)
+
+	gaps, err = db.GetSyncGaps(ctx, 2, 10)
+	require.NoError(t, err)
+	require.Equal(t, []HeightRange{{6, 8}}, gaps)
+
+	require.Equal(t, []uint64{6, 7, 8, 11}, db.GetMissingHeights(ctx, 1, 11))
+	require.Nil(t, db.GetMissingHeights(ctx, 3, 5))
+}
+
+func TestGetSyncGaps_OverlappingRanges(t *testing.T) {
+	ctx := context.Background()
+	db := newSqliteImpl(t, "sync_ranges_overlap")
+	require.NoError(t, db.Db.AutoMigrate(&models.SyncRange{}))
+
+	// left behind by concurrent merges
+	require.NoError(t, db.Db.Create([]*models.SyncRange{
+		{StartHeight: 10, EndHeight: 20},
+		{StartHeight: 12, EndHeight: 15},
+		{StartHeight: 21, EndHeight: 25},
+		{StartHeight: 30, EndHeight: 40},
+	}).Error)
+
+	gaps, err := db.GetSyncGaps(ctx, 1, 35)
+	require.NoError(t, err)
+	require.Equal(t, []HeightRange{{1, 9}, {26, 29}}, gaps)
+}
+
+func TestSaveProcessedHeight_MergesConcurrentLeftovers(t *testing.T) {
+	ctx := context.Background()
+	db := newSqliteImpl(t, "sync_ranges_leftovers")
+	require.NoError(t, db.Db.AutoMigrate(&models.SyncRange{}))
+
+	// left apart by concurrent merges
+	require.NoError(t, db.Db.Create([]*models.SyncRange{
+		{StartHeight: 1, EndHeight: 5},
+		{StartHeight: 3, EndHeight: 4},
+		{StartHeight: 6, EndHeight: 9},
+		{StartHeight: 20, EndHeight: 25},
+	}).Error)
+
+	// a processed height is merged with the ranges touching its own
+	require.NoError(t, db.SaveProcessedHeight(ctx, 4))
+	require.Equal(t, []HeightRange{{1, 9}, {20, 25}}, syncRanges(t, db))
+
+	// the ranges reached through the widened window are merged too
+	require.NoError(t, db.Db.Create(&models.SyncRange{StartHeight: 11, EndHeight: 15}).Error)
+	require.NoError(t, db.Db.Create(&models.SyncRange{StartHeight: 12, EndHeight: 16}).Error)
+	require.NoError(t, db.SaveProcessedHeight(ctx, 10))
+	require.Equal(t, []HeightRange{{1, 16}, {20, 25}}, syncRanges(t, db))
+
+	require.NoError(t, db.SaveProcessedHeight(ctx, 22))
+	require.Equal(t, []HeightRange{{1, 16}, {20, 25}}, syncRanges(t, db))
+}
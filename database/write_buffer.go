@@ -0,0 +1,241 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// writeBatchSize is the maximum number of rows of a single INSERT statement when flushing a WriteBuffer
+const writeBatchSize = 500
+
+type writeBufferKey struct{}
+
+// WriteBuffer collects the writes of a block so that they can be flushed in batches at the end of the block
+// instead of one statement per event. Writes are grouped per table and merged per key: an upsert followed by
+// updates of the same key becomes a single row, consecutive updates of the same key a single UPDATE.
+//
+// Only the hot paths (objects, buckets and virtual groups) are buffered. Any other statement issued
+// with a context holding a buffer flushes it first, so reads always observe the pending writes and
+// the resulting rows are identical to the ones of the row-by-row path: a block mixing buffered writes
+// with other statements is flushed several times, in smaller batches.
+//
+// When the outbox is enabled, the buckets and objects are not buffered: their outbox records need the rows
+// before the change, so they are written right away, along with their records. Only the virtual groups
+// are buffered then.
+type WriteBuffer struct {
+	mu     sync.Mutex
+	tables []*bufferedTable
+	byName map[string]*bufferedTable
+	// shadow is the buffer of a second database receiving the same writes, see WithShadowWriteBuffer
+	shadow *WriteBuffer
+}
+
+type bufferedTable struct {
+	name string
+	// conflict are the columns of the upsert, and the key of the buffered rows
+	conflict []string
+	keys     []string
+	rows     map[string]*bufferedRow
+}
+
+type bufferedRow struct {
+	// row is the model to upsert, nil when the key only received updates
+	row interface{}
+	// where and updates describe the pending UPDATE when row is nil
+	where   map[string]interface{}
+	updates map[string]interface{}
+}
+
+// WithWriteBuffer returns a context carrying a new WriteBuffer. Writes done with this context are buffered
+// until Impl.FlushWriteBuffer is called.
+func WithWriteBuffer(ctx context.Context) context.Context {
+	return context.WithValue(ctx, writeBufferKey{}, &WriteBuffer{byName: make(map[string]*bufferedTable)})
+}
+
+// WithShadowWriteBuffer returns a context carrying the shadow of the WriteBuffer of ctx, or ctx itself when it
+// carries none. The shadow is created once per buffer, so that a second database writing the same block buffers
+// and flushes its writes on its own instead of sharing the rows of the first one.
+func WithShadowWriteBuffer(ctx context.Context) context.Context {
+	buffer := writeBufferFrom(ctx)
+	if buffer == nil {
+		return ctx
+	}
+
+	buffer.mu.Lock()
+	if buffer.shadow == nil {
+		buffer.shadow = &WriteBuffer{byName: make(map[string]*bufferedTable)}
+	}
+	shadow := buffer.shadow
+	buffer.mu.Unlock()
+
+	return context.WithValue(ctx, writeBufferKey{}, shadow)
+}
+
+func writeBufferFrom(ctx context.Context) *WriteBuffer {
+	if ctx == nil {
+		return nil
+	}
+	buffer, _ := ctx.Value(writeBufferKey{}).(*WriteBuffer)
+	return buffer
+}
+
+func (b *WriteBuffer) table(name string, conflict []string) *bufferedTable {
+	t, ok := b.byName[name]
+	if !ok {
+		t = &bufferedTable{name: name, conflict: conflict, rows: make(map[string]*bufferedRow)}
+		b.byName[name] = t
+		b.tables = append(b.tables, t)
+	}
+	return t
+}
+
+func (t *bufferedTable) entry(key string) *bufferedRow {
+	r, ok := t.rows[key]
+	if !ok {
+		r = &bufferedRow{}
+		t.rows[key] = r
+		t.keys = append(t.keys, key)
+	}
+	return r
+}
+
+func bufferKey(values ...interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%v", v)
+	}
+	return strings.Join(parts, "/")
+}
+
+// upsert buffers an INSERT ... ON CONFLICT (conflict) DO UPDATE of all the columns.
+// It overrides whatever was pending for the same key. The row is copied, so that the caller
+// can keep using the model like after a direct write.
+func (b *WriteBuffer) upsert(name string, conflict []string, key string, row interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	copied := reflect.New(reflect.TypeOf(row).Elem())
+	copied.Elem().Set(reflect.ValueOf(row).Elem())
+
+	r := b.table(name, conflict).entry(key)
+	r.row, r.where, r.updates = copied.Interface(), nil, nil
+}
+
+// update buffers an UPDATE of the given columns for the row matching where. When the row itself is pending,
+// the columns are applied to it.
+func (b *WriteBuffer) update(db *gorm.DB, name string, conflict []string, key string, where, updates map[string]interface{}) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r := b.table(name, conflict).entry(key)
+	if r.row == nil {
+		if r.updates == nil {
+			r.where, r.updates = where, make(map[string]interface{}, len(updates))
+		}
+		for column, value := range updates {
+			r.updates[column] = value
+		}
+		return nil
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(r.row); err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(r.row).Elem()
+	for column, value := range updates {
+		field := stmt.Schema.LookUpField(column)
+		if field == nil {
+			return fmt.Errorf("unknown column %s of table %s", column, name)
+		}
+		if err := field.Set(db.Statement.Context, rv, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush writes the pending rows and updates, table by table in order of first write, and empties the buffer
+func (b *WriteBuffer) flush(db *gorm.DB) error {
+	b.mu.Lock()
+	tables := b.tables
+	b.tables, b.byName = nil, make(map[string]*bufferedTable)
+	b.mu.Unlock()
+
+	if len(tables) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, t := range tables {
+			var rows reflect.Value
+			for _, key := range t.keys {
+				r := t.rows[key]
+				if r.row == nil {
+					continue
+				}
+				if !rows.IsValid() {
+					rows = reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(r.row)), 0, len(t.keys))
+				}
+				rows = reflect.Append(rows, reflect.ValueOf(r.row))
+			}
+
+			if rows.IsValid() {
+				columns := make([]clause.Column, len(t.conflict))
+				for i, c := range t.conflict {
+					columns[i] = clause.Column{Name: c}
+				}
+				err := tx.Table(t.name).Clauses(clause.OnConflict{
+					Columns:   columns,
+					UpdateAll: true,
+				}).CreateInBatches(rows.Interface(), writeBatchSize).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, key := range t.keys {
+				r := t.rows[key]
+				if r.row != nil || len(r.updates) == 0 {
+					continue
+				}
+				if err := tx.Table(t.name).Where(r.where).Updates(r.updates).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// FlushWriteBuffer writes the pending writes of the buffer carried by ctx, if any
+func (db *Impl) FlushWriteBuffer(ctx context.Context) error {
+	buffer := writeBufferFrom(ctx)
+	if buffer == nil {
+		return nil
+	}
+	return buffer.flush(db.Db.WithContext(ctx))
+}
+
+// session returns the connection to run a statement with. When ctx carries a WriteBuffer, it is flushed
+// first so that the statement runs after the pending writes. Inside a Snapshot, the statement runs in its
+// transaction, the buffer was flushed when it started.
+// Outside of one, the statement commits on its own, before the block is recorded as processed: a block which
+// failed partway is processed again over its committed writes, so every write run through session must be
+// idempotent, e.g. an upsert or an insert keyed on the event position (see WithEventPosition).
+func (db *Impl) session(ctx context.Context) *gorm.DB {
+	if tx := db.snapshot(ctx); tx != nil {
+		return tx
+	}
+	conn := db.Db.WithContext(ctx)
+	if err := db.FlushWriteBuffer(ctx); err != nil {
+		_ = conn.AddError(err)
+	}
+	return conn
+}
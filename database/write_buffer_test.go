@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/models"
+)
+
+func newSqliteImpl(t *testing.T, name string) *Impl {
+	db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Bucket{}, &models.GlobalVirtualGroup{}))
+	return &Impl{Db: db}
+}
+
+// writeBlock runs the same writes a block with several events on the same bucket would do
+func writeBlock(t *testing.T, db *Impl, ctx context.Context) {
+	require.NoError(t, db.SaveBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x01"), BucketName: "bucket-1", Status: "BUCKET_STATUS_CREATED"}))
+	require.NoError(t, db.UpdateBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x01"), Visibility: "VISIBILITY_TYPE_PUBLIC_READ", UpdateAt: 2}))
+	require.NoError(t, db.UpdateBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x01"), Status: "BUCKET_STATUS_MIGRATING", DestPrimarySPID: "3", UpdateAt: 3}))
+	require.NoError(t, db.SaveBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x02"), BucketName: "bucket-2"}))
+	require.NoError(t, db.SaveGVG(ctx, &models.GlobalVirtualGroup{GlobalVirtualGroupId: 1, FamilyId: 2}))
+
+	// a read in the middle of the block observes the pending writes
+	bucket, err := db.GetBucket(ctx, common.HexToHash("0x01"))
+	require.NoError(t, err)
+	require.NotNil(t, bucket)
+	require.Equal(t, "BUCKET_STATUS_MIGRATING", bucket.Status)
+
+	require.NoError(t, db.UpdateBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x02"), Removed: true, UpdateAt: 4}))
+	require.NoError(t, db.UpdateBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x03"), Removed: true, UpdateAt: 4}))
+}
+
+func TestWriteBuffer_SameResultsAsRowByRow(t *testing.T) {
+	direct := newSqliteImpl(t, "direct")
+	buffered := newSqliteImpl(t, "buffered")
+
+	writeBlock(t, direct, context.Background())
+
+	ctx := WithWriteBuffer(context.Background())
+	writeBlock(t, buffered, ctx)
+	require.NoError(t, buffered.FlushWriteBuffer(ctx))
+
+	var expected, actual []*models.Bucket
+	require.NoError(t, direct.Db.Order("bucket_id").Find(&expected).Error)
+	require.NoError(t, buffered.Db.Order("bucket_id").Find(&actual).Error)
+	require.Len(t, actual, 2)
+	for i := range expected {
+		expected[i].ID, actual[i].ID = 0, 0
+		require.Equal(t, expected[i], actual[i])
+	}
+
+	var gvgs []*models.GlobalVirtualGroup
+	require.NoError(t, buffered.Db.Find(&gvgs).Error)
+	require.Len(t, gvgs, 1)
+}
+
+func TestWriteBuffer_MergesUpdates(t *testing.T) {
+	db := newSqliteImpl(t, "merged")
+	ctx := WithWriteBuffer(context.Background())
+
+	require.NoError(t, db.UpdateBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x04"), Visibility: "VISIBILITY_TYPE_PRIVATE"}))
+	require.NoError(t, db.UpdateBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x04"), ChargedReadQuota: 10}))
+
+	buffer := writeBufferFrom(ctx)
+	table := buffer.byName[(&models.Bucket{}).TableName()]
+	require.Len(t, table.keys, 1)
+	require.Equal(t, map[string]interface{}{"visibility": "VISIBILITY_TYPE_PRIVATE", "charged_read_quota": uint64(10)}, table.rows[table.keys[0]].updates)
+
+	require.NoError(t, db.FlushWriteBuffer(ctx))
+	require.Empty(t, buffer.tables)
+}
+
+// TestWriteBuffer_Fallbacks verifies the cases where the writes are not held until the buffer is flushed
+func TestWriteBuffer_Fallbacks(t *testing.T) {
+	countBuckets := func(db *Impl) int64 {
+		var count int64
+		require.NoError(t, db.Db.Model(&models.Bucket{}).Count(&count).Error)
+		return count
+	}
+
+	// a statement which is not buffered flushes the pending writes first
+	db := newSqliteImpl(t, "fallback_session")
+	ctx := WithWriteBuffer(context.Background())
+	require.NoError(t, db.SaveBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x01"), BucketName: "bucket-1"}))
+	require.Zero(t, countBuckets(db))
+	_, err := db.GetBucketByName(ctx, "bucket-1")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), countBuckets(db))
+	require.Empty(t, writeBufferFrom(ctx).tables)
+
+	// with the outbox, the buckets are written right away and the virtual groups are still buffered
+	db = newSqliteImpl(t, "fallback_outbox")
+	require.NoError(t, db.Db.AutoMigrate(&models.Outbox{}))
+	db.Outbox = true
+	ctx = WithWriteBuffer(context.Background())
+	require.NoError(t, db.SaveBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x01"), BucketName: "bucket-1"}))
+	require.NoError(t, db.SaveGVG(ctx, &models.GlobalVirtualGroup{GlobalVirtualGroupId: 1, FamilyId: 2}))
+	require.Equal(t, int64(1), countBuckets(db))
+
+	var gvgs int64
+	require.NoError(t, db.Db.Model(&models.GlobalVirtualGroup{}).Count(&gvgs).Error)
+	require.Zero(t, gvgs)
+	require.NoError(t, db.FlushWriteBuffer(ctx))
+	require.NoError(t, db.Db.Model(&models.GlobalVirtualGroup{}).Count(&gvgs).Error)
+	require.Equal(t, int64(1), gvgs)
+}
@@ -74,3 +74,152 @@ var IndexerLatencyHist = promauto.NewHistogramVec(
 	},
 	[]string{"procedure"},
 )
+
+// PermissionSweptCount represents the Telemetry counter used to track the policies and statements removed by the expiry sweep
+var PermissionSweptCount = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "permission",
+		Name:      "swept_count",
+		Help:      "Count of expired policies and statements removed by the sweep.",
+	},
+	[]string{"kind"},
+)
+
+// PermissionLastSweep represents the Telemetry gauge used to track the time of the last expiry sweep
+var PermissionLastSweep = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "permission",
+		Name:      "last_sweep",
+		Help:      "Unix time of the last expired policy sweep.",
+	},
+)
+
+// DualDBHeight represents the Telemetry gauge used to track the last processed height written to each database
+// when writing to two databases
+var DualDBHeight = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "dual_db",
+		Name:      "height",
+		Help:      "Last processed height written to the database.",
+	},
+	[]string{"database"},
+)
+
+// DualDBLag represents the Telemetry gauge used to track how many blocks each database is behind the other one
+var DualDBLag = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "dual_db",
+		Name:      "lag",
+		Help:      "Number of processed blocks the database is behind the other one.",
+	},
+	[]string{"database"},
+)
+
+// DualDBAuthoritative represents the Telemetry gauge used to track which database serves the reads
+var DualDBAuthoritative = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "dual_db",
+		Name:      "authoritative",
+		Help:      "Whether the database is the authoritative one (1) or not (0).",
+	},
+	[]string{"database"},
+)
+
+// DualDBWriteErrors represents the Telemetry counter used to track the failed writes to the non authoritative database
+var DualDBWriteErrors = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "dual_db",
+		Name:      "write_errors",
+		Help:      "Count of failed writes to the non authoritative database.",
+	},
+	[]string{"database"},
+)
+
+// PruningDeletedCount represents the Telemetry counter used to track the rows deleted by the pruning, per table
+var PruningDeletedCount = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "pruning",
+		Name:      "deleted_count",
+		Help:      "Count of rows deleted by the pruning.",
+	},
+	[]string{"table"},
+)
+
+// PruningBatchCount represents the Telemetry counter used to track the delete batches run by the pruning, per table
+var PruningBatchCount = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "pruning",
+		Name:      "batch_count",
+		Help:      "Count of delete batches run by the pruning.",
+	},
+	[]string{"table"},
+)
+
+// PruningLastRun represents the Telemetry gauge used to track the time of the last completed pruning
+var PruningLastRun = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "pruning",
+		Name:      "last_run",
+		Help:      "Unix time of the last completed pruning.",
+	},
+)
+
+// PruningLastHeight represents the Telemetry gauge used to track the block height the last completed pruning ran at
+var PruningLastHeight = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "pruning",
+		Name:      "last_height",
+		Help:      "Block height the last completed pruning ran at.",
+	},
+)
+
+// ArchiveMovedCount represents the Telemetry counter used to track the removed rows moved to the archive tables, per table
+var ArchiveMovedCount = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "archive",
+		Name:      "moved_count",
+		Help:      "Count of removed rows moved to the archive tables.",
+	},
+	[]string{"table"},
+)
+
+// ArchiveLastRun represents the Telemetry gauge used to track the time of the last completed archival
+var ArchiveLastRun = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "archive",
+		Name:      "last_run",
+		Help:      "Unix time of the last completed archival.",
+	},
+)
+
+// OutboxPublishedCount represents the Telemetry counter used to track the outbox records published by the relay
+var OutboxPublishedCount = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "outbox",
+		Name:      "published_count",
+		Help:      "Count of outbox records published by the relay.",
+	},
+)
+
+// OutboxPublishErrors represents the Telemetry counter used to track the failed publications of the relay
+var OutboxPublishErrors = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "outbox",
+		Name:      "publish_errors",
+		Help:      "Count of outbox batches which failed to be published.",
+	},
+)
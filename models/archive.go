@@ -0,0 +1,137 @@
+package models
+
+import (
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+
+	"github.com/forbole/juno/v4/common"
+)
+
+// The archive tables have the columns of the archived tables, so that the rows are moved as they are, but
+// neither their primary key nor their unique indexes: a name, or a group membership, can be removed and
+// archived several times, and SQLite reuses the ids of the deleted rows. Each archived row has its own
+// archive_id instead, and the columns looked up are indexed.
+
+// BucketArchive holds the removed buckets moved out of the buckets table by the archival.
+type BucketArchive struct {
+	ArchiveID uint64 `gorm:"column:archive_id;primaryKey"`
+	ID        uint64 `gorm:"column:id;index:idx_buckets_archive_id"`
+
+	BucketID                   common.Hash    `gorm:"column:bucket_id;type:BINARY(32);index:idx_buckets_archive_bucket_id"`
+	BucketName                 string         `gorm:"column:bucket_name;type:varchar(64);index:idx_buckets_archive_bucket_name"`
+	Owner                      common.Address `gorm:"column:owner;type:BINARY(20)"`
+	PaymentAddress             common.Address `gorm:"column:payment_address;type:BINARY(20)"`
+	GlobalVirtualGroupFamilyId uint32         `gorm:"column:global_virtual_group_family_id"`
+	Operator                   common.Address `gorm:"column:operator;type:BINARY(20)"`
+	SourceType                 string         `gorm:"column:source_type;type:VARCHAR(50)"`
+	ChargedReadQuota           uint64         `gorm:"column:charged_read_quota"`
+	Visibility                 string         `gorm:"column:visibility;type:VARCHAR(50)"`
+	Status                     string         `gorm:"column:status;type:varchar(64);"`
+	DeleteAt                   int64          `gorm:"column:delete_at"`
+	DeleteReason               string         `gorm:"column:delete_reason;type:varchar(256);"`
+
+	MigrationStartTime    *int64 `gorm:"column:migration_start_time"`
+	MigrationCompleteTime *int64 `gorm:"column:migration_complete_time"`
+	DestPrimarySPID       string `gorm:"column:dest_primary_sp_id;type:varchar(64)"`
+	MigrationRejectReason string `gorm:"column:migration_reject_reason;type:varchar(256)"`
+
+	FlowRateLimit              *common.Big `gorm:"column:flow_rate_limit"`
+	FlowRateLimited            bool        `gorm:"column:flow_rate_limited;default:false"`
+	SpAsDelegatedAgentDisabled bool        `gorm:"column:sp_as_delegated_agent_disabled;default:false"`
+
+	StorageSize decimal.Decimal `gorm:"column:storage_size;type:DECIMAL(65, 0);not null"`
+	ChargeSize  decimal.Decimal `gorm:"column:charge_size;type:DECIMAL(65, 0);not null"`
+
+	CreateAt       int64       `gorm:"column:create_at"`
+	CreateTxHash   common.Hash `gorm:"column:create_tx_hash;type:BINARY(32);not null"`
+	CreateTime     int64       `gorm:"column:create_time"`
+	UpdateAt       int64       `gorm:"column:update_at"`
+	UpdateTxHash   common.Hash `gorm:"column:update_tx_hash;type:BINARY(32);not null"`
+	UpdateTime     int64       `gorm:"column:update_time"`
+	Removed        bool        `gorm:"column:removed;default:false"`
+	OffChainStatus int         `gorm:"column:off_chain_status;type:int;not null;default:0"`
+
+	Tags datatypes.JSON `gorm:"column:tags;TYPE:json"`
+}
+
+func (*BucketArchive) TableName() string {
+	return "buckets_archive"
+}
+
+// ObjectArchive holds the removed objects moved out of the objects table by the archival.
+type ObjectArchive struct {
+	ArchiveID uint64 `gorm:"column:archive_id;primaryKey"`
+	ID        uint64 `gorm:"column:id;index:idx_objects_archive_id"`
+
+	BucketID   common.Hash `gorm:"column:bucket_id;type:BINARY(32)"`
+	BucketName string      `gorm:"column:bucket_name;type:varchar(64);index:idx_objects_archive_bucket_name_object_name,priority:1"`
+	ObjectID   common.Hash `gorm:"column:object_id;type:BINARY(32);index:idx_objects_archive_object_id"`
+	ObjectName string      `gorm:"column:object_name;type:varchar(1024);index:idx_objects_archive_bucket_name_object_name,length:512,priority:2"`
+
+	Creator             common.Address `gorm:"column:creator;type:BINARY(20)"`
+	Owner               common.Address `gorm:"column:owner;type:BINARY(20)"`
+	LocalVirtualGroupId uint32         `gorm:"column:local_virtual_group_id"`
+	Operator            common.Address `gorm:"column:operator;type:BINARY(20)"`
+	PayloadSize         uint64         `gorm:"column:payload_size"`
+	Visibility          string         `gorm:"column:visibility;type:VARCHAR(50)"`
+	ContentType         string         `gorm:"column:content_type"`
+	Status              string         `gorm:"column:status;type:VARCHAR(50)"`
+	RedundancyType      string         `gorm:"column:redundancy_type;type:VARCHAR(50)"`
+	SourceType          string         `gorm:"column:source_type;type:VARCHAR(50)"`
+	CheckSums           pq.ByteaArray  `gorm:"column:checksums;type:text"`
+	DeleteAt            int64          `gorm:"column:delete_at"`
+	DeleteReason        string         `gorm:"column:delete_reason;type:varchar(256);"`
+
+	CreateAt     int64       `gorm:"column:create_at"`
+	CreateTxHash common.Hash `gorm:"column:create_tx_hash;type:BINARY(32);not null"`
+	CreateTime   int64       `gorm:"column:create_time"`
+	UpdateAt     int64       `gorm:"column:update_at"`
+	UpdateTxHash common.Hash `gorm:"column:update_tx_hash;type:BINARY(32);not null"`
+	SealedTxHash common.Hash `gorm:"column:sealed_tx_hash;type:BINARY(32)"`
+	UpdateTime   int64       `gorm:"column:update_time"`
+	Removed      bool        `gorm:"column:removed;default:false"`
+
+	Tags datatypes.JSON `gorm:"column:tags;TYPE:json"`
+
+	IsUpdating         bool           `gorm:"is_updating"`
+	ContentUpdatedTime int64          `gorm:"content_updated_time"`
+	Updater            common.Address `gorm:"column:updater;type:BINARY(20)"`
+	Version            int64          `gorm:"version"`
+
+	SourceChainID    uint32 `gorm:"column:source_chain_id"`
+	DestChainID      uint32 `gorm:"column:dest_chain_id"`
+	MirrorStatus     string `gorm:"column:mirror_status;type:varchar(50)"`
+	MirrorFailReason string `gorm:"column:mirror_fail_reason;type:varchar(256)"`
+}
+
+func (*ObjectArchive) TableName() string {
+	return "objects_archive"
+}
+
+// GroupArchive holds the removed group members moved out of the groups table by the archival.
+type GroupArchive struct {
+	ArchiveID  uint64         `gorm:"column:archive_id;primaryKey"`
+	ID         uint64         `gorm:"column:id;index:idx_groups_archive_id"`
+	Owner      common.Address `gorm:"column:owner;type:BINARY(20)"`
+	GroupID    common.Hash    `gorm:"column:group_id;type:BINARY(32);index:idx_groups_archive_group_id"`
+	GroupName  string         `gorm:"column:group_name;type:varchar(63)"`
+	SourceType string         `gorm:"column:source_type;type:varchar(63)"`
+	Extra      string         `gorm:"column:extra;type:varchar(512)"`
+
+	AccountID      common.Address `gorm:"column:account_id;type:BINARY(20);index:idx_groups_archive_account_id"`
+	Operator       common.Address `gorm:"column:operator;type:BINARY(20)"`
+	ExpirationTime int64          `gorm:"column:expiration_time"`
+
+	CreateAt   int64 `gorm:"column:create_at"`
+	CreateTime int64 `gorm:"column:create_time"`
+	UpdateAt   int64 `gorm:"column:update_at"`
+	UpdateTime int64 `gorm:"column:update_time"`
+	Removed    bool  `gorm:"column:removed;default:false"`
+
+	Tags datatypes.JSON `gorm:"column:tags;TYPE:json"`
+}
+
+func (*GroupArchive) TableName() string {
+	return "groups_archive"
+}
@@ -28,6 +28,11 @@ type Bucket struct {
 	DestPrimarySPID       string `gorm:"column:dest_primary_sp_id;type:varchar(64)"`
 	MigrationRejectReason string `gorm:"column:migration_reject_reason;type:varchar(256)"`
 
+	// Payment throttling and agent settings
+	FlowRateLimit              *common.Big `gorm:"column:flow_rate_limit"`                              // nil if never set
+	FlowRateLimited            bool        `gorm:"column:flow_rate_limited;default:false"`              // the bucket is frozen because its flow rate exceeds the limit
+	SpAsDelegatedAgentDisabled bool        `gorm:"column:sp_as_delegated_agent_disabled;default:false"` // the owner disabled the primary sp as upload agent
+
 	StorageSize decimal.Decimal `gorm:"column:storage_size;type:DECIMAL(65, 0);not null"`
 	ChargeSize  decimal.Decimal `gorm:"column:charge_size;type:DECIMAL(65, 0);not null"`
 
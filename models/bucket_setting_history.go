@@ -0,0 +1,42 @@
+package models
+
+import (
+	"github.com/forbole/juno/v4/common"
+)
+
+const (
+	BucketSettingFlowRateLimit       = "flow_rate_limit"
+	BucketSettingFlowRateLimitStatus = "flow_rate_limit_status"
+	BucketSettingSpAsDelegatedAgent  = "sp_as_delegated_agent"
+)
+
+// BucketSettingHistory records every change of the flow rate limit and delegated agent settings of a bucket.
+// Only the fields of the changed setting are set.
+// A flow rate limit is set for a bucket name, payment address and owner, and may be set before the bucket
+// exists: its BucketID is then zero, and it applies to the bucket created later with that name, payment
+// address and owner.
+type BucketSettingHistory struct {
+	ID uint64 `gorm:"column:id;primaryKey"`
+
+	BucketID   common.Hash `gorm:"column:bucket_id;type:BINARY(32);index:idx_setting_bucket_id_height,priority:1;uniqueIndex:idx_setting_position,priority:1"`
+	BucketName string      `gorm:"column:bucket_name;type:varchar(64);index:idx_setting_rate_limit_key,priority:1"`
+	Setting    string      `gorm:"column:setting;type:VARCHAR(32)"`
+
+	Operator                   common.Address `gorm:"column:operator;type:BINARY(20)"`
+	PaymentAddress             common.Address `gorm:"column:payment_address;type:BINARY(20);index:idx_setting_rate_limit_key,priority:2"`
+	BucketOwner                common.Address `gorm:"column:bucket_owner;type:BINARY(20);index:idx_setting_rate_limit_key,priority:3"`
+	FlowRateLimit              *common.Big    `gorm:"column:flow_rate_limit"`
+	FlowRateLimited            bool           `gorm:"column:flow_rate_limited"`
+	SpAsDelegatedAgentDisabled bool           `gorm:"column:sp_as_delegated_agent_disabled"`
+
+	// Height, TxHash and EventIndex locate the event, so that the change is recorded once when its block is
+	// processed again
+	Height     int64       `gorm:"column:height;index:idx_setting_bucket_id_height,priority:2;uniqueIndex:idx_setting_position,priority:2"`
+	TxHash     common.Hash `gorm:"column:tx_hash;type:BINARY(32);not null;uniqueIndex:idx_setting_position,priority:3"`
+	EventIndex int         `gorm:"column:event_index;uniqueIndex:idx_setting_position,priority:4"`
+	Timestamp  int64       `gorm:"column:timestamp"` // seconds
+}
+
+func (*BucketSettingHistory) TableName() string {
+	return "bucket_setting_history"
+}
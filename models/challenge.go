@@ -0,0 +1,45 @@
+package models
+
+import (
+	"github.com/forbole/juno/v4/common"
+)
+
+// Challenge is a data availability challenge of an object segment stored by a storage provider
+type Challenge struct {
+	ID uint64 `gorm:"column:id;primaryKey"`
+
+	ChallengeId       uint64         `gorm:"column:challenge_id;uniqueIndex:idx_challenge_id"`
+	ObjectID          common.Hash    `gorm:"column:object_id;type:BINARY(32);index:idx_challenge_object_id"`
+	SegmentIndex      uint32         `gorm:"column:segment_index"`
+	SpId              uint32         `gorm:"column:sp_id;index:idx_challenge_sp_id"`
+	SpOperatorAddress common.Address `gorm:"column:sp_operator_address;type:BINARY(20)"`
+	RedundancyIndex   int32          `gorm:"column:redundancy_index"`
+	ChallengerAddress common.Address `gorm:"column:challenger_address;type:BINARY(20)"`
+	ExpiredHeight     uint64         `gorm:"column:expired_height"`
+
+	// attestation, empty until the challenge is attested
+	AttestResult     string         `gorm:"column:attest_result;type:VARCHAR(50)"`
+	SlashAmount      *common.Big    `gorm:"column:slash_amount"`
+	SubmitterAddress common.Address `gorm:"column:submitter_address;type:BINARY(20)"`
+	AttestAt         int64          `gorm:"column:attest_at"`
+	AttestTxHash     common.Hash    `gorm:"column:attest_tx_hash;type:BINARY(32)"`
+	AttestTime       int64          `gorm:"column:attest_time"` // seconds
+
+	CreateAt     int64       `gorm:"column:create_at"`
+	CreateTxHash common.Hash `gorm:"column:create_tx_hash;type:BINARY(32);not null"`
+	CreateTime   int64       `gorm:"column:create_time"` // seconds
+}
+
+func (*Challenge) TableName() string {
+	return "challenges"
+}
+
+// ChallengeDetail is a challenge joined with the challenged object and storage provider
+type ChallengeDetail struct {
+	Challenge
+
+	BucketName string `gorm:"column:bucket_name"`
+	ObjectName string `gorm:"column:object_name"`
+	SpMoniker  string `gorm:"column:sp_moniker"`
+	SpEndpoint string `gorm:"column:sp_endpoint"`
+}
@@ -7,7 +7,7 @@ import (
 type GlobalVirtualGroupFamily struct {
 	// ID                         uint64             `gorm:"column:id;primaryKey"`
 	GlobalVirtualGroupFamilyId uint32             `gorm:"column:global_virtual_group_family_id;primaryKey;index:idx_vgf_id"`
-	PrimarySpId                uint32             `gorm:"column:primary_sp_id;index:idx_primary_sp_id"`
+	PrimarySpId                uint32             `gorm:"column:primary_sp_id;index:idx_vgf_primary_sp_id"`
 	GlobalVirtualGroupIds      common.Uint32Array `gorm:"column:global_virtual_group_ids;type:MEDIUMTEXT"`
 	VirtualPaymentAddress      common.Address     `gorm:"column:virtual_payment_address;type:BINARY(20)"`
 
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"github.com/forbole/juno/v4/common"
+)
+
+// ObjectLineage links an object created by a copy to the object it was copied from
+type ObjectLineage struct {
+	ID uint64 `gorm:"column:id;primaryKey"`
+
+	SrcObjectID common.Hash    `gorm:"column:src_object_id;type:BINARY(32);index:idx_lineage_src_object_id"`
+	DstObjectID common.Hash    `gorm:"column:dst_object_id;type:BINARY(32);uniqueIndex:idx_lineage_dst_object_id"`
+	Operator    common.Address `gorm:"column:operator;type:BINARY(20)"`
+
+	Height int64       `gorm:"column:height"`
+	TxHash common.Hash `gorm:"column:tx_hash;type:BINARY(32);not null"`
+}
+
+func (*ObjectLineage) TableName() string {
+	return "object_lineage"
+}
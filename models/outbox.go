@@ -0,0 +1,51 @@
+package models
+
+import (
+	"gorm.io/datatypes"
+
+	"github.com/forbole/juno/v4/common"
+)
+
+// Entity types of the outbox records
+const (
+	OutboxBucket      = "bucket"
+	OutboxObject      = "object"
+	OutboxGroup       = "group"
+	OutboxGroupMember = "group_member"
+	OutboxPolicy      = "policy"
+)
+
+// Operations of the outbox records
+const (
+	OutboxCreate = "create"
+	OutboxUpdate = "update"
+	OutboxDelete = "delete"
+)
+
+// Outbox is a change of an indexed entity. It is written in the transaction of the change,
+// and relayed to the downstream consumers once committed.
+type Outbox struct {
+	ID uint64 `gorm:"column:id;primaryKey" json:"id"`
+	// IdempotencyKey identifies the change: the same event making the same change, e.g. when its block is
+	// indexed again, gets the same key, so that the change is recorded once and consumers dedupe on it
+	IdempotencyKey string `gorm:"column:idempotency_key;type:varchar(64);not null;uniqueIndex:idx_outbox_idempotency_key" json:"idempotency_key"`
+
+	EntityType string `gorm:"column:entity_type;type:varchar(32)" json:"entity_type"`
+	EntityID   string `gorm:"column:entity_id;type:varchar(128)" json:"entity_id"`
+	Operation  string `gorm:"column:operation;type:varchar(16)" json:"operation"`
+	// Before and After are the row before and after the change, Before is empty on creation
+	Before datatypes.JSON `gorm:"column:before_row;TYPE:json" json:"before,omitempty"`
+	After  datatypes.JSON `gorm:"column:after_row;TYPE:json" json:"after"`
+
+	// Height, TxHash and EventIndex locate the event which caused the change
+	Height     uint64      `gorm:"column:height" json:"height"`
+	TxHash     common.Hash `gorm:"column:tx_hash;type:BINARY(32)" json:"tx_hash"`
+	EventIndex int         `gorm:"column:event_index" json:"event_index"`
+
+	Published   bool  `gorm:"column:published;default:false;index:idx_outbox_published" json:"-"`
+	PublishTime int64 `gorm:"column:publish_time" json:"-"` // seconds
+}
+
+func (*Outbox) TableName() string {
+	return "outbox"
+}
@@ -0,0 +1,30 @@
+package models
+
+import "github.com/forbole/juno/v4/common"
+
+const (
+	PaymentLedgerKindDeposit     = "deposit"
+	PaymentLedgerKindWithdraw    = "withdraw"
+	PaymentLedgerKindForceSettle = "force_settle"
+)
+
+// PaymentLedger records one balance-affecting event of a stream account
+type PaymentLedger struct {
+	ID uint64 `gorm:"column:id;primaryKey" json:"-"`
+
+	Account      common.Address `gorm:"column:account;type:BINARY(20);not null;index:idx_ledger_account_time,priority:1"`
+	Counterparty common.Address `gorm:"column:counterparty;type:BINARY(20)"`
+	// Amount is the settled balance for force settlements, which can be negative
+	Amount *common.Big `gorm:"column:amount"`
+	Kind   string      `gorm:"column:kind;type:VARCHAR(20)"`
+
+	// Height, TxHash and EventIndex locate the event, so that it is recorded once when its block is processed again
+	Height     int64       `gorm:"column:height;uniqueIndex:idx_ledger_position,priority:1"`
+	TxHash     common.Hash `gorm:"column:tx_hash;type:BINARY(32);not null;uniqueIndex:idx_ledger_position,priority:2"`
+	EventIndex int         `gorm:"column:event_index;uniqueIndex:idx_ledger_position,priority:3"`
+	Timestamp  int64       `gorm:"column:timestamp;index:idx_ledger_account_time,priority:2"` // seconds
+}
+
+func (*PaymentLedger) TableName() string {
+	return "payment_ledger"
+}
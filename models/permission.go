@@ -25,7 +25,7 @@ func (p Permission) TableName() string {
 
 type Statements struct {
 	ID             uint64         `gorm:"id;type:bigint(64);primaryKey"`
-	PolicyID       common.Hash    `gorm:"policy_id;type:BINARY(32);index:idx_policy_id"`
+	PolicyID       common.Hash    `gorm:"policy_id;type:BINARY(32);index:idx_statement_policy_id"`
 	Effect         string         `gorm:"effect;type:varchar(32)"`
 	ActionValue    int            `gorm:"action_value;type:int"`
 	Resources      pq.StringArray `gorm:"resources;type:text"`
@@ -0,0 +1,11 @@
+package models
+
+// Pruning holds the single row recording the height below which the blocks and txs were pruned.
+// It has the layout of the pruning table of the legacy schema.
+type Pruning struct {
+	LastPrunedHeight int64 `gorm:"column:last_pruned_height;not null;primaryKey;autoIncrement:false"`
+}
+
+func (*Pruning) TableName() string {
+	return "pruning"
+}
@@ -0,0 +1,12 @@
+package models
+
+// SchemaMigration records a versioned migration applied to the database schema
+type SchemaMigration struct {
+	Version     uint64 `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Description string `gorm:"column:description;type:VARCHAR(256)"`
+	AppliedAt   int64  `gorm:"column:applied_at"`
+}
+
+func (*SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
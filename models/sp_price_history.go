@@ -0,0 +1,40 @@
+package models
+
+import (
+	"github.com/forbole/juno/v4/common"
+)
+
+// SpPriceHistory records every storage price update of a storage provider
+type SpPriceHistory struct {
+	ID uint64 `gorm:"column:id;primaryKey"`
+
+	SpId          uint32      `gorm:"column:sp_id;uniqueIndex:idx_price_sp_id_height,priority:1;index:idx_price_sp_id_update_time,priority:1"`
+	UpdateTimeSec int64       `gorm:"column:update_time_sec;index:idx_price_sp_id_update_time,priority:2"`
+	ReadPrice     *common.Big `gorm:"column:read_price"`
+	FreeReadQuota uint64      `gorm:"column:free_read_quota"`
+	StorePrice    *common.Big `gorm:"column:store_price"`
+
+	Height int64       `gorm:"column:height;uniqueIndex:idx_price_sp_id_height,priority:2"`
+	TxHash common.Hash `gorm:"column:tx_hash;type:BINARY(32);not null"`
+}
+
+func (*SpPriceHistory) TableName() string {
+	return "sp_price_history"
+}
+
+// GlobalStorePrice records every update of the global sp store price
+type GlobalStorePrice struct {
+	ID uint64 `gorm:"column:id;primaryKey"`
+
+	UpdateTimeSec       int64       `gorm:"column:update_time_sec;uniqueIndex:idx_global_update_time_sec"`
+	ReadPrice           *common.Big `gorm:"column:read_price"`
+	PrimaryStorePrice   *common.Big `gorm:"column:primary_store_price"`
+	SecondaryStorePrice *common.Big `gorm:"column:secondary_store_price"`
+
+	Height int64       `gorm:"column:height;index:idx_global_price_height"`
+	TxHash common.Hash `gorm:"column:tx_hash;type:BINARY(32);not null"`
+}
+
+func (*GlobalStorePrice) TableName() string {
+	return "global_store_prices"
+}
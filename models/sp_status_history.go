@@ -0,0 +1,38 @@
+package models
+
+import (
+	"github.com/forbole/juno/v4/common"
+)
+
+const (
+	// SpStatusExited is the terminal status recorded once a storage provider has completed its exit.
+	// The chain has no such status, the sp is simply removed.
+	SpStatusExited = "STATUS_EXITED"
+
+	SpStatusReasonCreate             = "create"
+	SpStatusReasonUpdate             = "update_status"
+	SpStatusReasonGracefulExit       = "graceful_exit"
+	SpStatusReasonForcedExit         = "forced_exit"
+	SpStatusReasonCompleteExit       = "complete_exit"
+	SpStatusReasonCompleteForcedExit = "complete_forced_exit"
+)
+
+// SpStatusHistory records one status transition of a storage provider
+type SpStatusHistory struct {
+	ID uint64 `gorm:"column:id;primaryKey"`
+
+	SpId       uint32 `gorm:"column:sp_id;index:idx_sp_id_height,priority:1;uniqueIndex:idx_sp_status_position,priority:1"`
+	FromStatus string `gorm:"column:from_status;type:VARCHAR(50)"`
+	ToStatus   string `gorm:"column:to_status;type:VARCHAR(50)"`
+	Reason     string `gorm:"column:reason;type:VARCHAR(50)"`
+	// Height, TxHash and EventIndex locate the event, so that the transition is recorded once when its block
+	// is processed again
+	Height     int64       `gorm:"column:height;index:idx_sp_id_height,priority:2;uniqueIndex:idx_sp_status_position,priority:2"`
+	TxHash     common.Hash `gorm:"column:tx_hash;type:BINARY(32);not null;uniqueIndex:idx_sp_status_position,priority:3"`
+	EventIndex int         `gorm:"column:event_index;uniqueIndex:idx_sp_status_position,priority:4"`
+	UpdateTime int64       `gorm:"column:update_time"` // seconds
+}
+
+func (*SpStatusHistory) TableName() string {
+	return "sp_status_history"
+}
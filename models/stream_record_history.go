@@ -0,0 +1,44 @@
+package models
+
+import (
+	"github.com/forbole/juno/v4/common"
+)
+
+// StreamRecordHistory keeps the stream record of an account as of each block it was updated in
+type StreamRecordHistory struct {
+	ID uint64 `gorm:"column:id;primaryKey" json:"-"`
+
+	Account           common.Address `gorm:"column:account;type:BINARY(20);uniqueIndex:idx_history_account_height,priority:1"`
+	CrudTimestamp     int64          `gorm:"column:crud_timestamp"`
+	NetflowRate       *common.Big    `gorm:"column:netflow_rate"`
+	StaticBalance     *common.Big    `gorm:"column:static_balance"`
+	BufferBalance     *common.Big    `gorm:"column:buffer_balance"`
+	LockBalance       *common.Big    `gorm:"column:lock_balance"`
+	Status            string         `gorm:"column:status"`
+	SettleTimestamp   int64          `gorm:"column:settle_timestamp"`
+	FrozenNetflowRate *common.Big    `gorm:"column:frozen_netflow_rate"`
+
+	Height int64       `gorm:"column:height;uniqueIndex:idx_history_account_height,priority:2"`
+	TxHash common.Hash `gorm:"column:tx_hash;type:BINARY(32);not null"`
+}
+
+func (*StreamRecordHistory) TableName() string {
+	return "stream_record_history"
+}
+
+// StreamRecordOutFlow is one payment flow from a stream account to a receiver, usually a sp
+type StreamRecordOutFlow struct {
+	ID uint64 `gorm:"column:id;primaryKey" json:"-"`
+
+	From   common.Address `gorm:"column:from_address;type:BINARY(20);uniqueIndex:idx_outflow_from_to,priority:1"`
+	To     common.Address `gorm:"column:to_address;type:BINARY(20);uniqueIndex:idx_outflow_from_to,priority:2;index:idx_outflow_to"`
+	Rate   *common.Big    `gorm:"column:rate"`
+	Status string         `gorm:"column:status;type:VARCHAR(50)"`
+
+	UpdateAt   int64 `gorm:"column:update_at"`
+	UpdateTime int64 `gorm:"column:update_time"` // seconds
+}
+
+func (*StreamRecordOutFlow) TableName() string {
+	return "stream_record_outflows"
+}
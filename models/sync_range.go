@@ -0,0 +1,13 @@
+package models
+
+// SyncRange is a contiguous interval of processed heights, both ends included.
+// Adjacent and overlapping ranges are merged when a height is processed.
+type SyncRange struct {
+	ID          uint64 `gorm:"column:id;primaryKey"`
+	StartHeight uint64 `gorm:"column:start_height;not null;index:idx_sync_range_start_height"`
+	EndHeight   uint64 `gorm:"column:end_height;not null;index:idx_sync_range_end_height"`
+}
+
+func (*SyncRange) TableName() string {
+	return "sync_ranges"
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"github.com/forbole/juno/v4/common"
+)
+
+const (
+	VgSwapTypeSwapOut = "swap_out"
+	VgSwapTypeSwapIn  = "swap_in"
+
+	// VgSwapStatusPending is the status of a swap out waiting for its successor to complete it
+	VgSwapStatusPending = "pending"
+	// VgSwapStatusReserved is the status of a swap in reserved by its successor
+	VgSwapStatusReserved  = "reserved"
+	VgSwapStatusCompleted = "completed"
+	VgSwapStatusCancelled = "cancelled"
+)
+
+// VgSwap tracks one swap out or swap in of a storage provider.
+// Like the chain, a swap targets either a whole family (as primary sp, GlobalVirtualGroupId is 0)
+// or a single gvg (as secondary sp, GlobalVirtualGroupFamilyId is 0).
+type VgSwap struct {
+	ID uint64 `gorm:"column:id;primaryKey"`
+
+	SwapType                   string `gorm:"column:swap_type;type:VARCHAR(20);index:idx_vg_swap_target,priority:1"`
+	GlobalVirtualGroupFamilyId uint32 `gorm:"column:global_virtual_group_family_id;index:idx_vg_swap_target,priority:2;uniqueIndex:idx_vg_swap_position,priority:4"`
+	GlobalVirtualGroupId       uint32 `gorm:"column:global_virtual_group_id;index:idx_vg_swap_target,priority:3;uniqueIndex:idx_vg_swap_position,priority:5"`
+	SpId                       uint32 `gorm:"column:sp_id;index:idx_vg_swap_sp_id"` // the sp being swapped out
+	SuccessorSpId              uint32 `gorm:"column:successor_sp_id"`
+	ExpirationTime             uint64 `gorm:"column:expiration_time"` // seconds, swap in only
+	Status                     string `gorm:"column:status;type:VARCHAR(20)"`
+
+	// CreateAt, CreateTxHash and CreateEventIndex locate the event starting the swap, with the target they key
+	// the swap so that it is started once when its block is processed again
+	CreateAt         int64       `gorm:"column:create_at;uniqueIndex:idx_vg_swap_position,priority:1"`
+	CreateTxHash     common.Hash `gorm:"column:create_tx_hash;type:BINARY(32);not null;uniqueIndex:idx_vg_swap_position,priority:2"`
+	CreateEventIndex int         `gorm:"column:create_event_index;uniqueIndex:idx_vg_swap_position,priority:3"`
+	CreateTime       int64       `gorm:"column:create_time"` // seconds
+
+	UpdateAt     int64       `gorm:"column:update_at"`
+	UpdateTxHash common.Hash `gorm:"column:update_tx_hash;type:BINARY(32);not null"`
+	UpdateTime   int64       `gorm:"column:update_time"` // seconds
+}
+
+func (*VgSwap) TableName() string {
+	return "vg_swaps"
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultRequestTimeout is the timeout of a request when not configured
+	defaultRequestTimeout = 10 * time.Second
+	// defaultCORSMaxAge is how long the preflight responses are cached when not configured
+	defaultCORSMaxAge = 10 * time.Minute
+	// defaultMaxDepth is the maximum depth of a GraphQL query when not configured
+	defaultMaxDepth = 12
+	// defaultMaxComplexity is the maximum complexity of a GraphQL query when not configured
+	defaultMaxComplexity = 5000
+)
+
+type Config struct {
+	Port uint `yaml:"port"`
+	// RequestTimeout is the timeout of a request, the response is 503 once it is exceeded
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// CORS is the cross-origin policy of the server, the cross-origin requests are refused when not set
+	CORS *CORSConfig `yaml:"cors"`
+	// GraphQL enables the GraphQL endpoint when set
+	GraphQL *GraphQLConfig `yaml:"graphql"`
+}
+
+// CORSConfig describes which cross-origin requests are allowed
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to query the server, * allows all of them
+	AllowedOrigins []string `yaml:"allowed_origins"`
+	// AllowedHeaders are the request headers allowed besides the simple ones
+	AllowedHeaders []string `yaml:"allowed_headers"`
+	// MaxAge is how long the preflight responses are cached
+	MaxAge time.Duration `yaml:"max_age"`
+}
+
+// GraphQLConfig describes the limits of the GraphQL queries
+type GraphQLConfig struct {
+	// MaxDepth is the maximum nesting of the fields of a query
+	MaxDepth int `yaml:"max_depth"`
+	// MaxComplexity is the maximum number of fields a query may resolve, the fields of a list counting once per item
+	MaxComplexity int `yaml:"max_complexity"`
+}
+
+func ParseConfig(bz []byte) (*Config, error) {
+	type T struct {
+		Config *Config `yaml:"api"`
+	}
+	var cfg T
+	err := yaml.Unmarshal(bz, &cfg)
+	return cfg.Config, err
+}
+
+// GetRequestTimeout returns the timeout of a request
+func (cfg *Config) GetRequestTimeout() time.Duration {
+	if cfg.RequestTimeout <= 0 {
+		return defaultRequestTimeout
+	}
+	return cfg.RequestTimeout
+}
+
+// GetMaxAge returns how long the preflight responses are cached
+func (cfg *CORSConfig) GetMaxAge() time.Duration {
+	if cfg.MaxAge <= 0 {
+		return defaultCORSMaxAge
+	}
+	return cfg.MaxAge
+}
+
+// GetMaxDepth returns the maximum nesting of the fields of a query
+func (cfg *GraphQLConfig) GetMaxDepth() int {
+	if cfg.MaxDepth <= 0 {
+		return defaultMaxDepth
+	}
+	return cfg.MaxDepth
+}
+
+// GetMaxComplexity returns the maximum complexity of a query
+func (cfg *GraphQLConfig) GetMaxComplexity() int {
+	if cfg.MaxComplexity <= 0 {
+		return defaultMaxComplexity
+	}
+	return cfg.MaxComplexity
+}
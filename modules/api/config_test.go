@@ -0,0 +1,37 @@
+package api_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/forbole/juno/v4/modules/api"
+)
+
+func TestParseConfig(t *testing.T) {
+	data := []byte(`
+api:
+  port: 8080
+  cors:
+    allowed_origins: ["https://example.com"]
+  graphql:
+    max_depth: 8
+`)
+
+	cfg, err := api.ParseConfig(data)
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	require.Equal(t, uint(8080), cfg.Port)
+	require.Equal(t, 10*time.Second, cfg.GetRequestTimeout())
+	require.Equal(t, []string{"https://example.com"}, cfg.CORS.AllowedOrigins)
+	require.Equal(t, 10*time.Minute, cfg.CORS.GetMaxAge())
+	require.Equal(t, 8, cfg.GraphQL.GetMaxDepth())
+	require.Equal(t, 5000, cfg.GraphQL.GetMaxComplexity())
+
+	cfg, err = api.ParseConfig([]byte(`invalid_field: yes`))
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+	require.Error(t, api.RunAdditionalOperations(cfg, nil))
+	require.Error(t, api.RunAdditionalOperations(&api.Config{}, nil))
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/validator"
+
+	"github.com/forbole/juno/v4/database"
+)
+
+const (
+	// maxGraphQLRequestSize is the maximum size of the body of a GraphQL request
+	maxGraphQLRequestSize = 1 << 20
+	// unsizedListItems is the number of items a list without first argument is counted for in the complexity
+	unsizedListItems = 10
+	// complexityCeiling bounds the complexity computed for a selection, so that it cannot overflow
+	complexityCeiling = 1 << 40
+)
+
+// graphqlHandler serves the GraphQL queries over the indexed data
+type graphqlHandler struct {
+	cfg    *GraphQLConfig
+	repo   database.Repository
+	schema *graphql.Schema
+	// ast is the schema the complexity of the queries is computed against
+	ast *ast.Schema
+}
+
+func newGraphQLHandler(cfg *GraphQLConfig, repo database.Repository) *graphqlHandler {
+	return &graphqlHandler{
+		cfg:    cfg,
+		repo:   repo,
+		schema: graphql.MustParseSchema(graphqlSchema, &queryResolver{}, graphql.MaxDepth(cfg.GetMaxDepth())),
+		ast:    gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: graphqlSchema}),
+	}
+}
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (h *graphqlHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req graphqlRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLRequestSize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid graphql request")
+		return
+	}
+
+	if complexity := h.complexity(req); complexity > h.cfg.GetMaxComplexity() {
+		writeJSON(w, http.StatusOK, &graphql.Response{Errors: []*gqlerrors.QueryError{{
+			Message: fmt.Sprintf("query complexity %d exceeds the limit of %d", complexity, h.cfg.GetMaxComplexity()),
+		}}})
+		return
+	}
+
+	ctx := withLoaders(r.Context(), newLoaders(h.repo))
+	writeJSON(w, http.StatusOK, h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+// complexity returns the complexity of the operation of the request. Invalid requests have no complexity,
+// they are rejected on execution.
+func (h *graphqlHandler) complexity(req graphqlRequest) int {
+	doc, errs := gqlparser.LoadQuery(h.ast, req.Query)
+	if len(errs) != 0 {
+		return 0
+	}
+	op := doc.Operations.ForName(req.OperationName)
+	if op == nil {
+		return 0
+	}
+	vars, err := validator.VariableValues(h.ast, op, req.Variables)
+	if err != nil {
+		return 0
+	}
+	return selectionComplexity(op.SelectionSet, vars)
+}
+
+// selectionComplexity returns the number of fields the selections resolve. The fields of a paginated
+// field count once per item of its page, and those of another list once per unsizedListItems.
+func selectionComplexity(selections ast.SelectionSet, vars map[string]interface{}) int {
+	total := 0
+	for _, selection := range selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			items := 1
+			if first, ok := s.ArgumentMap(vars)["first"]; ok {
+				items = pageItems(first)
+			} else if s.Definition.Type.Elem != nil && !strings.HasSuffix(s.ObjectDefinition.Name, "Connection") {
+				// the nodes of a connection are counted by its first argument
+				items = unsizedListItems
+			}
+			total += items * (1 + selectionComplexity(s.SelectionSet, vars))
+		case *ast.InlineFragment:
+			total += selectionComplexity(s.SelectionSet, vars)
+		case *ast.FragmentSpread:
+			total += selectionComplexity(s.Definition.SelectionSet, vars)
+		}
+		if total > complexityCeiling {
+			return complexityCeiling
+		}
+	}
+	return total
+}
+
+// pageItems returns the number of items of a page whose first argument is given
+func pageItems(first interface{}) int {
+	var n int64
+	switch first := first.(type) {
+	case int64:
+		n = first
+	case float64:
+		n = int64(first)
+	case json.Number:
+		n, _ = first.Int64()
+	}
+	if n <= 0 {
+		return database.DefaultPageLimit
+	}
+	if n > database.MaxPageLimit {
+		return database.MaxPageLimit
+	}
+	return int(n)
+}
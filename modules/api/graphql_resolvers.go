@@ -0,0 +1,569 @@
+package api
+
+import (
+	"context"
+
+	"github.com/evmos/evmos/v12/types/resource"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/common/hexutil"
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/models"
+)
+
+// loaders are the loaders of a GraphQL request, along with the repository the lists are read from
+type loaders struct {
+	repo database.Repository
+
+	buckets       *loader[common.Hash, *models.Bucket]
+	lvgs          *loader[common.Hash, []*models.LocalVirtualGroup] // by bucket id
+	gvgs          *loader[uint32, *models.GlobalVirtualGroup]
+	sps           *loader[uint32, *models.StorageProvider]
+	streamRecords *loader[common.Address, *models.StreamRecord]
+}
+
+// newLoaders returns the loaders of a request. The rows fetched together prime the keys of their relations,
+// so that the relations of sibling rows are fetched together as well.
+func newLoaders(repo database.Repository) *loaders {
+	l := &loaders{repo: repo}
+	l.buckets = newLoader(func(ctx context.Context, ids []common.Hash) (map[common.Hash]*models.Bucket, error) {
+		buckets, err := repo.GetBucketsByIDs(ctx, ids)
+		newBucketResolvers(l, buckets...)
+		return byKey(buckets, func(b *models.Bucket) common.Hash { return b.BucketID }), err
+	})
+	l.lvgs = newLoader(func(ctx context.Context, bucketIDs []common.Hash) (map[common.Hash][]*models.LocalVirtualGroup, error) {
+		lvgs, err := repo.GetLVGsByBucketIDs(ctx, bucketIDs)
+		newLVGResolvers(l, lvgs...)
+		values := make(map[common.Hash][]*models.LocalVirtualGroup)
+		for _, lvg := range lvgs {
+			values[lvg.BucketID] = append(values[lvg.BucketID], lvg)
+		}
+		return values, err
+	})
+	l.gvgs = newLoader(func(ctx context.Context, ids []uint32) (map[uint32]*models.GlobalVirtualGroup, error) {
+		gvgs, err := repo.GetGVGsByIDs(ctx, ids)
+		newGVGResolvers(l, gvgs...)
+		return byKey(gvgs, func(g *models.GlobalVirtualGroup) uint32 { return g.GlobalVirtualGroupId }), err
+	})
+	l.sps = newLoader(func(ctx context.Context, ids []uint32) (map[uint32]*models.StorageProvider, error) {
+		sps, err := repo.GetStorageProvidersByIDs(ctx, ids)
+		return byKey(sps, func(sp *models.StorageProvider) uint32 { return sp.SpId }), err
+	})
+	l.streamRecords = newLoader(func(ctx context.Context, accounts []common.Address) (map[common.Address]*models.StreamRecord, error) {
+		streamRecords, err := repo.GetStreamRecordsByAccounts(ctx, accounts)
+		return byKey(streamRecords, func(s *models.StreamRecord) common.Address { return s.Account }), err
+	})
+	return l
+}
+
+func byKey[K comparable, V any](rows []*V, key func(*V) K) map[K]*V {
+	values := make(map[K]*V, len(rows))
+	for _, row := range rows {
+		values[key(row)] = row
+	}
+	return values
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// pageArgs are the arguments of the paginated fields
+type pageArgs struct {
+	First int32
+	After *string
+}
+
+func (a pageArgs) page() database.Page {
+	page := database.Page{Limit: int(a.First)}
+	if a.After != nil {
+		page.Cursor = *a.After
+	}
+	return page
+}
+
+func nextCursor(cursor string) *string {
+	if cursor == "" {
+		return nil
+	}
+	return &cursor
+}
+
+func long(n int64) hexutil.Uint64 {
+	return hexutil.Uint64(n)
+}
+
+func decimal(b *common.Big) *string {
+	if b == nil {
+		return nil
+	}
+	s := b.Raw().String()
+	return &s
+}
+
+// queryResolver resolves the root queries
+type queryResolver struct{}
+
+func (*queryResolver) Bucket(ctx context.Context, args struct{ Name string }) (*bucketResolver, error) {
+	l := loadersFrom(ctx)
+	bucket, err := l.repo.GetBucketByName(ctx, args.Name)
+	if bucket == nil || err != nil {
+		return nil, err
+	}
+	return newBucketResolvers(l, bucket)[0], nil
+}
+
+func (*queryResolver) BucketById(ctx context.Context, args struct{ Id common.Hash }) (*bucketResolver, error) {
+	l := loadersFrom(ctx)
+	bucket, err := l.buckets.load(ctx, args.Id)
+	if bucket == nil || err != nil {
+		return nil, err
+	}
+	return newBucketResolvers(l, bucket)[0], nil
+}
+
+func (*queryResolver) Object(ctx context.Context, args struct{ BucketName, Name string }) (*objectResolver, error) {
+	l := loadersFrom(ctx)
+	object, err := l.repo.GetObjectByName(ctx, args.BucketName, args.Name)
+	if object == nil || err != nil {
+		return nil, err
+	}
+	return newObjectResolvers(l, object)[0], nil
+}
+
+func (*queryResolver) Group(ctx context.Context, args struct{ Id common.Hash }) (*groupResolver, error) {
+	l := loadersFrom(ctx)
+	group, err := l.repo.GetGroup(ctx, args.Id)
+	if group == nil || err != nil {
+		return nil, err
+	}
+	return &groupResolver{l: l, group: group}, nil
+}
+
+func (*queryResolver) Account(ctx context.Context, args struct{ Address common.Address }) *accountResolver {
+	return newAccountResolver(loadersFrom(ctx), args.Address)
+}
+
+func (*queryResolver) StorageProvider(ctx context.Context, args struct{ Id int32 }) (*storageProviderResolver, error) {
+	sp, err := loadersFrom(ctx).sps.load(ctx, uint32(args.Id))
+	if sp == nil || err != nil {
+		return nil, err
+	}
+	return &storageProviderResolver{sp: sp}, nil
+}
+
+func (*queryResolver) Gvg(ctx context.Context, args struct{ Id int32 }) (*gvgResolver, error) {
+	l := loadersFrom(ctx)
+	gvg, err := l.gvgs.load(ctx, uint32(args.Id))
+	if gvg == nil || err != nil {
+		return nil, err
+	}
+	return newGVGResolvers(l, gvg)[0], nil
+}
+
+type accountResolver struct {
+	l       *loaders
+	address common.Address
+}
+
+func newAccountResolver(l *loaders, address common.Address) *accountResolver {
+	l.streamRecords.prime(address)
+	return &accountResolver{l: l, address: address}
+}
+
+func (r *accountResolver) Address() common.Address { return r.address }
+
+func (r *accountResolver) Buckets(ctx context.Context, args pageArgs) (*bucketConnection, error) {
+	buckets, cursor, err := r.l.repo.ListBucketsByOwner(ctx, r.address, args.page())
+	if err != nil {
+		return nil, err
+	}
+	return &bucketConnection{nodes: newBucketResolvers(r.l, buckets...), cursor: cursor}, nil
+}
+
+func (r *accountResolver) PaymentAccounts(ctx context.Context, args pageArgs) (*paymentAccountConnection, error) {
+	paymentAccounts, cursor, err := r.l.repo.ListPaymentAccountsByOwner(ctx, r.address, args.page())
+	if err != nil {
+		return nil, err
+	}
+	connection := &paymentAccountConnection{cursor: cursor}
+	for _, paymentAccount := range paymentAccounts {
+		connection.nodes = append(connection.nodes, &paymentAccountResolver{l: r.l, paymentAccount: paymentAccount})
+	}
+	return connection, nil
+}
+
+func (r *accountResolver) StreamRecord(ctx context.Context) (*streamRecordResolver, error) {
+	streamRecord, err := r.l.streamRecords.load(ctx, r.address)
+	if streamRecord == nil || err != nil {
+		return nil, err
+	}
+	return &streamRecordResolver{streamRecord: streamRecord}, nil
+}
+
+type bucketResolver struct {
+	l      *loaders
+	bucket *models.Bucket
+}
+
+// newBucketResolvers returns the resolvers of buckets listed together, whose lvgs are loaded at once
+func newBucketResolvers(l *loaders, buckets ...*models.Bucket) []*bucketResolver {
+	resolvers := make([]*bucketResolver, len(buckets))
+	for i, bucket := range buckets {
+		l.lvgs.prime(bucket.BucketID)
+		l.streamRecords.prime(bucket.Owner)
+		resolvers[i] = &bucketResolver{l: l, bucket: bucket}
+	}
+	return resolvers
+}
+
+func (r *bucketResolver) Id() common.Hash                { return r.bucket.BucketID }
+func (r *bucketResolver) Name() string                   { return r.bucket.BucketName }
+func (r *bucketResolver) Owner() *accountResolver        { return newAccountResolver(r.l, r.bucket.Owner) }
+func (r *bucketResolver) PaymentAddress() common.Address { return r.bucket.PaymentAddress }
+func (r *bucketResolver) Operator() common.Address       { return r.bucket.Operator }
+func (r *bucketResolver) Visibility() string             { return r.bucket.Visibility }
+func (r *bucketResolver) Status() string                 { return r.bucket.Status }
+func (r *bucketResolver) SourceType() string             { return r.bucket.SourceType }
+func (r *bucketResolver) ChargedReadQuota() hexutil.Uint64 {
+	return hexutil.Uint64(r.bucket.ChargedReadQuota)
+}
+func (r *bucketResolver) GlobalVirtualGroupFamilyId() int32 {
+	return int32(r.bucket.GlobalVirtualGroupFamilyId)
+}
+func (r *bucketResolver) CreateAt() hexutil.Uint64   { return long(r.bucket.CreateAt) }
+func (r *bucketResolver) CreateTxHash() common.Hash  { return r.bucket.CreateTxHash }
+func (r *bucketResolver) CreateTime() hexutil.Uint64 { return long(r.bucket.CreateTime) }
+func (r *bucketResolver) UpdateAt() hexutil.Uint64   { return long(r.bucket.UpdateAt) }
+func (r *bucketResolver) UpdateTime() hexutil.Uint64 { return long(r.bucket.UpdateTime) }
+
+func (r *bucketResolver) Objects(ctx context.Context, args struct {
+	Prefix string
+	First  int32
+	After  *string
+}) (*objectConnection, error) {
+	page := pageArgs{First: args.First, After: args.After}.page()
+	objects, cursor, err := r.l.repo.ListObjectsByPrefix(ctx, r.bucket.BucketName, args.Prefix, page)
+	if err != nil {
+		return nil, err
+	}
+	return &objectConnection{nodes: newObjectResolvers(r.l, objects...), cursor: cursor}, nil
+}
+
+func (r *bucketResolver) Lvgs(ctx context.Context) ([]*lvgResolver, error) {
+	lvgs, err := r.l.lvgs.load(ctx, r.bucket.BucketID)
+	if err != nil {
+		return nil, err
+	}
+	return newLVGResolvers(r.l, lvgs...), nil
+}
+
+func (r *bucketResolver) Policies(ctx context.Context, args pageArgs) (*policyConnection, error) {
+	policies, cursor, err := r.l.repo.ListPermissionsByResource(ctx, resource.RESOURCE_TYPE_BUCKET.String(), r.bucket.BucketID, args.page())
+	if err != nil {
+		return nil, err
+	}
+	connection := &policyConnection{cursor: cursor}
+	for _, policy := range policies {
+		connection.nodes = append(connection.nodes, &policyResolver{policy: policy})
+	}
+	return connection, nil
+}
+
+type bucketConnection struct {
+	nodes  []*bucketResolver
+	cursor string
+}
+
+func (c *bucketConnection) Nodes() []*bucketResolver { return c.nodes }
+func (c *bucketConnection) NextCursor() *string      { return nextCursor(c.cursor) }
+
+// defaultVersions is the number of versions of an object returned when not set
+const defaultVersions = 10
+
+type objectResolver struct {
+	l      *loaders
+	object *models.Object
+}
+
+// newObjectResolvers returns the resolvers of objects listed together, whose buckets and lvgs are loaded at once
+func newObjectResolvers(l *loaders, objects ...*models.Object) []*objectResolver {
+	resolvers := make([]*objectResolver, len(objects))
+	for i, object := range objects {
+		l.buckets.prime(object.BucketID)
+		l.lvgs.prime(object.BucketID)
+		resolvers[i] = &objectResolver{l: l, object: object}
+	}
+	return resolvers
+}
+
+func (r *objectResolver) Id() common.Hash             { return r.object.ObjectID }
+func (r *objectResolver) BucketName() string          { return r.object.BucketName }
+func (r *objectResolver) Name() string                { return r.object.ObjectName }
+func (r *objectResolver) Owner() *accountResolver     { return newAccountResolver(r.l, r.object.Owner) }
+func (r *objectResolver) Creator() common.Address     { return r.object.Creator }
+func (r *objectResolver) PayloadSize() hexutil.Uint64 { return hexutil.Uint64(r.object.PayloadSize) }
+func (r *objectResolver) ContentType() string         { return r.object.ContentType }
+func (r *objectResolver) Visibility() string          { return r.object.Visibility }
+func (r *objectResolver) Status() string              { return r.object.Status }
+func (r *objectResolver) RedundancyType() string      { return r.object.RedundancyType }
+func (r *objectResolver) SourceType() string          { return r.object.SourceType }
+func (r *objectResolver) Version() hexutil.Uint64     { return long(r.object.Version) }
+func (r *objectResolver) CreateAt() hexutil.Uint64    { return long(r.object.CreateAt) }
+func (r *objectResolver) CreateTxHash() common.Hash   { return r.object.CreateTxHash }
+func (r *objectResolver) CreateTime() hexutil.Uint64  { return long(r.object.CreateTime) }
+func (r *objectResolver) UpdateAt() hexutil.Uint64    { return long(r.object.UpdateAt) }
+func (r *objectResolver) UpdateTime() hexutil.Uint64  { return long(r.object.UpdateTime) }
+func (r *objectResolver) Removed() bool               { return r.object.Removed }
+
+func (r *objectResolver) Bucket(ctx context.Context) (*bucketResolver, error) {
+	bucket, err := r.l.buckets.load(ctx, r.object.BucketID)
+	if bucket == nil || err != nil {
+		return nil, err
+	}
+	return newBucketResolvers(r.l, bucket)[0], nil
+}
+
+func (r *objectResolver) Lvg(ctx context.Context) (*lvgResolver, error) {
+	lvgs, err := r.l.lvgs.load(ctx, r.object.BucketID)
+	if err != nil {
+		return nil, err
+	}
+	for _, lvg := range lvgs {
+		if lvg.LocalVirtualGroupId == r.object.LocalVirtualGroupId {
+			return newLVGResolvers(r.l, lvg)[0], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *objectResolver) Versions(ctx context.Context, args struct{ First int32 }) ([]*objectResolver, error) {
+	limit := defaultVersions
+	if args.First > 0 && args.First <= database.MaxPageLimit {
+		limit = int(args.First)
+	}
+	objects, err := r.l.repo.SearchObjects(ctx, database.ArchiveSearch{
+		Where: map[string]interface{}{"bucket_name": r.object.BucketName, "object_name": r.object.ObjectName},
+		Limit: limit,
+		Desc:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newObjectResolvers(r.l, objects...), nil
+}
+
+type objectConnection struct {
+	nodes  []*objectResolver
+	cursor string
+}
+
+func (c *objectConnection) Nodes() []*objectResolver { return c.nodes }
+func (c *objectConnection) NextCursor() *string      { return nextCursor(c.cursor) }
+
+type lvgResolver struct {
+	l   *loaders
+	lvg *models.LocalVirtualGroup
+}
+
+// newLVGResolvers returns the resolvers of lvgs listed together, whose gvgs are loaded at once
+func newLVGResolvers(l *loaders, lvgs ...*models.LocalVirtualGroup) []*lvgResolver {
+	resolvers := make([]*lvgResolver, len(lvgs))
+	for i, lvg := range lvgs {
+		l.gvgs.prime(lvg.GlobalVirtualGroupId)
+		resolvers[i] = &lvgResolver{l: l, lvg: lvg}
+	}
+	return resolvers
+}
+
+func (r *lvgResolver) Id() int32                  { return int32(r.lvg.LocalVirtualGroupId) }
+func (r *lvgResolver) BucketId() common.Hash      { return r.lvg.BucketID }
+func (r *lvgResolver) StoredSize() hexutil.Uint64 { return hexutil.Uint64(r.lvg.StoredSize) }
+
+func (r *lvgResolver) Gvg(ctx context.Context) (*gvgResolver, error) {
+	gvg, err := r.l.gvgs.load(ctx, r.lvg.GlobalVirtualGroupId)
+	if gvg == nil || err != nil {
+		return nil, err
+	}
+	return newGVGResolvers(r.l, gvg)[0], nil
+}
+
+type gvgResolver struct {
+	l   *loaders
+	gvg *models.GlobalVirtualGroup
+}
+
+// newGVGResolvers returns the resolvers of gvgs listed together, whose sps are loaded at once
+func newGVGResolvers(l *loaders, gvgs ...*models.GlobalVirtualGroup) []*gvgResolver {
+	resolvers := make([]*gvgResolver, len(gvgs))
+	for i, gvg := range gvgs {
+		l.sps.prime(gvg.PrimarySpId)
+		l.sps.prime(gvg.SecondarySpIds...)
+		resolvers[i] = &gvgResolver{l: l, gvg: gvg}
+	}
+	return resolvers
+}
+
+func (r *gvgResolver) Id() int32                             { return int32(r.gvg.GlobalVirtualGroupId) }
+func (r *gvgResolver) FamilyId() int32                       { return int32(r.gvg.FamilyId) }
+func (r *gvgResolver) StoredSize() hexutil.Uint64            { return hexutil.Uint64(r.gvg.StoredSize) }
+func (r *gvgResolver) VirtualPaymentAddress() common.Address { return r.gvg.VirtualPaymentAddress }
+func (r *gvgResolver) TotalDeposit() *string                 { return decimal(r.gvg.TotalDeposit) }
+
+func (r *gvgResolver) PrimarySp(ctx context.Context) (*storageProviderResolver, error) {
+	sp, err := r.l.sps.load(ctx, r.gvg.PrimarySpId)
+	if sp == nil || err != nil {
+		return nil, err
+	}
+	return &storageProviderResolver{sp: sp}, nil
+}
+
+func (r *gvgResolver) SecondarySps(ctx context.Context) ([]*storageProviderResolver, error) {
+	var resolvers []*storageProviderResolver
+	for _, spId := range r.gvg.SecondarySpIds {
+		sp, err := r.l.sps.load(ctx, spId)
+		if err != nil {
+			return nil, err
+		}
+		if sp != nil {
+			resolvers = append(resolvers, &storageProviderResolver{sp: sp})
+		}
+	}
+	return resolvers, nil
+}
+
+type storageProviderResolver struct {
+	sp *models.StorageProvider
+}
+
+func (r *storageProviderResolver) Id() int32                       { return int32(r.sp.SpId) }
+func (r *storageProviderResolver) OperatorAddress() common.Address { return r.sp.OperatorAddress }
+func (r *storageProviderResolver) FundingAddress() common.Address  { return r.sp.FundingAddress }
+func (r *storageProviderResolver) SealAddress() common.Address     { return r.sp.SealAddress }
+func (r *storageProviderResolver) ApprovalAddress() common.Address { return r.sp.ApprovalAddress }
+func (r *storageProviderResolver) GcAddress() common.Address       { return r.sp.GcAddress }
+func (r *storageProviderResolver) Status() string                  { return r.sp.Status }
+func (r *storageProviderResolver) Endpoint() string                { return r.sp.Endpoint }
+func (r *storageProviderResolver) Moniker() string                 { return r.sp.Moniker }
+func (r *storageProviderResolver) Website() string                 { return r.sp.Website }
+func (r *storageProviderResolver) Details() string                 { return r.sp.Details }
+func (r *storageProviderResolver) TotalDeposit() *string           { return decimal(r.sp.TotalDeposit) }
+
+type policyResolver struct {
+	policy *models.Permission
+}
+
+func (r *policyResolver) Id() common.Hash                 { return r.policy.PolicyID }
+func (r *policyResolver) PrincipalType() int32            { return r.policy.PrincipalType }
+func (r *policyResolver) PrincipalValue() string          { return r.policy.PrincipalValue }
+func (r *policyResolver) ResourceType() string            { return r.policy.ResourceType }
+func (r *policyResolver) ResourceId() common.Hash         { return r.policy.ResourceID }
+func (r *policyResolver) ExpirationTime() hexutil.Uint64  { return long(r.policy.ExpirationTime) }
+func (r *policyResolver) CreateTimestamp() hexutil.Uint64 { return long(r.policy.CreateTimestamp) }
+func (r *policyResolver) UpdateTimestamp() hexutil.Uint64 { return long(r.policy.UpdateTimestamp) }
+
+type policyConnection struct {
+	nodes  []*policyResolver
+	cursor string
+}
+
+func (c *policyConnection) Nodes() []*policyResolver { return c.nodes }
+func (c *policyConnection) NextCursor() *string      { return nextCursor(c.cursor) }
+
+type groupResolver struct {
+	l     *loaders
+	group *models.Group
+}
+
+func (r *groupResolver) Id() common.Hash          { return r.group.GroupID }
+func (r *groupResolver) Name() string             { return r.group.GroupName }
+func (r *groupResolver) Owner() *accountResolver  { return newAccountResolver(r.l, r.group.Owner) }
+func (r *groupResolver) SourceType() string       { return r.group.SourceType }
+func (r *groupResolver) Extra() string            { return r.group.Extra }
+func (r *groupResolver) CreateAt() hexutil.Uint64 { return long(r.group.CreateAt) }
+func (r *groupResolver) UpdateAt() hexutil.Uint64 { return long(r.group.UpdateAt) }
+
+func (r *groupResolver) Members(ctx context.Context, args pageArgs) (*groupMemberConnection, error) {
+	members, cursor, err := r.l.repo.ListGroupMembers(ctx, r.group.GroupID, args.page())
+	if err != nil {
+		return nil, err
+	}
+	connection := &groupMemberConnection{cursor: cursor}
+	for _, member := range members {
+		connection.nodes = append(connection.nodes, &groupMemberResolver{l: r.l, member: member})
+	}
+	return connection, nil
+}
+
+type groupMemberResolver struct {
+	l      *loaders
+	member *models.Group
+}
+
+func (r *groupMemberResolver) Account() *accountResolver {
+	return newAccountResolver(r.l, r.member.AccountID)
+}
+func (r *groupMemberResolver) Operator() common.Address       { return r.member.Operator }
+func (r *groupMemberResolver) ExpirationTime() hexutil.Uint64 { return long(r.member.ExpirationTime) }
+func (r *groupMemberResolver) CreateAt() hexutil.Uint64       { return long(r.member.CreateAt) }
+func (r *groupMemberResolver) UpdateAt() hexutil.Uint64       { return long(r.member.UpdateAt) }
+
+type groupMemberConnection struct {
+	nodes  []*groupMemberResolver
+	cursor string
+}
+
+func (c *groupMemberConnection) Nodes() []*groupMemberResolver { return c.nodes }
+func (c *groupMemberConnection) NextCursor() *string           { return nextCursor(c.cursor) }
+
+type paymentAccountResolver struct {
+	l              *loaders
+	paymentAccount *models.PaymentAccount
+}
+
+func (r *paymentAccountResolver) Address() common.Address { return r.paymentAccount.Addr }
+func (r *paymentAccountResolver) Owner() *accountResolver {
+	return newAccountResolver(r.l, r.paymentAccount.Owner)
+}
+func (r *paymentAccountResolver) Refundable() bool         { return r.paymentAccount.Refundable }
+func (r *paymentAccountResolver) UpdateAt() hexutil.Uint64 { return long(r.paymentAccount.UpdateAt) }
+func (r *paymentAccountResolver) UpdateTime() hexutil.Uint64 {
+	return long(r.paymentAccount.UpdateTime)
+}
+
+type paymentAccountConnection struct {
+	nodes  []*paymentAccountResolver
+	cursor string
+}
+
+func (c *paymentAccountConnection) Nodes() []*paymentAccountResolver { return c.nodes }
+func (c *paymentAccountConnection) NextCursor() *string              { return nextCursor(c.cursor) }
+
+type streamRecordResolver struct {
+	streamRecord *models.StreamRecord
+}
+
+func (r *streamRecordResolver) Account() common.Address { return r.streamRecord.Account }
+func (r *streamRecordResolver) Status() string          { return r.streamRecord.Status }
+func (r *streamRecordResolver) CrudTimestamp() hexutil.Uint64 {
+	return long(r.streamRecord.CrudTimestamp)
+}
+func (r *streamRecordResolver) SettleTimestamp() hexutil.Uint64 {
+	return long(r.streamRecord.SettleTimestamp)
+}
+func (r *streamRecordResolver) NetflowRate() *string   { return decimal(r.streamRecord.NetflowRate) }
+func (r *streamRecordResolver) StaticBalance() *string { return decimal(r.streamRecord.StaticBalance) }
+func (r *streamRecordResolver) BufferBalance() *string { return decimal(r.streamRecord.BufferBalance) }
+func (r *streamRecordResolver) LockBalance() *string   { return decimal(r.streamRecord.LockBalance) }
+func (r *streamRecordResolver) FrozenNetflowRate() *string {
+	return decimal(r.streamRecord.FrozenNetflowRate)
+}
+func (r *streamRecordResolver) OutFlowCount() hexutil.Uint64 {
+	return hexutil.Uint64(r.streamRecord.OutFlowCount)
+}
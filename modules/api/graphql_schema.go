@@ -0,0 +1,184 @@
+package api
+
+// graphqlSchema is the GraphQL schema of the indexed data. The numbers which may not fit in an Int are
+// Long, hex encoded, and the token amounts are decimal strings.
+const graphqlSchema = `
+schema {
+  query: Query
+}
+
+scalar Bytes32
+scalar Address
+scalar Long
+
+type Query {
+  bucket(name: String!): Bucket
+  bucketById(id: Bytes32!): Bucket
+  object(bucketName: String!, name: String!): Object
+  group(id: Bytes32!): Group
+  account(address: Address!): Account!
+  storageProvider(id: Int!): StorageProvider
+  gvg(id: Int!): GlobalVirtualGroup
+}
+
+type Account {
+  address: Address!
+  buckets(first: Int = 20, after: String): BucketConnection!
+  paymentAccounts(first: Int = 20, after: String): PaymentAccountConnection!
+  streamRecord: StreamRecord
+}
+
+type Bucket {
+  id: Bytes32!
+  name: String!
+  owner: Account!
+  paymentAddress: Address!
+  operator: Address!
+  visibility: String!
+  status: String!
+  sourceType: String!
+  chargedReadQuota: Long!
+  globalVirtualGroupFamilyId: Int!
+  createAt: Long!
+  createTxHash: Bytes32!
+  createTime: Long!
+  updateAt: Long!
+  updateTime: Long!
+  objects(prefix: String = "", first: Int = 20, after: String): ObjectConnection!
+  lvgs: [LocalVirtualGroup!]!
+  policies(first: Int = 20, after: String): PolicyConnection!
+}
+
+type BucketConnection {
+  nodes: [Bucket!]!
+  nextCursor: String
+}
+
+type Object {
+  id: Bytes32!
+  bucketName: String!
+  name: String!
+  bucket: Bucket
+  owner: Account!
+  creator: Address!
+  payloadSize: Long!
+  contentType: String!
+  visibility: String!
+  status: String!
+  redundancyType: String!
+  sourceType: String!
+  version: Long!
+  createAt: Long!
+  createTxHash: Bytes32!
+  createTime: Long!
+  updateAt: Long!
+  updateTime: Long!
+  removed: Boolean!
+  lvg: LocalVirtualGroup
+  # the objects which held the name of the object in its bucket, newest first, deleted and archived ones included
+  versions(first: Int = 10): [Object!]!
+}
+
+type ObjectConnection {
+  nodes: [Object!]!
+  nextCursor: String
+}
+
+type LocalVirtualGroup {
+  id: Int!
+  bucketId: Bytes32!
+  storedSize: Long!
+  gvg: GlobalVirtualGroup
+}
+
+type GlobalVirtualGroup {
+  id: Int!
+  familyId: Int!
+  storedSize: Long!
+  virtualPaymentAddress: Address!
+  totalDeposit: String
+  primarySp: StorageProvider
+  secondarySps: [StorageProvider!]!
+}
+
+type StorageProvider {
+  id: Int!
+  operatorAddress: Address!
+  fundingAddress: Address!
+  sealAddress: Address!
+  approvalAddress: Address!
+  gcAddress: Address!
+  status: String!
+  endpoint: String!
+  moniker: String!
+  website: String!
+  details: String!
+  totalDeposit: String
+}
+
+type Policy {
+  id: Bytes32!
+  principalType: Int!
+  principalValue: String!
+  resourceType: String!
+  resourceId: Bytes32!
+  expirationTime: Long!
+  createTimestamp: Long!
+  updateTimestamp: Long!
+}
+
+type PolicyConnection {
+  nodes: [Policy!]!
+  nextCursor: String
+}
+
+type Group {
+  id: Bytes32!
+  name: String!
+  owner: Account!
+  sourceType: String!
+  extra: String!
+  createAt: Long!
+  updateAt: Long!
+  members(first: Int = 20, after: String): GroupMemberConnection!
+}
+
+type GroupMember {
+  account: Account!
+  operator: Address!
+  expirationTime: Long!
+  createAt: Long!
+  updateAt: Long!
+}
+
+type GroupMemberConnection {
+  nodes: [GroupMember!]!
+  nextCursor: String
+}
+
+type PaymentAccount {
+  address: Address!
+  owner: Account!
+  refundable: Boolean!
+  updateAt: Long!
+  updateTime: Long!
+}
+
+type PaymentAccountConnection {
+  nodes: [PaymentAccount!]!
+  nextCursor: String
+}
+
+type StreamRecord {
+  account: Address!
+  status: String!
+  crudTimestamp: Long!
+  settleTimestamp: Long!
+  netflowRate: String
+  staticBalance: String
+  bufferBalance: String
+  lockBalance: String
+  frozenNetflowRate: String
+  outFlowCount: Long!
+}
+`
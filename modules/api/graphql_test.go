@@ -0,0 +1,134 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	databaseconfig "github.com/forbole/juno/v4/database/config"
+	"github.com/forbole/juno/v4/database/sqlclient"
+	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/modules/api"
+)
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func newGraphQLServer(t *testing.T, cfg *api.GraphQLConfig) (*httptest.Server, *database.Impl, *int64) {
+	db, err := sqlclient.New(&databaseconfig.Config{Type: databaseconfig.SQLite, DSN: "file:graphql_" + t.Name() + "?mode=memory&cache=shared"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Bucket{}, &models.Object{}, &models.ObjectArchive{}, &models.LocalVirtualGroup{},
+		&models.GlobalVirtualGroup{}, &models.StorageProvider{}, &models.StreamRecord{}))
+
+	// the queries are counted to check that the lookups are batched
+	var queries int64
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:count", func(*gorm.DB) {
+		atomic.AddInt64(&queries, 1)
+	}))
+
+	server := httptest.NewServer(api.NewHandler(&api.Config{GraphQL: cfg}, &database.Impl{Db: db}))
+	t.Cleanup(server.Close)
+	return server, &database.Impl{Db: db}, &queries
+}
+
+func query(t *testing.T, server *httptest.Server, q string) graphqlResponse {
+	body, err := json.Marshal(map[string]string{"query": q})
+	require.NoError(t, err)
+	res, err := http.Post(server.URL+"/graphql", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var response graphqlResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+	return response
+}
+
+func TestGraphQL_Bucket(t *testing.T) {
+	server, db, queries := newGraphQLServer(t, &api.GraphQLConfig{})
+	ctx := context.Background()
+
+	bucketID := common.HexToHash("0x01")
+	owner := common.HexToAddress("0x0a")
+	require.NoError(t, db.SaveBucket(ctx, &models.Bucket{BucketID: bucketID, BucketName: "bucket", Owner: owner}))
+	for i := byte(1); i <= 3; i++ {
+		require.NoError(t, db.SaveObject(ctx, &models.Object{
+			BucketID: bucketID, BucketName: "bucket", ObjectID: common.BytesToHash([]byte{i}),
+			ObjectName: "object-" + string('0'+rune(i)), LocalVirtualGroupId: uint32(i % 2),
+		}))
+	}
+	require.NoError(t, db.Db.Create([]*models.LocalVirtualGroup{
+		{LocalVirtualGroupId: 0, GlobalVirtualGroupId: 1, BucketID: bucketID},
+		{LocalVirtualGroupId: 1, GlobalVirtualGroupId: 2, BucketID: bucketID},
+	}).Error)
+	require.NoError(t, db.Db.Create([]*models.GlobalVirtualGroup{
+		{GlobalVirtualGroupId: 1, PrimarySpId: 1, SecondarySpIds: common.Uint32Array{2, 3}},
+		{GlobalVirtualGroupId: 2, PrimarySpId: 2, SecondarySpIds: common.Uint32Array{1, 3}},
+	}).Error)
+	require.NoError(t, db.Db.Create([]*models.StorageProvider{
+		{SpId: 1, Moniker: "sp-1"}, {SpId: 2, Moniker: "sp-2"}, {SpId: 3, Moniker: "sp-3"},
+	}).Error)
+	require.NoError(t, db.SaveStreamRecord(ctx, &models.StreamRecord{Account: owner, Status: "STREAM_ACCOUNT_STATUS_ACTIVE"}))
+
+	atomic.StoreInt64(queries, 0)
+	response := query(t, server, `{
+		bucket(name: "bucket") {
+			name
+			owner { streamRecord { status } }
+			objects(first: 2) {
+				nodes { name lvg { id gvg { primarySp { moniker } secondarySps { id } } } }
+				nextCursor
+			}
+		}
+	}`)
+	require.Empty(t, response.Errors)
+	require.JSONEq(t, `{"bucket": {
+		"name": "bucket",
+		"owner": {"streamRecord": {"status": "STREAM_ACCOUNT_STATUS_ACTIVE"}},
+		"objects": {
+			"nodes": [
+				{"name": "object-1", "lvg": {"id": 1, "gvg": {"primarySp": {"moniker": "sp-2"}, "secondarySps": [{"id": 1}, {"id": 3}]}}},
+				{"name": "object-2", "lvg": {"id": 0, "gvg": {"primarySp": {"moniker": "sp-1"}, "secondarySps": [{"id": 2}, {"id": 3}]}}}
+			],
+			"nextCursor": "AAAAAAAAAAI"
+		}
+	}}`, string(response.Data))
+	// one query for the bucket, the stream records, the objects, the lvgs, the gvgs and the sps
+	require.Equal(t, int64(6), atomic.LoadInt64(queries))
+
+	response = query(t, server, `{ object(bucketName: "bucket", name: "object-3") { bucket { name } versions { id } } }`)
+	require.Empty(t, response.Errors)
+	require.JSONEq(t, `{"object": {"bucket": {"name": "bucket"}, "versions": [
+		{"id": "0x0000000000000000000000000000000000000000000000000000000000000003"}
+	]}}`, string(response.Data))
+}
+
+func TestGraphQL_Limits(t *testing.T) {
+	server, _, _ := newGraphQLServer(t, &api.GraphQLConfig{MaxDepth: 4, MaxComplexity: 100})
+
+	response := query(t, server, `{ account(address: "0x000000000000000000000000000000000000000a") { buckets(first: 50) { nodes { name } } } }`)
+	require.Len(t, response.Errors, 1)
+	require.Contains(t, response.Errors[0].Message, "query complexity 151 exceeds the limit of 100")
+
+	response = query(t, server, `{ account(address: "0x000000000000000000000000000000000000000a") {
+		buckets(first: 1) { nodes { owner { address } } } } }`)
+	require.NotEmpty(t, response.Errors)
+	require.Contains(t, response.Errors[0].Message, "exceeds max depth 4")
+
+	response = query(t, server, `{ account(address: "0x000000000000000000000000000000000000000a") { buckets(first: 5) { nodes { name } } } }`)
+	require.Empty(t, response.Errors)
+	require.JSONEq(t, `{"account": {"buckets": {"nodes": []}}}`, string(response.Data))
+}
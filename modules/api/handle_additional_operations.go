@@ -0,0 +1,50 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/forbole/juno/v4/database"
+)
+
+// RunAdditionalOperations runs the module additional operations
+func RunAdditionalOperations(cfg *Config, repo database.Repository) error {
+	err := checkConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	go startServer(cfg, repo)
+
+	return nil
+}
+
+// checkConfig checks if the given config is valid
+func checkConfig(cfg *Config) error {
+	if cfg == nil {
+		return fmt.Errorf("api config is not set but module is enabled")
+	}
+
+	if cfg.Port == 0 {
+		return fmt.Errorf("api is enabled, but no port is set")
+	}
+
+	return nil
+}
+
+// startServer starts the api server using the given configuration
+func startServer(cfg *Config, repo database.Repository) {
+	// the connection is given some time besides the request to write the timeout response
+	server := http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Port),
+		Handler:      NewHandler(cfg, repo),
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: cfg.GetRequestTimeout() + 5*time.Second,
+	}
+
+	err := server.ListenAndServe()
+	if err != nil {
+		panic(err)
+	}
+}
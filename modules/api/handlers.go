@@ -0,0 +1,214 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/models"
+)
+
+// handler serves the api requests from the read-only repository
+type handler struct {
+	repo database.Repository
+}
+
+// parseAddress parses the hex address of the path variable, answering bad request when it is invalid
+func parseAddress(w http.ResponseWriter, r *http.Request, name string) (common.Address, bool) {
+	var address common.Address
+	if err := address.UnmarshalText([]byte(mux.Vars(r)[name])); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid "+name)
+		return address, false
+	}
+	return address, true
+}
+
+// parseHash parses the hex hash of the path variable, answering bad request when it is invalid
+func parseHash(w http.ResponseWriter, r *http.Request, name string) (common.Hash, bool) {
+	var hash common.Hash
+	if err := hash.UnmarshalText([]byte(mux.Vars(r)[name])); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid "+name)
+		return hash, false
+	}
+	return hash, true
+}
+
+// parseUint parses the decimal number of the path variable, answering bad request when it is invalid
+func parseUint(w http.ResponseWriter, r *http.Request, name string, bitSize int) (uint64, bool) {
+	n, err := strconv.ParseUint(mux.Vars(r)[name], 10, bitSize)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid "+name)
+		return 0, false
+	}
+	return n, true
+}
+
+func (h *handler) listBlocks(w http.ResponseWriter, r *http.Request) {
+	serveList(w, r, func(page database.Page) ([]*models.Block, string, error) {
+		return h.repo.ListBlocks(r.Context(), page)
+	})
+}
+
+func (h *handler) getBlock(w http.ResponseWriter, r *http.Request) {
+	height, ok := parseUint(w, r, "height", 64)
+	if !ok {
+		return
+	}
+	serveResource(w, r, func() (*models.Block, error) {
+		return h.repo.GetBlockByHeight(r.Context(), height)
+	})
+}
+
+func (h *handler) listTxs(w http.ResponseWriter, r *http.Request) {
+	height, ok := parseUint(w, r, "height", 64)
+	if !ok {
+		return
+	}
+	serveList(w, r, func(page database.Page) ([]*models.Tx, string, error) {
+		return h.repo.ListTxsByHeight(r.Context(), height, page)
+	})
+}
+
+func (h *handler) getTx(w http.ResponseWriter, r *http.Request) {
+	hash, ok := parseHash(w, r, "hash")
+	if !ok {
+		return
+	}
+	serveResource(w, r, func() (*models.Tx, error) {
+		return h.repo.GetTxByHash(r.Context(), hash)
+	})
+}
+
+func (h *handler) listBuckets(w http.ResponseWriter, r *http.Request) {
+	owner, ok := parseAddress(w, r, "address")
+	if !ok {
+		return
+	}
+	serveList(w, r, func(page database.Page) ([]*models.Bucket, string, error) {
+		return h.repo.ListBucketsByOwner(r.Context(), owner, page)
+	})
+}
+
+func (h *handler) listPaymentAccounts(w http.ResponseWriter, r *http.Request) {
+	owner, ok := parseAddress(w, r, "address")
+	if !ok {
+		return
+	}
+	serveList(w, r, func(page database.Page) ([]*models.PaymentAccount, string, error) {
+		return h.repo.ListPaymentAccountsByOwner(r.Context(), owner, page)
+	})
+}
+
+func (h *handler) getBucket(w http.ResponseWriter, r *http.Request) {
+	serveResource(w, r, func() (*models.Bucket, error) {
+		return h.repo.GetBucketByName(r.Context(), mux.Vars(r)["bucket"])
+	})
+}
+
+func (h *handler) listObjects(w http.ResponseWriter, r *http.Request) {
+	bucketName, prefix := mux.Vars(r)["bucket"], r.URL.Query().Get("prefix")
+	serveList(w, r, func(page database.Page) ([]*models.Object, string, error) {
+		return h.repo.ListObjectsByPrefix(r.Context(), bucketName, prefix, page)
+	})
+}
+
+func (h *handler) getObject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serveResource(w, r, func() (*models.Object, error) {
+		return h.repo.GetObjectByName(r.Context(), vars["bucket"], vars["object"])
+	})
+}
+
+func (h *handler) listLVGs(w http.ResponseWriter, r *http.Request) {
+	bucket, err := h.repo.GetBucketByName(r.Context(), mux.Vars(r)["bucket"])
+	if err != nil {
+		writeRepoError(w, r, err)
+		return
+	}
+	if bucket == nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	serveList(w, r, func(page database.Page) ([]*models.LocalVirtualGroup, string, error) {
+		return h.repo.ListLVGsByBucket(r.Context(), bucket.BucketID, page)
+	})
+}
+
+func (h *handler) getGroup(w http.ResponseWriter, r *http.Request) {
+	groupID, ok := parseHash(w, r, "group")
+	if !ok {
+		return
+	}
+	serveResource(w, r, func() (*models.Group, error) {
+		return h.repo.GetGroup(r.Context(), groupID)
+	})
+}
+
+func (h *handler) listGroupMembers(w http.ResponseWriter, r *http.Request) {
+	groupID, ok := parseHash(w, r, "group")
+	if !ok {
+		return
+	}
+	serveList(w, r, func(page database.Page) ([]*models.Group, string, error) {
+		return h.repo.ListGroupMembers(r.Context(), groupID, page)
+	})
+}
+
+func (h *handler) listStorageProviders(w http.ResponseWriter, r *http.Request) {
+	serveList(w, r, func(page database.Page) ([]*models.StorageProvider, string, error) {
+		return h.repo.ListStorageProviders(r.Context(), page)
+	})
+}
+
+func (h *handler) getStorageProvider(w http.ResponseWriter, r *http.Request) {
+	spID, ok := parseUint(w, r, "sp", 32)
+	if !ok {
+		return
+	}
+	serveResource(w, r, func() (*models.StorageProvider, error) {
+		return h.repo.GetStorageProvider(r.Context(), uint32(spID))
+	})
+}
+
+func (h *handler) getGVG(w http.ResponseWriter, r *http.Request) {
+	gvgID, ok := parseUint(w, r, "gvg", 32)
+	if !ok {
+		return
+	}
+	serveResource(w, r, func() (*models.GlobalVirtualGroup, error) {
+		return h.repo.GetGVG(r.Context(), uint32(gvgID))
+	})
+}
+
+func (h *handler) listGVGs(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := parseUint(w, r, "family", 32)
+	if !ok {
+		return
+	}
+	serveList(w, r, func(page database.Page) ([]*models.GlobalVirtualGroup, string, error) {
+		return h.repo.ListGVGsByFamily(r.Context(), uint32(familyID), page)
+	})
+}
+
+func (h *handler) getPaymentAccount(w http.ResponseWriter, r *http.Request) {
+	addr, ok := parseAddress(w, r, "address")
+	if !ok {
+		return
+	}
+	serveResource(w, r, func() (*models.PaymentAccount, error) {
+		return h.repo.GetPaymentAccount(r.Context(), addr)
+	})
+}
+
+func (h *handler) getStreamRecord(w http.ResponseWriter, r *http.Request) {
+	account, ok := parseAddress(w, r, "address")
+	if !ok {
+		return
+	}
+	serveResource(w, r, func() (*models.StreamRecord, error) {
+		return h.repo.GetStreamRecord(r.Context(), account)
+	})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+// loader batches the lookups of a GraphQL request. The keys of the resources listed together are
+// primed, and are all fetched by a single query on the first lookup of any of them, instead of one
+// query per resource. The fetched values are cached for the rest of the request.
+type loader[K comparable, V any] struct {
+	// fetch returns the values of the keys, the keys without a value are left out
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	primed  []K
+	entries map[K]*loaderEntry[V]
+}
+
+type loaderEntry[V any] struct {
+	batch *loaderBatch
+	value V
+}
+
+// loaderBatch is a single fetch of the values of several keys
+type loaderBatch struct {
+	done chan struct{}
+	err  error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		entries: make(map[K]*loaderEntry[V]),
+	}
+}
+
+// prime registers keys to be fetched along with the next lookup
+func (l *loader[K, V]) prime(keys ...K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if _, ok := l.entries[key]; !ok {
+			l.primed = append(l.primed, key)
+		}
+	}
+}
+
+// load returns the value of key, the zero value when it has none
+func (l *loader[K, V]) load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	entry, ok := l.entries[key]
+	if !ok {
+		entry = l.fetchBatch(ctx, key)
+	} else {
+		l.mu.Unlock()
+	}
+
+	select {
+	case <-entry.batch.done:
+		return entry.value, entry.batch.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// fetchBatch fetches key along with the primed keys, it is called with the lock held and releases it
+func (l *loader[K, V]) fetchBatch(ctx context.Context, key K) *loaderEntry[V] {
+	batch := &loaderBatch{done: make(chan struct{})}
+	keys := make([]K, 0, len(l.primed)+1)
+	entries := make([]*loaderEntry[V], 0, len(l.primed)+1)
+	for _, k := range append(l.primed, key) {
+		if _, ok := l.entries[k]; ok {
+			continue
+		}
+		entry := &loaderEntry[V]{batch: batch}
+		l.entries[k] = entry
+		keys = append(keys, k)
+		entries = append(entries, entry)
+	}
+	l.primed = nil
+	entry := l.entries[key]
+	l.mu.Unlock()
+
+	// the values are set before the batch is done, and only read after
+	values, err := l.fetch(ctx, keys)
+	for i, k := range keys {
+		entries[i].value = values[k]
+	}
+	batch.err = err
+	close(batch.done)
+	return entry
+}
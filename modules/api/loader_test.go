@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]int
+	l := newLoader(func(_ context.Context, keys []int) (map[int]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, keys)
+		values := make(map[int]string)
+		for _, key := range keys {
+			if key%2 == 1 {
+				values[key] = string(rune('a' + key))
+			}
+		}
+		return values, nil
+	})
+
+	// the primed keys are fetched at once, by concurrent lookups
+	ctx := context.Background()
+	l.prime(1, 2, 3, 1)
+	var wg sync.WaitGroup
+	for _, key := range []int{1, 2, 3} {
+		wg.Add(1)
+		go func(key int) {
+			defer wg.Done()
+			_, err := l.load(ctx, key)
+			require.NoError(t, err)
+		}(key)
+	}
+	wg.Wait()
+	require.Equal(t, [][]int{{1, 2, 3}}, batches)
+
+	value, err := l.load(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, "d", value)
+	value, err = l.load(ctx, 2)
+	require.NoError(t, err)
+	require.Empty(t, value)
+
+	// the keys fetched already are not fetched again
+	l.prime(3, 5)
+	value, err = l.load(ctx, 7)
+	require.NoError(t, err)
+	require.Equal(t, "h", value)
+	require.Equal(t, [][]int{{1, 2, 3}, {5, 7}}, batches)
+
+	failing := newLoader(func(context.Context, []int) (map[int]string, error) {
+		return nil, errors.New("failed")
+	})
+	_, err = failing.load(ctx, 1)
+	require.Error(t, err)
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/log"
+)
+
+// NewHandler returns the handler of the api, serving the resources read from repo
+func NewHandler(cfg *Config, repo database.Repository) http.Handler {
+	h := &handler{repo: repo}
+
+	router := mux.NewRouter()
+	// the object names are taken as they are, without cleaning their path
+	router.SkipClean(true)
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not found")
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	})
+
+	v1 := router.PathPrefix("/v1").Methods(http.MethodGet, http.MethodHead).Subrouter()
+	v1.HandleFunc("/blocks", h.listBlocks)
+	v1.HandleFunc("/blocks/{height:[0-9]+}", h.getBlock)
+	v1.HandleFunc("/blocks/{height:[0-9]+}/txs", h.listTxs)
+	v1.HandleFunc("/txs/{hash}", h.getTx)
+
+	v1.HandleFunc("/accounts/{address}/buckets", h.listBuckets)
+	v1.HandleFunc("/accounts/{address}/payment-accounts", h.listPaymentAccounts)
+	v1.HandleFunc("/buckets/{bucket}", h.getBucket)
+	v1.HandleFunc("/buckets/{bucket}/objects", h.listObjects)
+	v1.HandleFunc("/buckets/{bucket}/objects/{object:.+}", h.getObject)
+	v1.HandleFunc("/buckets/{bucket}/lvgs", h.listLVGs)
+
+	v1.HandleFunc("/groups/{group}", h.getGroup)
+	v1.HandleFunc("/groups/{group}/members", h.listGroupMembers)
+
+	v1.HandleFunc("/sps", h.listStorageProviders)
+	v1.HandleFunc("/sps/{sp:[0-9]+}", h.getStorageProvider)
+	v1.HandleFunc("/gvgs/{gvg:[0-9]+}", h.getGVG)
+	v1.HandleFunc("/families/{family:[0-9]+}/gvgs", h.listGVGs)
+
+	v1.HandleFunc("/payment-accounts/{address}", h.getPaymentAccount)
+	v1.HandleFunc("/stream-records/{address}", h.getStreamRecord)
+
+	if cfg.GraphQL != nil {
+		router.Handle("/graphql", newGraphQLHandler(cfg.GraphQL, repo)).Methods(http.MethodPost)
+	}
+
+	timeout := http.TimeoutHandler(withSnapshot(repo, router), cfg.GetRequestTimeout(), `{"error":"request timed out"}`)
+	return withCORS(cfg.CORS, timeout)
+}
+
+// withSnapshot serves each request from a read-only snapshot of the index: the database refuses the writes
+// of the request, and its reads observe the same state
+func withSnapshot(repo database.Repository, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served := false
+		err := repo.Snapshot(r.Context(), func(ctx context.Context) error {
+			served = true
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return nil
+		})
+		if err == nil {
+			return
+		}
+		if !served {
+			writeRepoError(w, r, err)
+			return
+		}
+		// the response is already written
+		log.Debugw("failed to end the api request snapshot", "module", ModuleName, "path", r.URL.Path, "err", err)
+	})
+}
+
+// withCORS answers the preflight requests and allows the cross-origin requests following cfg.
+// The cross-origin requests are not allowed when cfg is nil.
+func withCORS(cfg *CORSConfig, next http.Handler) http.Handler {
+	if cfg == nil || len(cfg.AllowedOrigins) == 0 {
+		return next
+	}
+
+	allowAll := false
+	origins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		allowAll = allowAll || origin == "*"
+		origins[origin] = true
+	}
+	maxAge := strconv.Itoa(int(cfg.GetMaxAge().Seconds()))
+	// the GraphQL requests are sent as json
+	allowedHeaders := strings.Join(append([]string{"Content-Type"}, cfg.AllowedHeaders...), ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !allowAll && !origins[origin] {
+			if preflight {
+				writeError(w, http.StatusForbidden, "origin not allowed")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if allowAll {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if !preflight {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+		w.Header().Set("Access-Control-Max-Age", maxAge)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// listResponse is the body of the responses serving a page of a list
+type listResponse struct {
+	Items interface{} `json:"items"`
+	// NextCursor is the cursor of the next page, empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// errorResponse is the body of the error responses
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Debugw("failed to write api response", "module", ModuleName, "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+// writeRepoError answers with the error returned by the repository
+func writeRepoError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, database.ErrInvalidCursor) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// the request timed out or was canceled, its response is not read anymore
+	if r.Context().Err() != nil {
+		return
+	}
+
+	log.Errorw("failed to serve api request", "module", ModuleName, "path", r.URL.Path, "err", err)
+	writeError(w, http.StatusInternalServerError, "internal error")
+}
+
+// serveList answers with the page of the list requested by r
+func serveList[T any](w http.ResponseWriter, r *http.Request, list func(database.Page) ([]*T, string, error)) {
+	page := database.Page{Cursor: r.URL.Query().Get("cursor")}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		var err error
+		if page.Limit, err = strconv.Atoi(limit); err != nil || page.Limit < 0 {
+			writeError(w, http.StatusBadRequest, "invalid page limit")
+			return
+		}
+	}
+
+	items, nextCursor, err := list(page)
+	if err != nil {
+		writeRepoError(w, r, err)
+		return
+	}
+	if items == nil {
+		items = []*T{}
+	}
+	writeJSON(w, http.StatusOK, listResponse{Items: items, NextCursor: nextCursor})
+}
+
+// serveResource answers with the resource returned by get, not found when it is nil
+func serveResource[T any](w http.ResponseWriter, r *http.Request, get func() (*T, error)) {
+	resource, err := get()
+	if err != nil {
+		writeRepoError(w, r, err)
+		return
+	}
+	if resource == nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, resource)
+}
@@ -0,0 +1,164 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	databaseconfig "github.com/forbole/juno/v4/database/config"
+	"github.com/forbole/juno/v4/database/sqlclient"
+	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/modules/api"
+)
+
+func newServer(t *testing.T, cfg *api.Config) (*httptest.Server, *database.Impl) {
+	db, err := sqlclient.New(&databaseconfig.Config{Type: databaseconfig.SQLite, DSN: "file:api_" + t.Name() + "?mode=memory&cache=shared"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Bucket{}, &models.Object{}, &models.StorageProvider{}))
+	impl := &database.Impl{Db: db}
+
+	server := httptest.NewServer(api.NewHandler(cfg, impl))
+	t.Cleanup(server.Close)
+	return server, impl
+}
+
+// get requests the path and decodes the json response into v
+func get(t *testing.T, server *httptest.Server, path string, v interface{}) int {
+	res, err := http.Get(server.URL + path)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	if v != nil {
+		require.NoError(t, json.NewDecoder(res.Body).Decode(v))
+	}
+	return res.StatusCode
+}
+
+type objectsPage struct {
+	Items      []*models.Object `json:"items"`
+	NextCursor string           `json:"next_cursor"`
+}
+
+func TestServer_Objects(t *testing.T) {
+	server, db := newServer(t, &api.Config{})
+	ctx := context.Background()
+
+	require.NoError(t, db.SaveBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x01"), BucketName: "bucket"}))
+	for i, name := range []string{"photos/a.png", "photos/b.png", "docs/c.txt"} {
+		require.NoError(t, db.SaveObject(ctx, &models.Object{
+			BucketName: "bucket", ObjectName: name, ObjectID: common.BytesToHash([]byte{byte(i + 1)}),
+		}))
+	}
+
+	var page objectsPage
+	require.Equal(t, http.StatusOK, get(t, server, "/v1/buckets/bucket/objects?prefix=photos/&limit=1", &page))
+	require.Len(t, page.Items, 1)
+	require.Equal(t, "photos/a.png", page.Items[0].ObjectName)
+	require.NotEmpty(t, page.NextCursor)
+
+	cursor := page.NextCursor
+	page = objectsPage{}
+	require.Equal(t, http.StatusOK, get(t, server, "/v1/buckets/bucket/objects?prefix=photos/&cursor="+cursor, &page))
+	require.Len(t, page.Items, 1)
+	require.Equal(t, "photos/b.png", page.Items[0].ObjectName)
+	require.Empty(t, page.NextCursor)
+
+	// the object names may hold slashes
+	var object models.Object
+	require.Equal(t, http.StatusOK, get(t, server, "/v1/buckets/bucket/objects/docs/c.txt", &object))
+	require.Equal(t, "docs/c.txt", object.ObjectName)
+	require.Equal(t, http.StatusNotFound, get(t, server, "/v1/buckets/bucket/objects/docs/d.txt", nil))
+
+	res, err := http.Head(server.URL + "/v1/buckets/bucket/objects/docs/c.txt")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	// empty lists are served as such, not as null
+	var empty map[string]json.RawMessage
+	require.Equal(t, http.StatusOK, get(t, server, "/v1/buckets/none/objects", &empty))
+	require.JSONEq(t, `[]`, string(empty["items"]))
+
+	require.Equal(t, http.StatusBadRequest, get(t, server, "/v1/buckets/bucket/objects?cursor=invalid", nil))
+	require.Equal(t, http.StatusBadRequest, get(t, server, "/v1/buckets/bucket/objects?limit=-1", nil))
+	require.Equal(t, http.StatusBadRequest, get(t, server, "/v1/accounts/0x01/buckets", nil))
+	require.Equal(t, http.StatusNotFound, get(t, server, "/v1/unknown", nil))
+}
+
+func TestServer_StorageProviders(t *testing.T) {
+	server, db := newServer(t, &api.Config{})
+	require.NoError(t, db.Db.Create(&models.StorageProvider{SpId: 1, Moniker: "sp-1"}).Error)
+
+	var sp models.StorageProvider
+	require.Equal(t, http.StatusOK, get(t, server, "/v1/sps/1", &sp))
+	require.Equal(t, "sp-1", sp.Moniker)
+	require.Equal(t, http.StatusNotFound, get(t, server, "/v1/sps/2", nil))
+}
+
+func TestServer_CORS(t *testing.T) {
+	server, _ := newServer(t, &api.Config{CORS: &api.CORSConfig{
+		AllowedOrigins: []string{"https://example.com"},
+		AllowedHeaders: []string{"Authorization"},
+	}})
+
+	preflight := func(origin string) *http.Response {
+		req, err := http.NewRequest(http.MethodOptions, server.URL+"/v1/sps", nil)
+		require.NoError(t, err)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		return res
+	}
+
+	res := preflight("https://example.com")
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+	require.Equal(t, "https://example.com", res.Header.Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "Content-Type, Authorization", res.Header.Get("Access-Control-Allow-Headers"))
+	require.Equal(t, "600", res.Header.Get("Access-Control-Max-Age"))
+
+	res = preflight("https://other.com")
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+	require.Empty(t, res.Header.Get("Access-Control-Allow-Origin"))
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/v1/sps", nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "https://example.com")
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "https://example.com", res.Header.Get("Access-Control-Allow-Origin"))
+}
+
+// slowRepository blocks listing the blocks until the request is canceled
+type slowRepository struct {
+	database.Repository
+}
+
+func (slowRepository) Snapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (slowRepository) ListBlocks(ctx context.Context, _ database.Page) ([]*models.Block, string, error) {
+	<-ctx.Done()
+	return nil, "", ctx.Err()
+}
+
+func TestServer_Timeout(t *testing.T) {
+	server := httptest.NewServer(api.NewHandler(&api.Config{RequestTimeout: 50 * time.Millisecond}, slowRepository{}))
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/v1/blocks")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+}
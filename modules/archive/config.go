@@ -0,0 +1,55 @@
+package archive
+
+import (
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultSchedule is the interval between two archival runs when not configured
+	defaultSchedule = time.Hour
+	// defaultBatchSize is the maximum number of rows moved by a single transaction when not configured
+	defaultBatchSize = 1000
+)
+
+// TableConfig tells after how long the removed rows of a table are archived
+type TableConfig struct {
+	Table string `yaml:"table"`
+	// AfterDays is the number of days after their removal the rows are archived
+	AfterDays uint64 `yaml:"after_days"`
+}
+
+type Config struct {
+	// Schedule is the interval between two archival runs
+	Schedule time.Duration `yaml:"schedule"`
+	// BatchSize is the maximum number of rows moved by a single transaction
+	BatchSize int `yaml:"batch_size"`
+	// Tables lists the archived tables
+	Tables []TableConfig `yaml:"tables"`
+}
+
+func ParseConfig(bz []byte) (*Config, error) {
+	type T struct {
+		Config *Config `yaml:"archive"`
+	}
+	var cfg T
+	err := yaml.Unmarshal(bz, &cfg)
+	return cfg.Config, err
+}
+
+// GetSchedule returns the interval between two archival runs
+func (cfg *Config) GetSchedule() time.Duration {
+	if cfg.Schedule <= 0 {
+		return defaultSchedule
+	}
+	return cfg.Schedule
+}
+
+// GetBatchSize returns the maximum number of rows moved by a single transaction
+func (cfg *Config) GetBatchSize() int {
+	if cfg.BatchSize <= 0 {
+		return defaultBatchSize
+	}
+	return cfg.BatchSize
+}
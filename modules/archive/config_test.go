@@ -0,0 +1,44 @@
+package archive_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/forbole/juno/v4/modules/archive"
+)
+
+func TestParseConfig(t *testing.T) {
+	data := []byte(`
+archive:
+  schedule: 30m
+  batch_size: 200
+  tables:
+    - table: objects
+      after_days: 7
+    - table: groups
+      after_days: 30
+`)
+
+	cfg, err := archive.ParseConfig(data)
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	require.Equal(t, 30*time.Minute, cfg.GetSchedule())
+	require.Equal(t, 200, cfg.GetBatchSize())
+	require.Equal(t, []archive.TableConfig{
+		{Table: "objects", AfterDays: 7},
+		{Table: "groups", AfterDays: 30},
+	}, cfg.Tables)
+	require.NoError(t, archive.RunAdditionalOperations(cfg))
+
+	cfg, err = archive.ParseConfig([]byte(`invalid_field: yes`))
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+	require.Error(t, archive.RunAdditionalOperations(cfg))
+
+	require.Error(t, archive.RunAdditionalOperations(&archive.Config{}))
+	require.Error(t, archive.RunAdditionalOperations(&archive.Config{
+		Tables: []archive.TableConfig{{Table: "txs", AfterDays: 1}},
+	}))
+}
@@ -0,0 +1,30 @@
+package archive
+
+import (
+	"fmt"
+
+	"github.com/forbole/juno/v4/database"
+)
+
+// RunAdditionalOperations runs the additional operations for the archive module
+func RunAdditionalOperations(cfg *Config) error {
+	return checkConfig(cfg)
+}
+
+// checkConfig checks if the given config is valid
+func checkConfig(cfg *Config) error {
+	if cfg == nil {
+		return fmt.Errorf("archive config is not set but module is enabled")
+	}
+
+	if len(cfg.Tables) == 0 {
+		return fmt.Errorf("archive is enabled, but no table is set")
+	}
+	for _, table := range cfg.Tables {
+		if err := database.ValidateArchiveTable(table.Table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
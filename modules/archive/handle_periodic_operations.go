@@ -0,0 +1,58 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-co-op/gocron"
+
+	"github.com/forbole/juno/v4/log"
+)
+
+// secondsPerDay is the number of seconds of an archival day
+const secondsPerDay = 24 * 60 * 60
+
+// RegisterPeriodicOperations implements modules.PeriodicOperationsModule
+func (m *Module) RegisterPeriodicOperations(scheduler *gocron.Scheduler) error {
+	log.Debugw("setting up periodic tasks", "module", m.Name())
+
+	if _, err := scheduler.Every(m.cfg.GetSchedule()).SingletonMode().Do(func() {
+		if err := m.Archive(context.Background(), time.Now()); err != nil {
+			log.Errorw("failed to archive the removed rows", "module", m.Name(), "err", err)
+		}
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Archive moves the rows removed for longer than configured to the archive tables, in batches
+func (m *Module) Archive(ctx context.Context, now time.Time) error {
+	batchSize := m.cfg.GetBatchSize()
+	for _, table := range m.cfg.Tables {
+		before := now.Unix() - int64(table.AfterDays)*secondsPerDay
+
+		var total int64
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			moved, err := m.db.ArchiveRemoved(ctx, table.Table, before, batchSize)
+			if err != nil {
+				return fmt.Errorf("error while archiving table %s: %s", table.Table, err)
+			}
+			total += moved
+			log.ArchiveMovedCount.WithLabelValues(table.Table).Add(float64(moved))
+
+			if moved < int64(batchSize) {
+				break
+			}
+		}
+		log.Infow("archived table", "module", m.Name(), "table", table.Table, "moved", total)
+	}
+
+	log.ArchiveLastRun.Set(float64(now.Unix()))
+	return nil
+}
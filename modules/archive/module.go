@@ -0,0 +1,67 @@
+package archive
+
+import (
+	"context"
+
+	"gorm.io/gorm/schema"
+
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/modules"
+	"github.com/forbole/juno/v4/types/config"
+)
+
+var (
+	_ modules.Module                     = &Module{}
+	_ modules.PrepareTablesModule        = &Module{}
+	_ modules.PeriodicOperationsModule   = &Module{}
+	_ modules.AdditionalOperationsModule = &Module{}
+)
+
+// Module represents the archive module, moving the removed buckets, objects and groups out of their tables periodically
+type Module struct {
+	cfg *Config
+	db  database.Database
+}
+
+// NewModule builds a new Module instance
+func NewModule(cfg config.Config, db database.Database) *Module {
+	bz, err := cfg.GetBytes()
+	if err != nil {
+		panic(err)
+	}
+
+	archiveCfg, err := ParseConfig(bz)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Module{
+		cfg: archiveCfg,
+		db:  db,
+	}
+}
+
+// Name implements modules.Module
+func (m *Module) Name() string {
+	return "archive"
+}
+
+// PrepareTables implements modules.PrepareTablesModule
+func (m *Module) PrepareTables() error {
+	return m.db.PrepareArchiveTables(context.TODO())
+}
+
+// AutoMigrate implements modules.PrepareTablesModule
+func (m *Module) AutoMigrate() error {
+	return m.db.AutoMigrate(context.TODO(), []schema.Tabler{
+		&models.BucketArchive{},
+		&models.ObjectArchive{},
+		&models.GroupArchive{},
+	})
+}
+
+// RunAdditionalOperations implements modules.AdditionalOperationsModule
+func (m *Module) RunAdditionalOperations() error {
+	return RunAdditionalOperations(m.cfg)
+}
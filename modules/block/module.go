@@ -43,6 +43,8 @@ func (m *Module) PrepareTables() error {
 		&models.AverageBlockTimePerMinute{},
 
 		&models.Epoch{},
+		&models.SyncRange{},
+		&models.Outbox{},
 
 		&models.Tx{},
 	})
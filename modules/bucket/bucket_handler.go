@@ -25,6 +25,10 @@ var (
 	EventCompleteMigrationBucket = proto.MessageName(&storagetypes.EventCompleteMigrationBucket{})
 	EventCancelMigrationBucket   = proto.MessageName(&storagetypes.EventCancelMigrationBucket{})
 	EventRejectMigrateBucket     = proto.MessageName(&storagetypes.EventRejectMigrateBucket{})
+
+	EventSetBucketFlowRateLimit    = proto.MessageName(&storagetypes.EventSetBucketFlowRateLimit{})
+	EventBucketFlowRateLimitStatus = proto.MessageName(&storagetypes.EventBucketFlowRateLimitStatus{})
+	EventToggleSPAsDelegatedAgent  = proto.MessageName(&storagetypes.EventToggleSPAsDelegatedAgent{})
 )
 
 var BucketEvents = map[string]bool{
@@ -36,6 +40,10 @@ var BucketEvents = map[string]bool{
 	EventCompleteMigrationBucket: true,
 	EventCancelMigrationBucket:   true,
 	EventRejectMigrateBucket:     true,
+
+	EventSetBucketFlowRateLimit:    true,
+	EventBucketFlowRateLimitStatus: true,
+	EventToggleSPAsDelegatedAgent:  true,
 }
 
 func (m *Module) ExtractEventStatements(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, event sdk.Event) (map[string][]interface{}, error) {
@@ -110,6 +118,27 @@ func (m *Module) HandleEvent(ctx context.Context, block *tmctypes.ResultBlock, t
 			return errors.New("reject migrate bucket event assert error")
 		}
 		return m.handleRejectMigrateBucket(ctx, block, txHash, rejectMigrateBucket)
+	case EventSetBucketFlowRateLimit:
+		setFlowRateLimit, ok := typedEvent.(*storagetypes.EventSetBucketFlowRateLimit)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventSetBucketFlowRateLimit", "event", typedEvent)
+			return errors.New("set bucket flow rate limit event assert error")
+		}
+		return m.handleSetBucketFlowRateLimit(ctx, block, txHash, setFlowRateLimit)
+	case EventBucketFlowRateLimitStatus:
+		flowRateLimitStatus, ok := typedEvent.(*storagetypes.EventBucketFlowRateLimitStatus)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventBucketFlowRateLimitStatus", "event", typedEvent)
+			return errors.New("bucket flow rate limit status event assert error")
+		}
+		return m.handleBucketFlowRateLimitStatus(ctx, block, txHash, flowRateLimitStatus)
+	case EventToggleSPAsDelegatedAgent:
+		toggleSPAsDelegatedAgent, ok := typedEvent.(*storagetypes.EventToggleSPAsDelegatedAgent)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventToggleSPAsDelegatedAgent", "event", typedEvent)
+			return errors.New("toggle sp as delegated agent event assert error")
+		}
+		return m.handleToggleSPAsDelegatedAgent(ctx, block, txHash, toggleSPAsDelegatedAgent)
 	}
 
 	return nil
@@ -137,6 +166,13 @@ func (m *Module) handleCreateBucket(ctx context.Context, block *tmctypes.ResultB
 		UpdateTime:   block.Block.Time.UTC().Unix(),
 	}
 
+	// a flow rate limit may be set before the bucket is created
+	flowRateLimit, err := m.db.GetBucketFlowRateLimit(ctx, bucket.BucketName, bucket.PaymentAddress, bucket.Owner)
+	if err != nil {
+		return err
+	}
+	bucket.FlowRateLimit = flowRateLimit
+
 	return m.db.SaveBucket(ctx, bucket)
 }
 
@@ -275,3 +311,110 @@ func (m *Module) handleRejectMigrateBucket(ctx context.Context, block *tmctypes.
 
 	return m.db.UpdateBucket(ctx, bucket)
 }
+
+// handleSetBucketFlowRateLimit records the limit for the bucket name, payment address and owner of the event.
+// Like on the chain, the limit only applies to the bucket when it exists and uses that payment address and owner,
+// otherwise it is kept for the bucket created later with them.
+func (m *Module) handleSetBucketFlowRateLimit(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, setFlowRateLimit *storagetypes.EventSetBucketFlowRateLimit) error {
+	paymentAddress := common.HexToAddress(setFlowRateLimit.PaymentAddress)
+	owner := common.HexToAddress(setFlowRateLimit.BucketOwner)
+	flowRateLimit := (*common.Big)(setFlowRateLimit.FlowRateLimit.BigInt())
+	history := &models.BucketSettingHistory{
+		BucketName:     setFlowRateLimit.BucketName,
+		Setting:        models.BucketSettingFlowRateLimit,
+		Operator:       common.HexToAddress(setFlowRateLimit.Operator),
+		PaymentAddress: paymentAddress,
+		BucketOwner:    owner,
+		FlowRateLimit:  flowRateLimit,
+	}
+
+	// the event only carries the bucket name
+	existing, err := m.db.GetBucketByName(ctx, setFlowRateLimit.BucketName)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.PaymentAddress != paymentAddress || existing.Owner != owner {
+		log.Warnw("flow rate limit set for a bucket not indexed with its payment address and owner, it is kept for later",
+			"bucket_name", setFlowRateLimit.BucketName, "payment_address", paymentAddress, "owner", owner)
+		return m.saveBucketSetting(ctx, block, txHash, nil, history)
+	}
+
+	history.BucketID = existing.BucketID
+	bucket := &models.Bucket{
+		BucketID:      existing.BucketID,
+		FlowRateLimit: flowRateLimit,
+
+		UpdateAt:     block.Block.Height,
+		UpdateTxHash: txHash,
+		UpdateTime:   block.Block.Time.UTC().Unix(),
+	}
+	return m.saveBucketSetting(ctx, block, txHash, bucket, history)
+}
+
+func (m *Module) handleBucketFlowRateLimitStatus(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, flowRateLimitStatus *storagetypes.EventBucketFlowRateLimitStatus) error {
+	bucketID := common.BigToHash(flowRateLimitStatus.BucketId.BigInt())
+	bucket := &models.Bucket{
+		BucketID:        bucketID,
+		FlowRateLimited: flowRateLimitStatus.IsLimited,
+
+		UpdateAt:     block.Block.Height,
+		UpdateTxHash: txHash,
+		UpdateTime:   block.Block.Time.UTC().Unix(),
+	}
+	history := &models.BucketSettingHistory{
+		BucketID:        bucketID,
+		BucketName:      flowRateLimitStatus.BucketName,
+		Setting:         models.BucketSettingFlowRateLimitStatus,
+		FlowRateLimited: flowRateLimitStatus.IsLimited,
+	}
+
+	return m.saveBucketSetting(ctx, block, txHash, bucket, history)
+}
+
+func (m *Module) handleToggleSPAsDelegatedAgent(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, toggleSPAsDelegatedAgent *storagetypes.EventToggleSPAsDelegatedAgent) error {
+	bucketID := common.BigToHash(toggleSPAsDelegatedAgent.BucketId.BigInt())
+	bucket := &models.Bucket{
+		BucketID:                   bucketID,
+		SpAsDelegatedAgentDisabled: toggleSPAsDelegatedAgent.SpAsDelegatedAgentDisabled,
+
+		UpdateAt:     block.Block.Height,
+		UpdateTxHash: txHash,
+		UpdateTime:   block.Block.Time.UTC().Unix(),
+	}
+	history := &models.BucketSettingHistory{
+		BucketID:                   bucketID,
+		BucketName:                 toggleSPAsDelegatedAgent.BucketName,
+		Setting:                    models.BucketSettingSpAsDelegatedAgent,
+		SpAsDelegatedAgentDisabled: toggleSPAsDelegatedAgent.SpAsDelegatedAgentDisabled,
+	}
+
+	return m.saveBucketSetting(ctx, block, txHash, bucket, history)
+}
+
+// saveBucketSetting updates the setting of the bucket, unless it is nil, and records the change in the same transaction
+func (m *Module) saveBucketSetting(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, bucket *models.Bucket, history *models.BucketSettingHistory) error {
+	history.Height = block.Block.Height
+	history.TxHash = txHash
+	history.Timestamp = block.Block.Time.UTC().Unix()
+
+	tx := m.db.Begin(ctx)
+	var err error
+	switch {
+	case bucket == nil:
+	case history.Setting == models.BucketSettingFlowRateLimit:
+		err = tx.UpdateBucketFlowRateLimit(ctx, bucket)
+	case history.Setting == models.BucketSettingFlowRateLimitStatus:
+		err = tx.UpdateBucketFlowRateLimitStatus(ctx, bucket)
+	case history.Setting == models.BucketSettingSpAsDelegatedAgent:
+		err = tx.UpdateBucketSpAsDelegatedAgent(ctx, bucket)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.SaveBucketSettingHistory(ctx, history); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
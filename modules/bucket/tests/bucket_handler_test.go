@@ -5,12 +5,19 @@ import (
 	"testing"
 	"time"
 
+	"cosmossdk.io/math"
+	tmctypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/gogoproto/proto"
+	storagetypes "github.com/evmos/evmos/v12/x/storage/types"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	"github.com/forbole/juno/v4/database"
 	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/modules/bucket"
 
 	// Mock dependencies
 	"github.com/forbole/juno/v4/common"
@@ -28,8 +35,8 @@ func NewMockDB() (*MockDBImpl, error) {
 		return nil, err
 	}
 
-	// Auto-migrate the Bucket model
-	err = db.AutoMigrate(&models.Bucket{})
+	// Auto-migrate the Bucket models
+	err = db.AutoMigrate(&models.Bucket{}, &models.BucketSettingHistory{})
 	if err != nil {
 		return nil, err
 	}
@@ -58,6 +65,11 @@ func (s *BucketHandlerTestSuite) SetupTest() {
 	s.ctx = context.Background()
 }
 
+// TearDownTest drops the tables, the in-memory database being shared by the tests
+func (s *BucketHandlerTestSuite) TearDownTest() {
+	s.Require().NoError(s.db.Impl.Db.Migrator().DropTable(&models.Bucket{}, &models.BucketSettingHistory{}))
+}
+
 // TestMigrationComplete_ClearsFields verifies that completing migration clears start time and dest SP
 func (s *BucketHandlerTestSuite) TestMigrationComplete_ClearsFields() {
 	// 1. Setup: Create a bucket in MIGRATING state with migration fields set
@@ -204,3 +216,114 @@ func (s *BucketHandlerTestSuite) TestCompleteMigrationFlow() {
 	s.Nil(finalBucket.MigrationStartTime, "Start time cleared")
 	s.Equal("", finalBucket.DestPrimarySPID, "Dest SP cleared")
 }
+
+func (s *BucketHandlerTestSuite) handle(height int64, txHash common.Hash, event proto.Message) {
+	sdkEvent, err := sdk.TypedEventToEvent(event)
+	s.Require().NoError(err)
+
+	block := &tmctypes.ResultBlock{Block: &tmtypes.Block{Header: tmtypes.Header{Height: height, Time: time.Unix(1000+height, 0)}}}
+	s.Require().NoError(bucket.NewModule(s.db).HandleEvent(s.ctx, block, txHash, sdkEvent))
+}
+
+// TestFlowRateLimit_RecordsSettingsAndHistory verifies that the flow rate limit, the limit status and the
+// delegated agent flag are stored on the bucket, including when they are reset to zero values
+func (s *BucketHandlerTestSuite) TestFlowRateLimit_RecordsSettingsAndHistory() {
+	bucketID := common.HexToHash("0x9abc")
+	s.Require().NoError(s.db.SaveBucket(s.ctx, &models.Bucket{
+		BucketID:       bucketID,
+		BucketName:     "limited-bucket",
+		Owner:          common.HexToAddress("0x03"),
+		PaymentAddress: common.HexToAddress("0x02"),
+	}))
+
+	s.handle(10, common.HexToHash("0x01"), &storagetypes.EventSetBucketFlowRateLimit{
+		Operator:       "0x0000000000000000000000000000000000000001",
+		BucketName:     "limited-bucket",
+		PaymentAddress: "0x0000000000000000000000000000000000000002",
+		BucketOwner:    "0x0000000000000000000000000000000000000003",
+		FlowRateLimit:  math.NewInt(500),
+	})
+	s.handle(20, common.HexToHash("0x02"), &storagetypes.EventBucketFlowRateLimitStatus{
+		BucketName: "limited-bucket",
+		BucketId:   math.NewUintFromBigInt(bucketID.Big()),
+		IsLimited:  true,
+	})
+	s.handle(30, common.HexToHash("0x03"), &storagetypes.EventToggleSPAsDelegatedAgent{
+		BucketName:                 "limited-bucket",
+		BucketId:                   math.NewUintFromBigInt(bucketID.Big()),
+		SpAsDelegatedAgentDisabled: true,
+	})
+
+	stored, err := s.db.GetBucket(s.ctx, bucketID)
+	s.Require().NoError(err)
+	s.Equal(int64(500), stored.FlowRateLimit.Raw().Int64())
+	s.True(stored.FlowRateLimited)
+	s.True(stored.SpAsDelegatedAgentDisabled)
+	s.Equal(int64(30), stored.UpdateAt)
+
+	// lifting the limit writes the zero value back, it is recorded once when the block is processed again
+	for i := 0; i < 2; i++ {
+		s.handle(40, common.HexToHash("0x04"), &storagetypes.EventBucketFlowRateLimitStatus{
+			BucketName: "limited-bucket",
+			BucketId:   math.NewUintFromBigInt(bucketID.Big()),
+			IsLimited:  false,
+		})
+	}
+	stored, err = s.db.GetBucket(s.ctx, bucketID)
+	s.Require().NoError(err)
+	s.False(stored.FlowRateLimited)
+
+	histories, err := s.db.GetBucketSettingHistory(s.ctx, bucketID)
+	s.Require().NoError(err)
+	s.Require().Len(histories, 4)
+	s.Equal(models.BucketSettingFlowRateLimit, histories[0].Setting)
+	s.Equal(common.HexToAddress("0x02"), histories[0].PaymentAddress)
+	s.Equal(common.HexToAddress("0x03"), histories[0].BucketOwner)
+	s.Equal(models.BucketSettingFlowRateLimitStatus, histories[1].Setting)
+	s.True(histories[1].FlowRateLimited)
+	s.Equal(models.BucketSettingSpAsDelegatedAgent, histories[2].Setting)
+	s.False(histories[3].FlowRateLimited)
+	s.Equal(common.HexToHash("0x04"), histories[3].TxHash)
+}
+
+// TestFlowRateLimit_BucketNotIndexed verifies that a limit set before the bucket exists, or for another payment
+// address, does not fail the block, and that it applies to the bucket created later with its payment address and owner
+func (s *BucketHandlerTestSuite) TestFlowRateLimit_BucketNotIndexed() {
+	setLimit := func(height int64, paymentAddress string, limit int64) {
+		s.handle(height, common.HexToHash("0x01"), &storagetypes.EventSetBucketFlowRateLimit{
+			Operator:       paymentAddress,
+			BucketName:     "future-bucket",
+			PaymentAddress: paymentAddress,
+			BucketOwner:    "0x0000000000000000000000000000000000000003",
+			FlowRateLimit:  math.NewInt(limit),
+		})
+	}
+	setLimit(10, "0x0000000000000000000000000000000000000002", 100)
+	setLimit(11, "0x0000000000000000000000000000000000000002", 200)
+	setLimit(12, "0x0000000000000000000000000000000000000004", 300)
+
+	var pending []*models.BucketSettingHistory
+	s.Require().NoError(s.db.Db.Where("bucket_name = ?", "future-bucket").Order("id").Find(&pending).Error)
+	s.Require().Len(pending, 3)
+	s.Equal(common.Hash{}, pending[0].BucketID)
+	s.Equal(common.HexToAddress("0x02"), pending[0].PaymentAddress)
+	s.Equal(common.HexToAddress("0x03"), pending[0].BucketOwner)
+
+	bucketID := common.HexToHash("0x9abd")
+	s.handle(20, common.HexToHash("0x02"), &storagetypes.EventCreateBucket{
+		Owner:          "0x0000000000000000000000000000000000000003",
+		BucketName:     "future-bucket",
+		PaymentAddress: "0x0000000000000000000000000000000000000002",
+		BucketId:       math.NewUintFromBigInt(bucketID.Big()),
+	})
+	stored, err := s.db.GetBucket(s.ctx, bucketID)
+	s.Require().NoError(err)
+	s.Require().NotNil(stored.FlowRateLimit)
+	s.Equal(int64(200), stored.FlowRateLimit.Raw().Int64())
+
+	// a limit for another payment address does not apply to the bucket
+	setLimit(30, "0x0000000000000000000000000000000000000004", 400)
+	stored, err = s.db.GetBucket(s.ctx, bucketID)
+	s.Require().NoError(err)
+	s.Equal(int64(200), stored.FlowRateLimit.Raw().Int64())
+}
@@ -0,0 +1,103 @@
+package challenge
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	tmctypes "github.com/cometbft/cometbft/rpc/core/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/gogoproto/proto"
+	challengetypes "github.com/evmos/evmos/v12/x/challenge/types"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/log"
+	"github.com/forbole/juno/v4/models"
+)
+
+var (
+	EventStartChallenge  = proto.MessageName(&challengetypes.EventStartChallenge{})
+	EventAttestChallenge = proto.MessageName(&challengetypes.EventAttestChallenge{})
+)
+
+var ChallengeEvents = map[string]bool{
+	EventStartChallenge:  true,
+	EventAttestChallenge: true,
+}
+
+func (m *Module) ExtractEventStatements(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, event sdk.Event) (map[string][]interface{}, error) {
+	return nil, nil
+}
+
+func (m *Module) HandleEvent(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, event sdk.Event) error {
+	if !ChallengeEvents[event.Type] {
+		return nil
+	}
+
+	typedEvent, err := sdk.ParseTypedEvent(abci.Event(event))
+	if err != nil {
+		log.Errorw("parse typed events error", "module", m.Name(), "event", event, "err", err)
+		return err
+	}
+
+	switch event.Type {
+	case EventStartChallenge:
+		startChallenge, ok := typedEvent.(*challengetypes.EventStartChallenge)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventStartChallenge", "event", typedEvent)
+			return errors.New("start challenge event assert error")
+		}
+		return m.handleStartChallenge(ctx, block, txHash, startChallenge)
+	case EventAttestChallenge:
+		attestChallenge, ok := typedEvent.(*challengetypes.EventAttestChallenge)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventAttestChallenge", "event", typedEvent)
+			return errors.New("attest challenge event assert error")
+		}
+		return m.handleAttestChallenge(ctx, block, txHash, attestChallenge)
+	}
+
+	return nil
+}
+
+func (m *Module) handleStartChallenge(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, startChallenge *challengetypes.EventStartChallenge) error {
+	challenge := &models.Challenge{
+		ChallengeId:       startChallenge.ChallengeId,
+		ObjectID:          common.BigToHash(startChallenge.ObjectId.BigInt()),
+		SegmentIndex:      startChallenge.SegmentIndex,
+		SpId:              startChallenge.SpId,
+		SpOperatorAddress: common.HexToAddress(startChallenge.SpOperatorAddress),
+		RedundancyIndex:   startChallenge.RedundancyIndex,
+		ChallengerAddress: common.HexToAddress(startChallenge.ChallengerAddress),
+		ExpiredHeight:     startChallenge.ExpiredHeight,
+
+		CreateAt:     block.Block.Height,
+		CreateTxHash: txHash,
+		CreateTime:   block.Block.Time.UTC().Unix(),
+	}
+
+	return m.db.SaveChallenge(ctx, challenge)
+}
+
+func (m *Module) handleAttestChallenge(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, attestChallenge *challengetypes.EventAttestChallenge) error {
+	challenge := &models.Challenge{
+		ChallengeId:       attestChallenge.ChallengeId,
+		SpId:              attestChallenge.SpId,
+		ChallengerAddress: common.HexToAddress(attestChallenge.ChallengerAddress),
+
+		AttestResult:     attestChallenge.Result.String(),
+		SubmitterAddress: common.HexToAddress(attestChallenge.SubmitterAddress),
+
+		AttestAt:     block.Block.Height,
+		AttestTxHash: txHash,
+		AttestTime:   block.Block.Time.UTC().Unix(),
+	}
+
+	// the slash amount is only set when the challenge succeeded
+	if slashAmount, ok := new(big.Int).SetString(attestChallenge.SlashAmount, 10); ok {
+		challenge.SlashAmount = (*common.Big)(slashAmount)
+	}
+
+	return m.db.UpdateChallenge(ctx, challenge)
+}
@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	abci "github.com/cometbft/cometbft/abci/types"
+	tmctypes "github.com/cometbft/cometbft/rpc/core/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/gogoproto/proto"
+	challengetypes "github.com/evmos/evmos/v12/x/challenge/types"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/modules/challenge"
+	"github.com/forbole/juno/v4/modules/testutil"
+)
+
+type ChallengeHandlerTestSuite struct {
+	suite.Suite
+	db     *database.Impl
+	module *challenge.Module
+	ctx    context.Context
+}
+
+func TestChallengeHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(ChallengeHandlerTestSuite))
+}
+
+func (s *ChallengeHandlerTestSuite) SetupTest() {
+	s.db = testutil.NewDB(s.T(), &models.Challenge{}, &models.Object{}, &models.StorageProvider{})
+	s.module = challenge.NewModule(s.db)
+	s.ctx = context.Background()
+}
+
+func (s *ChallengeHandlerTestSuite) handle(height int64, txHash common.Hash, event proto.Message) {
+	testutil.HandleEvent(s.T(), s.ctx, s.module, height, txHash, event)
+}
+
+// TestChallengeLifecycle verifies that a challenge is recorded when started and completed when attested
+func (s *ChallengeHandlerTestSuite) TestChallengeLifecycle() {
+	objectId := common.BigToHash(sdkmath.NewUint(42).BigInt())
+	s.Require().NoError(s.db.Db.Create(&models.Object{BucketName: "bucket", ObjectName: "object", ObjectID: objectId}).Error)
+	s.Require().NoError(s.db.Db.Create(&models.StorageProvider{SpId: 7, Moniker: "sp7", Endpoint: "https://sp7"}).Error)
+
+	s.handle(10, common.HexToHash("0x01"), &challengetypes.EventStartChallenge{
+		ChallengeId:       1,
+		ObjectId:          sdkmath.NewUint(42),
+		SegmentIndex:      3,
+		SpId:              7,
+		SpOperatorAddress: "0x0000000000000000000000000000000000000007",
+		RedundancyIndex:   -1,
+		ChallengerAddress: "0x00000000000000000000000000000000000000c1",
+		ExpiredHeight:     110,
+	})
+	s.handle(10, common.HexToHash("0x02"), &challengetypes.EventStartChallenge{
+		ChallengeId: 2,
+		ObjectId:    sdkmath.NewUint(43),
+		SpId:        7,
+	})
+	s.handle(20, common.HexToHash("0x03"), &challengetypes.EventAttestChallenge{
+		ChallengeId:      1,
+		Result:           challengetypes.CHALLENGE_SUCCEED,
+		SpId:             7,
+		SlashAmount:      "1000",
+		SubmitterAddress: "0x00000000000000000000000000000000000000d1",
+	})
+	s.handle(20, common.HexToHash("0x04"), &challengetypes.EventAttestChallenge{
+		ChallengeId: 2,
+		Result:      challengetypes.CHALLENGE_FAILED,
+		SpId:        7,
+	})
+
+	challenges, err := s.db.GetChallengesBySp(s.ctx, 7, 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(challenges, 2)
+
+	s.Equal(uint64(2), challenges[0].ChallengeId)
+	s.Equal(challengetypes.CHALLENGE_FAILED.String(), challenges[0].AttestResult)
+	s.Nil(challenges[0].SlashAmount)
+	s.Empty(challenges[0].ObjectName)
+
+	s.Equal(uint64(1), challenges[1].ChallengeId)
+	s.Equal(objectId, challenges[1].ObjectID)
+	s.Equal(uint32(3), challenges[1].SegmentIndex)
+	s.Equal(challengetypes.CHALLENGE_SUCCEED.String(), challenges[1].AttestResult)
+	s.Equal(int64(1000), challenges[1].SlashAmount.Raw().Int64())
+	s.Equal(int64(10), challenges[1].CreateAt)
+	s.Equal(int64(20), challenges[1].AttestAt)
+	s.Equal(common.HexToHash("0x03"), challenges[1].AttestTxHash)
+	s.Equal("bucket", challenges[1].BucketName)
+	s.Equal("object", challenges[1].ObjectName)
+	s.Equal("sp7", challenges[1].SpMoniker)
+	s.Equal("https://sp7", challenges[1].SpEndpoint)
+
+	challenges, err = s.db.GetChallengesBySp(s.ctx, 7, 1, 10)
+	s.Require().NoError(err)
+	s.Require().Len(challenges, 1)
+	s.Equal(uint64(1), challenges[0].ChallengeId)
+}
+
+// TestEndBlockChallenge verifies that the challenges started by the chain at the end of the block are recorded
+func (s *ChallengeHandlerTestSuite) TestEndBlockChallenge() {
+	sdkEvent, err := sdk.TypedEventToEvent(&challengetypes.EventStartChallenge{
+		ChallengeId: 5,
+		ObjectId:    sdkmath.NewUint(42),
+		SpId:        8,
+	})
+	s.Require().NoError(err)
+	event := abci.Event(sdkEvent)
+	event.Attributes = append(event.Attributes, abci.EventAttribute{Key: "mode", Value: "EndBlock"})
+
+	testutil.ExportBlockEvents(s.T(), s.ctx, s.db, s.module, &tmctypes.ResultBlockResults{Height: 30, FinalizeBlockEvents: []abci.Event{
+		{Type: "other", Attributes: []abci.EventAttribute{{Key: "mode", Value: "EndBlock"}}},
+		event,
+	}})
+
+	challenges, err := s.db.GetChallengesBySp(s.ctx, 8, 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(challenges, 1)
+	s.Equal(uint64(5), challenges[0].ChallengeId)
+	s.Equal(int64(30), challenges[0].CreateAt)
+	s.Equal(common.Hash{}, challenges[0].CreateTxHash)
+}
+
+// TestAttestationBeforeStart verifies that an attestation is kept when the start of the challenge is not indexed,
+// or is indexed after it
+func (s *ChallengeHandlerTestSuite) TestAttestationBeforeStart() {
+	s.handle(20, common.HexToHash("0x03"), &challengetypes.EventAttestChallenge{
+		ChallengeId:       6,
+		Result:            challengetypes.CHALLENGE_SUCCEED,
+		SpId:              9,
+		ChallengerAddress: "0x00000000000000000000000000000000000000c1",
+	})
+
+	challenges, err := s.db.GetChallengesBySp(s.ctx, 9, 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(challenges, 1)
+	s.Equal(challengetypes.CHALLENGE_SUCCEED.String(), challenges[0].AttestResult)
+	s.Equal(common.HexToAddress("0xc1"), challenges[0].ChallengerAddress)
+
+	s.handle(10, common.HexToHash("0x01"), &challengetypes.EventStartChallenge{
+		ChallengeId:  6,
+		ObjectId:     sdkmath.NewUint(44),
+		SegmentIndex: 2,
+		SpId:         9,
+	})
+
+	challenges, err = s.db.GetChallengesBySp(s.ctx, 9, 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(challenges, 1)
+	s.Equal(uint32(2), challenges[0].SegmentIndex)
+	s.Equal(int64(10), challenges[0].CreateAt)
+	s.Equal(challengetypes.CHALLENGE_SUCCEED.String(), challenges[0].AttestResult)
+	s.Equal(int64(20), challenges[0].AttestAt)
+}
@@ -2,14 +2,25 @@ package epoch
 
 import (
 	"context"
+
 	"github.com/forbole/juno/v4/log"
 )
 
+// IsProcessed implements modules.EpochModule. The epoch is the last fully indexed block, every block up to it
+// is processed. The blocks past it complete out of order, so they are looked up in the sync ranges.
 func (m *Module) IsProcessed(height uint64) (bool, error) {
 	ep, err := m.db.GetEpoch(context.Background())
 	if err != nil {
 		return false, err
 	}
-	log.Infof("epoch height:%d, cur height: %d", ep.BlockHeight, height)
-	return ep.BlockHeight > int64(height), nil
+	log.Debugw("checking epoch", "epoch_height", ep.BlockHeight, "height", height)
+	if ep.BlockHeight >= int64(height) {
+		return true, nil
+	}
+
+	gaps, err := m.db.GetSyncGaps(context.Background(), height, height)
+	if err != nil {
+		return false, err
+	}
+	return len(gaps) == 0, nil
 }
@@ -17,6 +17,7 @@ const (
 var (
 	_ modules.Module              = &Module{}
 	_ modules.PrepareTablesModule = &Module{}
+	_ modules.EpochModule         = &Module{}
 )
 
 // Module represents the telemetry module
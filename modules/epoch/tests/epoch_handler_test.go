@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"cosmossdk.io/simapp/params"
+	abci "github.com/cometbft/cometbft/abci/types"
+	tmctypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdktx "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/modules"
+	"github.com/forbole/juno/v4/modules/epoch"
+	"github.com/forbole/juno/v4/modules/testutil"
+	"github.com/forbole/juno/v4/node"
+	"github.com/forbole/juno/v4/parser"
+	"github.com/forbole/juno/v4/types"
+)
+
+type EpochTestSuite struct {
+	suite.Suite
+	db      *database.Impl
+	module  *epoch.Module
+	indexer *parser.Impl
+}
+
+func TestEpochTestSuite(t *testing.T) {
+	suite.Run(t, new(EpochTestSuite))
+}
+
+func (s *EpochTestSuite) SetupTest() {
+	s.db = testutil.NewDB(s.T(), &models.Epoch{}, &models.SyncRange{}, &models.Bucket{}, &models.Block{}, &models.Tx{})
+	s.module = epoch.NewModule(s.db)
+	s.indexer = &parser.Impl{Ctx: context.Background(), DB: s.db}
+}
+
+func block(height int64, hash string) *tmctypes.ResultBlock {
+	b := testutil.Block(height)
+	b.BlockID = tmtypes.BlockID{Hash: common.HexToHash(hash).Bytes()}
+	return b
+}
+
+// TestExportEpoch_StopsAtFirstGap verifies that the epoch is the last block of the first gap-free sync range,
+// with the hash and time of that block, and never moves backwards
+func (s *EpochTestSuite) TestExportEpoch_StopsAtFirstGap() {
+	for height := int64(10); height <= 12; height++ {
+		s.Require().NoError(s.db.SaveBlock(context.Background(), &models.Block{
+			BlockID: models.BlockID{Hash: common.BigToHash(big.NewInt(height))},
+			Header:  models.Header{Height: uint64(height), Timestamp: uint64(1000 + height)},
+		}))
+	}
+
+	s.Require().NoError(s.indexer.ExportEpoch(context.Background(), block(10, "0x0a")))
+	s.Require().NoError(s.indexer.ExportEpoch(context.Background(), block(12, "0x0c")))
+
+	// the block 11 is still being processed
+	ep, err := s.db.GetEpoch(context.Background())
+	s.Require().NoError(err)
+	s.Equal(int64(10), ep.BlockHeight)
+	s.Equal(common.HexToHash("0x0a"), ep.BlockHash)
+
+	processed, err := s.module.IsProcessed(11)
+	s.Require().NoError(err)
+	s.False(processed)
+	processed, err = s.module.IsProcessed(12)
+	s.Require().NoError(err)
+	s.True(processed)
+
+	// the gap is filled, the epoch moves to the block past it
+	s.Require().NoError(s.indexer.ExportEpoch(context.Background(), block(11, "0x0b")))
+	ep, err = s.db.GetEpoch(context.Background())
+	s.Require().NoError(err)
+	s.Equal(int64(12), ep.BlockHeight)
+	s.Equal(common.BigToHash(big.NewInt(12)), ep.BlockHash)
+	s.Equal(int64(1012), ep.UpdateTime)
+
+	// re-processing an older block does not lower the epoch
+	s.Require().NoError(s.indexer.ExportEpoch(context.Background(), block(5, "0x05")))
+	ep, err = s.db.GetEpoch(context.Background())
+	s.Require().NoError(err)
+	s.Equal(int64(12), ep.BlockHeight)
+
+	gaps, err := s.db.GetSyncGaps(context.Background(), 1, 12)
+	s.Require().NoError(err)
+	s.Equal([]database.HeightRange{{Start: 1, End: 4}, {Start: 6, End: 9}}, gaps)
+}
+
+// TestExportEpoch_CommitsBufferedWrites verifies that the buffered writes of a block are committed along with its epoch
+func (s *EpochTestSuite) TestExportEpoch_CommitsBufferedWrites() {
+	ctx := database.WithWriteBuffer(context.Background())
+	s.Require().NoError(s.db.SaveBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x01"), BucketName: "bucket-1"}))
+
+	// the block fails once its writes are flushed, none of them is committed
+	s.Require().NoError(s.db.Db.Migrator().DropTable(&models.SyncRange{}))
+	s.Require().Error(s.indexer.ExportEpoch(ctx, block(10, "0x0a")))
+
+	bucket, err := s.db.GetBucketByName(context.Background(), "bucket-1")
+	s.Require().NoError(err)
+	s.Nil(bucket)
+	ep, err := s.db.GetEpoch(context.Background())
+	s.Require().NoError(err)
+	s.Equal(int64(0), ep.BlockHeight)
+
+	// the block is processed again
+	s.Require().NoError(s.db.Db.Migrator().CreateTable(&models.SyncRange{}))
+	ctx = database.WithWriteBuffer(context.Background())
+	s.Require().NoError(s.db.SaveBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x01"), BucketName: "bucket-1"}))
+	s.Require().NoError(s.indexer.ExportEpoch(ctx, block(10, "0x0a")))
+
+	bucket, err = s.db.GetBucketByName(context.Background(), "bucket-1")
+	s.Require().NoError(err)
+	s.Require().NotNil(bucket)
+	ep, err = s.db.GetEpoch(context.Background())
+	s.Require().NoError(err)
+	s.Equal(int64(10), ep.BlockHeight)
+}
+
+// fakeNode serves the blocks of the given txs and end block events
+type fakeNode struct {
+	node.Node
+	txs    map[int64][]*types.Tx
+	events map[int64][]abci.Event
+}
+
+func (n *fakeNode) Block(height int64) (*tmctypes.ResultBlock, error) {
+	return block(height, fmt.Sprintf("0x%x", height)), nil
+}
+
+func (n *fakeNode) BlockResults(height int64) (*tmctypes.ResultBlockResults, error) {
+	return &tmctypes.ResultBlockResults{Height: height, FinalizeBlockEvents: n.events[height]}, nil
+}
+
+func (n *fakeNode) Txs(block *tmctypes.ResultBlock) ([]*types.Tx, error) {
+	return n.txs[block.Block.Height], nil
+}
+
+// failingModule fails the first failures events of the failing type, or of any type when it is empty, and records
+// the type of the events it handles
+type failingModule struct {
+	failing  string
+	failures int
+	handled  []string
+}
+
+func (m *failingModule) Name() string { return "failing" }
+
+func (m *failingModule) HandleEvent(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, event sdk.Event) error {
+	if m.failures > 0 && (m.failing == "" || m.failing == event.Type) {
+		m.failures--
+		return errors.New("failed to handle event")
+	}
+	m.handled = append(m.handled, event.Type)
+	return nil
+}
+
+func (m *failingModule) ExtractEventStatements(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, event sdk.Event) (map[string][]interface{}, error) {
+	return nil, nil
+}
+
+func (m *failingModule) SetCtx(key string, value interface{}) {}
+
+func (m *failingModule) GetCtx(key string) interface{} { return nil }
+
+func (m *failingModule) ClearCtx() {}
+
+// TestWorker_RetriesFailedHeight verifies that a block whose events failed is processed again, although
+// its block row was saved before the failure, and is skipped once it is processed
+func (s *EpochTestSuite) TestWorker_RetriesFailedHeight() {
+	tx := &types.Tx{
+		Tx: &sdktx.Tx{Body: &sdktx.TxBody{}, AuthInfo: &sdktx.AuthInfo{Fee: &sdktx.Fee{}}},
+		TxResponse: &sdk.TxResponse{Height: 10, TxHash: common.HexToHash("0x01").Hex(),
+			Events: []abci.Event{{Type: "test"}}},
+	}
+	module := &failingModule{failures: 1}
+	indexer := &parser.Impl{Ctx: context.Background(), DB: s.db, Node: &fakeNode{txs: map[int64][]*types.Tx{10: {tx}}},
+		Modules: []modules.Module{module}}
+	worker := parser.NewWorker(&parser.Context{EncodingConfig: &params.EncodingConfig{}, Database: s.db,
+		Modules: []modules.Module{s.module, module}}, nil, 0, false)
+	worker.SetIndexer(indexer)
+
+	s.Require().Error(worker.ProcessIfNotExists(10))
+	exists, err := s.db.HasBlock(context.Background(), 10)
+	s.Require().NoError(err)
+	s.True(exists)
+	processed, err := indexer.Processed(context.Background(), 10)
+	s.Require().NoError(err)
+	s.False(processed)
+
+	s.Require().NoError(worker.ProcessIfNotExists(10))
+	s.Equal([]string{"test"}, module.handled)
+	processed, err = s.module.IsProcessed(10)
+	s.Require().NoError(err)
+	s.True(processed)
+
+	s.Require().NoError(worker.ProcessIfNotExists(10))
+	s.Equal([]string{"test"}, module.handled)
+}
+
+// TestWorker_RetriesFailedEndBlockEvent verifies that the events of the end of the block are handled after the ones
+// of its txs, and that the block is processed again when one of them fails
+func (s *EpochTestSuite) TestWorker_RetriesFailedEndBlockEvent() {
+	tx := &types.Tx{
+		Tx: &sdktx.Tx{Body: &sdktx.TxBody{}, AuthInfo: &sdktx.AuthInfo{Fee: &sdktx.Fee{}}},
+		TxResponse: &sdk.TxResponse{Height: 10, TxHash: common.HexToHash("0x01").Hex(),
+			Events: []abci.Event{{Type: "tx"}}},
+	}
+	module := &failingModule{failing: "end_block", failures: 1}
+	indexer := &parser.Impl{Ctx: context.Background(), DB: s.db, Modules: []modules.Module{module}, Node: &fakeNode{
+		txs:    map[int64][]*types.Tx{10: {tx}},
+		events: map[int64][]abci.Event{10: {{Type: "end_block", Attributes: []abci.EventAttribute{{Key: "mode", Value: "EndBlock"}}}}},
+	}}
+	worker := parser.NewWorker(&parser.Context{EncodingConfig: &params.EncodingConfig{}, Database: s.db,
+		Modules: []modules.Module{s.module, module}}, nil, 0, false)
+	worker.SetIndexer(indexer)
+
+	s.Require().Error(worker.ProcessIfNotExists(10))
+	s.Equal([]string{"tx"}, module.handled)
+	processed, err := s.module.IsProcessed(10)
+	s.Require().NoError(err)
+	s.False(processed)
+
+	s.Require().NoError(worker.ProcessIfNotExists(10))
+	s.Equal([]string{"tx", "tx", "end_block"}, module.handled)
+	processed, err = s.module.IsProcessed(10)
+	s.Require().NoError(err)
+	s.True(processed)
+}
@@ -226,6 +226,10 @@ func (m *Module) handleCopyObject(ctx context.Context, block *tmctypes.ResultBlo
 	if err != nil {
 		return err
 	}
+	// the source object was not indexed, the copy only holds what the event tells
+	if destObject == nil {
+		destObject = &models.Object{}
+	}
 
 	destObject.ObjectID = common.BigToHash(copyObject.DstObjectId.BigInt())
 	destObject.ObjectName = copyObject.DstObjectName
@@ -239,7 +243,22 @@ func (m *Module) handleCopyObject(ctx context.Context, block *tmctypes.ResultBlo
 	destObject.UpdateTime = block.Block.Time.UTC().Unix()
 	destObject.Removed = false
 
-	return m.db.UpdateObject(ctx, destObject)
+	tx := m.db.Begin(ctx)
+	if err := tx.UpdateObject(ctx, destObject); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.SaveObjectLineage(ctx, &models.ObjectLineage{
+		SrcObjectID: common.BigToHash(copyObject.SrcObjectId.BigInt()),
+		DstObjectID: destObject.ObjectID,
+		Operator:    destObject.Operator,
+		Height:      block.Block.Height,
+		TxHash:      txHash,
+	}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
 
 func (m *Module) handleDeleteObject(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, deleteObject *storagetypes.EventDeleteObject) error {
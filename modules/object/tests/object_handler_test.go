@@ -25,8 +25,8 @@ func NewMockDB() (*MockDBImpl, error) {
 		return nil, err
 	}
 
-	// Auto-migrate the Object model
-	err = db.AutoMigrate(&models.Object{})
+	// Auto-migrate the Object models
+	err = db.AutoMigrate(&models.Object{}, &models.ObjectLineage{})
 	if err != nil {
 		return nil, err
 	}
@@ -232,3 +232,31 @@ func (s *ObjectHandlerTestSuite) TestNormalUpdate_PreservesIsUpdating() {
 	s.Equal("VISIBILITY_PUBLIC", storedObj.Visibility)
 	s.True(storedObj.IsUpdating, "IsUpdating should persist because Status was empty in update")
 }
+
+// TestObjectLineage_WalksAncestorsAndDescendants verifies that copies can be traced in both directions
+func (s *ObjectHandlerTestSuite) TestObjectLineage_WalksAncestorsAndDescendants() {
+	// 0x2001 -> 0x2002 -> 0x2003, 0x2002 -> 0x2004 and 0x2003 -> 0x2005
+	links := [][2]string{{"0x2001", "0x2002"}, {"0x2002", "0x2003"}, {"0x2002", "0x2004"}, {"0x2003", "0x2005"}}
+	for i, link := range links {
+		s.Require().NoError(s.db.SaveObjectLineage(s.ctx, &models.ObjectLineage{
+			SrcObjectID: common.HexToHash(link[0]),
+			DstObjectID: common.HexToHash(link[1]),
+			Height:      int64(10 + i),
+		}))
+	}
+
+	ancestors, descendants, err := s.db.GetObjectLineage(s.ctx, common.HexToHash("0x2003"))
+	s.Require().NoError(err)
+	s.Require().Len(ancestors, 2)
+	s.Equal(common.HexToHash("0x2002"), ancestors[0].SrcObjectID)
+	s.Equal(common.HexToHash("0x2001"), ancestors[1].SrcObjectID)
+	s.Require().Len(descendants, 1)
+	s.Equal(common.HexToHash("0x2005"), descendants[0].DstObjectID)
+
+	ancestors, descendants, err = s.db.GetObjectLineage(s.ctx, common.HexToHash("0x2001"))
+	s.Require().NoError(err)
+	s.Empty(ancestors)
+	s.Require().Len(descendants, 4)
+	s.Equal(common.HexToHash("0x2002"), descendants[0].DstObjectID)
+	s.Equal(common.HexToHash("0x2005"), descendants[3].DstObjectID)
+}
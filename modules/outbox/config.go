@@ -0,0 +1,67 @@
+package outbox
+
+import (
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultSchedule is the interval between two relay runs when not configured
+	defaultSchedule = 5 * time.Second
+	// defaultBatchSize is the maximum number of records published at once when not configured
+	defaultBatchSize = 100
+	// defaultTimeout is the timeout of a webhook request when not configured
+	defaultTimeout = 10 * time.Second
+)
+
+type Config struct {
+	// Schedule is the interval between two relay runs
+	Schedule time.Duration `yaml:"schedule"`
+	// BatchSize is the maximum number of records published at once
+	BatchSize int `yaml:"batch_size"`
+	// Webhook is the endpoint the records are published to
+	Webhook *WebhookConfig `yaml:"webhook"`
+}
+
+// WebhookConfig describes the endpoint receiving the outbox records
+type WebhookConfig struct {
+	URL string `yaml:"url"`
+	// Token is sent as bearer token when set
+	Token string `yaml:"token"`
+	// Timeout is the timeout of a request
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func ParseConfig(bz []byte) (*Config, error) {
+	type T struct {
+		Config *Config `yaml:"outbox"`
+	}
+	var cfg T
+	err := yaml.Unmarshal(bz, &cfg)
+	return cfg.Config, err
+}
+
+// GetSchedule returns the interval between two relay runs
+func (cfg *Config) GetSchedule() time.Duration {
+	if cfg.Schedule <= 0 {
+		return defaultSchedule
+	}
+	return cfg.Schedule
+}
+
+// GetBatchSize returns the maximum number of records published at once
+func (cfg *Config) GetBatchSize() int {
+	if cfg.BatchSize <= 0 {
+		return defaultBatchSize
+	}
+	return cfg.BatchSize
+}
+
+// GetTimeout returns the timeout of a webhook request
+func (cfg *WebhookConfig) GetTimeout() time.Duration {
+	if cfg.Timeout <= 0 {
+		return defaultTimeout
+	}
+	return cfg.Timeout
+}
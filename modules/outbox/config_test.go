@@ -0,0 +1,36 @@
+package outbox_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/forbole/juno/v4/modules/outbox"
+)
+
+func TestParseConfig(t *testing.T) {
+	data := []byte(`
+outbox:
+  schedule: 1s
+  batch_size: 50
+  webhook:
+    url: http://localhost:8080/changes
+    token: secret
+`)
+
+	cfg, err := outbox.ParseConfig(data)
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	require.Equal(t, time.Second, cfg.GetSchedule())
+	require.Equal(t, 50, cfg.GetBatchSize())
+	require.Equal(t, "http://localhost:8080/changes", cfg.Webhook.URL)
+	require.Equal(t, 10*time.Second, cfg.Webhook.GetTimeout())
+	require.NoError(t, outbox.RunAdditionalOperations(cfg))
+
+	cfg, err = outbox.ParseConfig([]byte(`invalid_field: yes`))
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+	require.Error(t, outbox.RunAdditionalOperations(cfg))
+	require.Error(t, outbox.RunAdditionalOperations(&outbox.Config{}))
+}
@@ -0,0 +1,21 @@
+package outbox
+
+import "fmt"
+
+// RunAdditionalOperations runs the additional operations for the outbox module
+func RunAdditionalOperations(cfg *Config) error {
+	return checkConfig(cfg)
+}
+
+// checkConfig checks if the given config is valid
+func checkConfig(cfg *Config) error {
+	if cfg == nil {
+		return fmt.Errorf("outbox config is not set but module is enabled")
+	}
+
+	if cfg.Webhook == nil || cfg.Webhook.URL == "" {
+		return fmt.Errorf("outbox is enabled, but no webhook url is set")
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-co-op/gocron"
+
+	"github.com/forbole/juno/v4/log"
+)
+
+// RegisterPeriodicOperations implements modules.PeriodicOperationsModule
+func (m *Module) RegisterPeriodicOperations(scheduler *gocron.Scheduler) error {
+	log.Debugw("setting up periodic tasks", "module", m.Name())
+
+	if _, err := scheduler.Every(m.cfg.GetSchedule()).SingletonMode().Do(func() {
+		if err := m.Relay(context.Background()); err != nil {
+			log.Errorw("failed to relay the outbox", "module", m.Name(), "err", err)
+		}
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Relay publishes the unpublished outbox records in batches, oldest first, and marks them as published.
+// It stops at the first batch which fails to be published, so that the records are delivered in order.
+func (m *Module) Relay(ctx context.Context) error {
+	batchSize := m.cfg.GetBatchSize()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		changes, err := m.db.GetUnpublishedChanges(ctx, batchSize)
+		if err != nil {
+			return fmt.Errorf("error while getting the outbox records: %s", err)
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+
+		if err = m.publisher.Publish(ctx, changes); err != nil {
+			log.OutboxPublishErrors.Inc()
+			return fmt.Errorf("error while publishing the outbox records: %s", err)
+		}
+
+		ids := make([]uint64, len(changes))
+		for i, change := range changes {
+			ids[i] = change.ID
+		}
+		if err = m.db.MarkChangesPublished(ctx, ids, time.Now().Unix()); err != nil {
+			return fmt.Errorf("error while marking the outbox records as published: %s", err)
+		}
+		log.OutboxPublishedCount.Add(float64(len(changes)))
+
+		if len(changes) < batchSize {
+			return nil
+		}
+	}
+}
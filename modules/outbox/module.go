@@ -0,0 +1,59 @@
+package outbox
+
+import (
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/modules"
+	"github.com/forbole/juno/v4/types/config"
+)
+
+var (
+	_ modules.Module                     = &Module{}
+	_ modules.PeriodicOperationsModule   = &Module{}
+	_ modules.AdditionalOperationsModule = &Module{}
+)
+
+// Module represents the outbox module, relaying the changes recorded in the outbox table to the downstream consumers.
+// The changes are recorded only when enable_outbox is set in the database config.
+type Module struct {
+	cfg       *Config
+	db        database.Database
+	publisher Publisher
+}
+
+// NewModule builds a new Module instance
+func NewModule(cfg config.Config, db database.Database) *Module {
+	bz, err := cfg.GetBytes()
+	if err != nil {
+		panic(err)
+	}
+
+	outboxCfg, err := ParseConfig(bz)
+	if err != nil {
+		panic(err)
+	}
+
+	var publisher Publisher
+	if outboxCfg != nil && outboxCfg.Webhook != nil {
+		publisher = NewWebhookPublisher(outboxCfg.Webhook)
+	}
+	return NewModuleWithPublisher(outboxCfg, db, publisher)
+}
+
+// NewModuleWithPublisher builds a new Module instance publishing the records with the given Publisher
+func NewModuleWithPublisher(cfg *Config, db database.Database, publisher Publisher) *Module {
+	return &Module{
+		cfg:       cfg,
+		db:        db,
+		publisher: publisher,
+	}
+}
+
+// Name implements modules.Module
+func (m *Module) Name() string {
+	return "outbox"
+}
+
+// RunAdditionalOperations implements modules.AdditionalOperationsModule
+func (m *Module) RunAdditionalOperations() error {
+	return RunAdditionalOperations(m.cfg)
+}
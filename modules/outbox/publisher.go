@@ -0,0 +1,64 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/forbole/juno/v4/models"
+)
+
+// Publisher delivers the outbox records to the downstream consumers
+type Publisher interface {
+	// Publish delivers the records, oldest first. The records are published again until Publish succeeds,
+	// so a record may be delivered more than once: consumers must skip the idempotency keys they already applied.
+	Publish(ctx context.Context, changes []*models.Outbox) error
+}
+
+// webhookPublisher posts the records to an HTTP endpoint as a JSON object {"changes": [...]}.
+// Any status other than 2xx fails the delivery.
+type webhookPublisher struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewWebhookPublisher returns a Publisher posting the records to the webhook of cfg
+func NewWebhookPublisher(cfg *WebhookConfig) Publisher {
+	return &webhookPublisher{
+		url:    cfg.URL,
+		token:  cfg.Token,
+		client: &http.Client{Timeout: cfg.GetTimeout()},
+	}
+}
+
+func (p *webhookPublisher) Publish(ctx context.Context, changes []*models.Outbox) error {
+	body, err := json.Marshal(map[string]interface{}{"changes": changes})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	databaseconfig "github.com/forbole/juno/v4/database/config"
+	"github.com/forbole/juno/v4/database/sqlclient"
+	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/modules/outbox"
+)
+
+func TestRelay(t *testing.T) {
+	db, err := sqlclient.New(&databaseconfig.Config{Type: databaseconfig.SQLite, DSN: "file:outbox_relay?mode=memory&cache=shared"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Bucket{}, &models.Outbox{}))
+	impl := &database.Impl{Db: db, Outbox: true}
+
+	ctx := context.Background()
+	for i := byte(1); i <= 5; i++ {
+		require.NoError(t, impl.SaveBucket(ctx, &models.Bucket{BucketID: common.BytesToHash([]byte{i}), BucketName: string('a' + rune(i))}))
+	}
+
+	var received []uint64
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var body struct {
+			Changes []*models.Outbox `json:"changes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, change := range body.Changes {
+			received = append(received, change.ID)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &outbox.Config{BatchSize: 2, Webhook: &outbox.WebhookConfig{URL: server.URL, Token: "secret"}}
+	module := outbox.NewModuleWithPublisher(cfg, impl, outbox.NewWebhookPublisher(cfg.Webhook))
+
+	// the records are kept until they are delivered
+	require.Error(t, module.Relay(ctx))
+	changes, err := impl.GetUnpublishedChanges(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, changes, 5)
+
+	fail = false
+	require.NoError(t, module.Relay(ctx))
+	require.Equal(t, []uint64{1, 2, 3, 4, 5}, received)
+	changes, err = impl.GetUnpublishedChanges(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+}
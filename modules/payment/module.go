@@ -3,11 +3,16 @@ package payment
 import (
 	"context"
 
+	paymenttypes "github.com/evmos/evmos/v12/x/payment/types"
+	"google.golang.org/grpc"
 	"gorm.io/gorm/schema"
 
 	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/log"
 	"github.com/forbole/juno/v4/models"
 	"github.com/forbole/juno/v4/modules"
+	"github.com/forbole/juno/v4/node/remote"
+	"github.com/forbole/juno/v4/types/config"
 )
 
 const (
@@ -19,16 +24,42 @@ var (
 	_ modules.PrepareTablesModule = &Module{}
 )
 
+// OutFlowsClient is the part of the payment Query service of the chain the out flows of the stream records
+// are read from, since the stream record update events do not carry them
+type OutFlowsClient interface {
+	OutFlows(ctx context.Context, in *paymenttypes.QueryOutFlowsRequest, opts ...grpc.CallOption) (*paymenttypes.QueryOutFlowsResponse, error)
+}
+
 // Module represents the payment module
 type Module struct {
-	db database.Database
+	db            database.Database
+	outFlows      OutFlowsClient
+	outFlowsCache *outFlowsCache
 }
 
-// NewModule builds a new Module instance
-func NewModule(db database.Database) *Module {
+// NewModule builds a new Module instance. The out flows are not indexed when outFlows is nil.
+func NewModule(db database.Database, outFlows OutFlowsClient) *Module {
 	return &Module{
-		db: db,
+		db:            db,
+		outFlows:      outFlows,
+		outFlowsCache: newOutFlowsCache(),
+	}
+}
+
+// NewOutFlowsClient returns the payment Query client of the gRPC endpoint of the remote node,
+// nil when the node is not a remote one
+func NewOutFlowsClient(cfg config.Config) OutFlowsClient {
+	details, ok := cfg.Node.Details.(*remote.Details)
+	if !ok || details.GRPC == nil {
+		return nil
+	}
+
+	conn, err := remote.CreateGrpcConnection(details.GRPC)
+	if err != nil {
+		log.Errorw("failed to connect to the grpc endpoint, the out flows are not indexed", "module", ModuleName, "err", err)
+		return nil
 	}
+	return paymenttypes.NewQueryClient(conn)
 }
 
 // Name implements modules.Module
@@ -38,10 +69,10 @@ func (m *Module) Name() string {
 
 // PrepareTables implements
 func (m *Module) PrepareTables() error {
-	return m.db.PrepareTables(context.TODO(), []schema.Tabler{&models.StreamRecord{}, &models.PaymentAccount{}})
+	return m.db.PrepareTables(context.TODO(), []schema.Tabler{&models.StreamRecord{}, &models.PaymentAccount{}, &models.PaymentLedger{}, &models.StreamRecordHistory{}, &models.StreamRecordOutFlow{}})
 }
 
 // AutoMigrate implements
 func (m *Module) AutoMigrate() error {
-	return m.db.AutoMigrate(context.TODO(), []schema.Tabler{&models.StreamRecord{}, &models.PaymentAccount{}})
+	return m.db.AutoMigrate(context.TODO(), []schema.Tabler{&models.StreamRecord{}, &models.PaymentAccount{}, &models.PaymentLedger{}, &models.StreamRecordHistory{}, &models.StreamRecordOutFlow{}})
 }
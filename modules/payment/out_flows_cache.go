@@ -0,0 +1,71 @@
+package payment
+
+import (
+	"sort"
+	"sync"
+
+	paymenttypes "github.com/evmos/evmos/v12/x/payment/types"
+)
+
+// outFlowsCacheHeights is the number of heights the out flows are kept for, enough for the blocks
+// processed at the same time by the workers
+const outFlowsCacheHeights = 16
+
+// outFlowsCache keeps the out flows read from the chain per height and account, so that the chain is
+// queried once per account and block. A failed query is kept too, it is not retried within the block.
+type outFlowsCache struct {
+	mu      sync.Mutex
+	entries map[int64]map[string]*outFlowsEntry
+}
+
+type outFlowsEntry struct {
+	once     sync.Once
+	outFlows []paymenttypes.OutFlow
+	err      error
+}
+
+func newOutFlowsCache() *outFlowsCache {
+	return &outFlowsCache{entries: make(map[int64]map[string]*outFlowsEntry)}
+}
+
+// load returns the out flows of account at height, read by query unless they were already read
+func (c *outFlowsCache) load(height int64, account string, query func() ([]paymenttypes.OutFlow, error)) ([]paymenttypes.OutFlow, error) {
+	entry := c.entry(height, account)
+	entry.once.Do(func() {
+		entry.outFlows, entry.err = query()
+	})
+	return entry.outFlows, entry.err
+}
+
+func (c *outFlowsCache) entry(height int64, account string) *outFlowsEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	accounts, ok := c.entries[height]
+	if !ok {
+		accounts = make(map[string]*outFlowsEntry)
+		c.entries[height] = accounts
+		c.evict()
+	}
+	entry, ok := accounts[account]
+	if !ok {
+		entry = &outFlowsEntry{}
+		accounts[account] = entry
+	}
+	return entry
+}
+
+// evict drops the lowest heights past the outFlowsCacheHeights kept
+func (c *outFlowsCache) evict() {
+	if len(c.entries) <= outFlowsCacheHeights {
+		return
+	}
+	heights := make([]int64, 0, len(c.entries))
+	for height := range c.entries {
+		heights = append(heights, height)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	for _, height := range heights[:len(heights)-outFlowsCacheHeights] {
+		delete(c.entries, height)
+	}
+}
@@ -13,23 +13,30 @@ import (
 	"github.com/forbole/juno/v4/common"
 	"github.com/forbole/juno/v4/log"
 	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/node/remote"
 )
 
 var (
 	EventPaymentAccountUpdate = proto.MessageName(&paymenttypes.EventPaymentAccountUpdate{})
 	EventStreamRecordUpdate   = proto.MessageName(&paymenttypes.EventStreamRecordUpdate{})
+	EventDeposit              = proto.MessageName(&paymenttypes.EventDeposit{})
+	EventWithdraw             = proto.MessageName(&paymenttypes.EventWithdraw{})
+	EventForceSettle          = proto.MessageName(&paymenttypes.EventForceSettle{})
 )
 
 var PaymentEvents = map[string]bool{
 	EventPaymentAccountUpdate: true,
 	EventStreamRecordUpdate:   true,
+	EventDeposit:              true,
+	EventWithdraw:             true,
+	EventForceSettle:          true,
 }
 
 func (m *Module) ExtractEventStatements(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, event sdk.Event) (map[string][]interface{}, error) {
 	return nil, nil
 }
 
-func (m *Module) HandleEvent(ctx context.Context, block *tmctypes.ResultBlock, _ common.Hash, event sdk.Event) error {
+func (m *Module) HandleEvent(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, event sdk.Event) error {
 	if !PaymentEvents[event.Type] {
 		return nil
 	}
@@ -54,7 +61,35 @@ func (m *Module) HandleEvent(ctx context.Context, block *tmctypes.ResultBlock, _
 			log.Errorw("type assert error", "type", "EventStreamRecordUpdate", "event", typedEvent)
 			return errors.New("update stream record event assert error")
 		}
-		return m.handleEventStreamRecordUpdate(ctx, streamRecordUpdate)
+		// the stream record is recorded even when the out flows cannot be read, the known ones are kept then
+		outFlows, err := m.getOutFlows(ctx, block.Block.Height, streamRecordUpdate.Account)
+		if err != nil {
+			log.Errorw("query out flows error, the out flows are not updated", "module", m.Name(), "height", block.Block.Height,
+				"account", streamRecordUpdate.Account, "err", err)
+			outFlows = nil
+		}
+		return m.handleEventStreamRecordUpdate(ctx, block, txHash, streamRecordUpdate, outFlows)
+	case EventDeposit:
+		deposit, ok := typedEvent.(*paymenttypes.EventDeposit)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventDeposit", "event", typedEvent)
+			return errors.New("deposit event assert error")
+		}
+		return m.handleDeposit(ctx, block, txHash, deposit)
+	case EventWithdraw:
+		withdraw, ok := typedEvent.(*paymenttypes.EventWithdraw)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventWithdraw", "event", typedEvent)
+			return errors.New("withdraw event assert error")
+		}
+		return m.handleWithdraw(ctx, block, txHash, withdraw)
+	case EventForceSettle:
+		forceSettle, ok := typedEvent.(*paymenttypes.EventForceSettle)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventForceSettle", "event", typedEvent)
+			return errors.New("force settle event assert error")
+		}
+		return m.handleForceSettle(ctx, block, txHash, forceSettle)
 	}
 
 	return nil
@@ -72,7 +107,7 @@ func (m *Module) handlePaymentAccountUpdate(ctx context.Context, block *tmctypes
 	return m.db.SavePaymentAccount(ctx, paymentAccount)
 }
 
-func (m *Module) handleEventStreamRecordUpdate(ctx context.Context, streamRecordUpdate *paymenttypes.EventStreamRecordUpdate) error {
+func (m *Module) handleEventStreamRecordUpdate(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, streamRecordUpdate *paymenttypes.EventStreamRecordUpdate, outFlows []*models.StreamRecordOutFlow) error {
 	streamRecord := &models.StreamRecord{
 		Account:           common.HexToAddress(streamRecordUpdate.Account),
 		CrudTimestamp:     streamRecordUpdate.CrudTimestamp,
@@ -85,5 +120,116 @@ func (m *Module) handleEventStreamRecordUpdate(ctx context.Context, streamRecord
 		SettleTimestamp:   streamRecordUpdate.SettleTimestamp,
 	}
 
-	return m.db.SaveStreamRecord(ctx, streamRecord)
+	history := &models.StreamRecordHistory{
+		Account:           streamRecord.Account,
+		CrudTimestamp:     streamRecord.CrudTimestamp,
+		NetflowRate:       streamRecord.NetflowRate,
+		FrozenNetflowRate: streamRecord.FrozenNetflowRate,
+		StaticBalance:     streamRecord.StaticBalance,
+		BufferBalance:     streamRecord.BufferBalance,
+		LockBalance:       streamRecord.LockBalance,
+		Status:            streamRecord.Status,
+		SettleTimestamp:   streamRecord.SettleTimestamp,
+
+		Height: block.Block.Height,
+		TxHash: txHash,
+	}
+
+	tx := m.db.Begin(ctx)
+	if err := tx.SaveStreamRecord(ctx, streamRecord); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.SaveStreamRecordHistory(ctx, history); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if outFlows != nil {
+		for _, outFlow := range outFlows {
+			outFlow.From = streamRecord.Account
+			outFlow.UpdateAt = block.Block.Height
+			outFlow.UpdateTime = block.Block.Time.UTC().Unix()
+		}
+		if err := tx.SaveStreamRecordOutFlows(ctx, streamRecord.Account, outFlows); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// getOutFlows returns the out flows of the stream record of account at height, read from the chain.
+// Nil is returned when no client is set, so that the known out flows are kept.
+// The chain is queried once per account and height, however many times the stream record is updated in the block.
+func (m *Module) getOutFlows(ctx context.Context, height int64, account string) ([]*models.StreamRecordOutFlow, error) {
+	if m.outFlows == nil {
+		return nil, nil
+	}
+
+	res, err := m.outFlowsCache.load(height, account, func() ([]paymenttypes.OutFlow, error) {
+		res, err := m.outFlows.OutFlows(remote.GetHeightRequestContext(ctx, height), &paymenttypes.QueryOutFlowsRequest{Account: account})
+		if err != nil {
+			return nil, err
+		}
+		return res.OutFlows, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	outFlows := make([]*models.StreamRecordOutFlow, 0, len(res))
+	for _, outFlow := range res {
+		outFlows = append(outFlows, &models.StreamRecordOutFlow{
+			To:     common.HexToAddress(outFlow.ToAddress),
+			Rate:   (*common.Big)(outFlow.Rate.BigInt()),
+			Status: outFlow.Status.String(),
+		})
+	}
+	return outFlows, nil
+}
+
+func (m *Module) handleDeposit(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, deposit *paymenttypes.EventDeposit) error {
+	ledger := &models.PaymentLedger{
+		Account:      common.HexToAddress(deposit.To),
+		Counterparty: common.HexToAddress(deposit.From),
+		Amount:       (*common.Big)(deposit.Amount.BigInt()),
+		Kind:         models.PaymentLedgerKindDeposit,
+
+		Height:    block.Block.Height,
+		TxHash:    txHash,
+		Timestamp: block.Block.Time.UTC().Unix(),
+	}
+
+	return m.db.SavePaymentLedger(ctx, ledger)
+}
+
+func (m *Module) handleWithdraw(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, withdraw *paymenttypes.EventWithdraw) error {
+	ledger := &models.PaymentLedger{
+		Account:      common.HexToAddress(withdraw.From),
+		Counterparty: common.HexToAddress(withdraw.To),
+		Amount:       (*common.Big)(withdraw.Amount.BigInt()),
+		Kind:         models.PaymentLedgerKindWithdraw,
+
+		Height:    block.Block.Height,
+		TxHash:    txHash,
+		Timestamp: block.Block.Time.UTC().Unix(),
+	}
+
+	return m.db.SavePaymentLedger(ctx, ledger)
+}
+
+// handleForceSettle records the balance left when a payment account is force settled,
+// which goes to (or, if negative, is paid by) the governance stream account
+func (m *Module) handleForceSettle(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, forceSettle *paymenttypes.EventForceSettle) error {
+	ledger := &models.PaymentLedger{
+		Account:      common.HexToAddress(forceSettle.Addr),
+		Counterparty: common.BytesToAddress(paymenttypes.GovernanceAddress),
+		Amount:       (*common.Big)(forceSettle.SettledBalance.BigInt()),
+		Kind:         models.PaymentLedgerKindForceSettle,
+
+		Height:    block.Block.Height,
+		TxHash:    txHash,
+		Timestamp: block.Block.Time.UTC().Unix(),
+	}
+
+	return m.db.SavePaymentLedger(ctx, ledger)
 }
@@ -0,0 +1,260 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"cosmossdk.io/math"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"github.com/cosmos/gogoproto/proto"
+	paymenttypes "github.com/evmos/evmos/v12/x/payment/types"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/modules/payment"
+	"github.com/forbole/juno/v4/modules/testutil"
+)
+
+// outFlowsClient answers the out flows queries like the payment Query service of the chain, from the
+// out flows set per account and height, and counts the queries. The queries of the accounts in unavailable fail.
+type outFlowsClient struct {
+	outFlows    map[string]map[int64][]paymenttypes.OutFlow
+	unavailable map[string]bool
+	queries     int
+}
+
+func (c *outFlowsClient) OutFlows(ctx context.Context, in *paymenttypes.QueryOutFlowsRequest, opts ...grpc.CallOption) (*paymenttypes.QueryOutFlowsResponse, error) {
+	c.queries++
+	if c.unavailable[in.Account] {
+		return nil, errors.New("historical state not available")
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	values := md.Get(grpctypes.GRPCBlockHeightHeader)
+	if len(values) != 1 {
+		return nil, errors.New("no height requested")
+	}
+	height, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &paymenttypes.QueryOutFlowsResponse{OutFlows: c.outFlows[in.Account][height]}, nil
+}
+
+type PaymentHandlerTestSuite struct {
+	suite.Suite
+	db       *database.Impl
+	outFlows *outFlowsClient
+	module   *payment.Module
+	ctx      context.Context
+}
+
+func TestPaymentHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(PaymentHandlerTestSuite))
+}
+
+func (s *PaymentHandlerTestSuite) SetupTest() {
+	s.db = testutil.NewDB(s.T(), &models.StreamRecord{}, &models.PaymentAccount{}, &models.PaymentLedger{}, &models.StreamRecordHistory{}, &models.StreamRecordOutFlow{})
+	s.outFlows = &outFlowsClient{outFlows: make(map[string]map[int64][]paymenttypes.OutFlow), unavailable: make(map[string]bool)}
+	s.module = payment.NewModule(s.db, s.outFlows)
+	s.ctx = context.Background()
+}
+
+func (s *PaymentHandlerTestSuite) handle(height int64, txHash common.Hash, event proto.Message) {
+	testutil.HandleEvent(s.T(), s.ctx, s.module, height, txHash, event)
+}
+
+// TestLedger_TimeRange verifies that deposits, withdrawals and force settlements are recorded and queried by time range
+func (s *PaymentHandlerTestSuite) TestLedger_TimeRange() {
+	account := "0x00000000000000000000000000000000000000a1"
+	owner := "0x00000000000000000000000000000000000000b1"
+
+	s.handle(10, common.HexToHash("0x01"), &paymenttypes.EventDeposit{From: owner, To: account, Amount: math.NewInt(100)})
+	s.handle(20, common.HexToHash("0x02"), &paymenttypes.EventWithdraw{From: account, To: owner, Amount: math.NewInt(30)})
+	s.handle(30, common.HexToHash("0x03"), &paymenttypes.EventForceSettle{Addr: account, SettledBalance: math.NewInt(-5)})
+	s.handle(40, common.HexToHash("0x04"), &paymenttypes.EventDeposit{From: owner, To: "0x00000000000000000000000000000000000000a2", Amount: math.NewInt(1)})
+
+	ledger, err := s.db.GetPaymentLedger(s.ctx, common.HexToAddress(account), 1000, 2000)
+	s.Require().NoError(err)
+	s.Require().Len(ledger, 3)
+
+	s.Equal(models.PaymentLedgerKindDeposit, ledger[0].Kind)
+	s.Equal(common.HexToAddress(owner), ledger[0].Counterparty)
+	s.Equal(int64(100), ledger[0].Amount.Raw().Int64())
+	s.Equal(common.HexToHash("0x01"), ledger[0].TxHash)
+
+	s.Equal(models.PaymentLedgerKindWithdraw, ledger[1].Kind)
+	s.Equal(int64(30), ledger[1].Amount.Raw().Int64())
+
+	s.Equal(models.PaymentLedgerKindForceSettle, ledger[2].Kind)
+	s.Equal(common.BytesToAddress(paymenttypes.GovernanceAddress), ledger[2].Counterparty)
+	s.Equal(int64(-5), ledger[2].Amount.Raw().Int64())
+
+	ledger, err = s.db.GetPaymentLedger(s.ctx, common.HexToAddress(account), 1015, 1030)
+	s.Require().NoError(err)
+	s.Require().Len(ledger, 1)
+	s.Equal(int64(20), ledger[0].Height)
+}
+
+// TestLedger_EventProcessedAgain verifies that a payment event is recorded once when its block is processed again,
+// and that the events of a tx are recorded apart
+func (s *PaymentHandlerTestSuite) TestLedger_EventProcessedAgain() {
+	account := "0x00000000000000000000000000000000000000a3"
+	owner := "0x00000000000000000000000000000000000000b3"
+	txHash := common.HexToHash("0x05")
+
+	for _, index := range []int{0, 0, 1} {
+		ctx := database.WithEventPosition(s.ctx, database.EventPosition{Height: 10, TxHash: txHash, EventIndex: index})
+		testutil.HandleEvent(s.T(), ctx, s.module, 10, txHash, &paymenttypes.EventDeposit{From: owner, To: account, Amount: math.NewInt(100)})
+	}
+
+	ledger, err := s.db.GetPaymentLedger(s.ctx, common.HexToAddress(account), 1000, 2000)
+	s.Require().NoError(err)
+	s.Require().Len(ledger, 2)
+	s.Equal(0, ledger[0].EventIndex)
+	s.Equal(1, ledger[1].EventIndex)
+}
+
+func (s *PaymentHandlerTestSuite) streamRecordUpdate(account string, crudTimestamp int64, staticBalance int64) *paymenttypes.EventStreamRecordUpdate {
+	return &paymenttypes.EventStreamRecordUpdate{
+		Account:           account,
+		CrudTimestamp:     crudTimestamp,
+		NetflowRate:       math.NewInt(-2),
+		FrozenNetflowRate: math.ZeroInt(),
+		StaticBalance:     math.NewInt(staticBalance),
+		BufferBalance:     math.ZeroInt(),
+		LockBalance:       math.ZeroInt(),
+		Status:            paymenttypes.STREAM_ACCOUNT_STATUS_ACTIVE,
+	}
+}
+
+// TestStreamRecordUpdate_KeepsHistory verifies that every update of a stream record is kept per height
+func (s *PaymentHandlerTestSuite) TestStreamRecordUpdate_KeepsHistory() {
+	account := "0x00000000000000000000000000000000000000c1"
+
+	s.handle(10, common.HexToHash("0x11"), s.streamRecordUpdate(account, 1010, 100))
+	s.handle(20, common.HexToHash("0x12"), s.streamRecordUpdate(account, 1020, 80))
+	s.handle(20, common.HexToHash("0x13"), s.streamRecordUpdate(account, 1020, 70))
+	s.handle(30, common.HexToHash("0x14"), s.streamRecordUpdate(account, 1030, 50))
+
+	history, err := s.db.GetStreamRecordHistory(s.ctx, common.HexToAddress(account), 1000, 1030)
+	s.Require().NoError(err)
+	s.Require().Len(history, 2)
+	s.Equal(int64(100), history[0].StaticBalance.Raw().Int64())
+	s.Equal(int64(70), history[1].StaticBalance.Raw().Int64())
+	s.Equal(common.HexToHash("0x13"), history[1].TxHash)
+}
+
+// TestStreamRecordUpdate_OutFlows verifies that the out flows of a stream record are replaced by the ones
+// the chain returns at the height of the update
+func (s *PaymentHandlerTestSuite) TestStreamRecordUpdate_OutFlows() {
+	account := "0x00000000000000000000000000000000000000d1"
+	sp1 := "0x00000000000000000000000000000000000000e1"
+	sp2 := "0x00000000000000000000000000000000000000e2"
+
+	s.outFlows.outFlows[account] = map[int64][]paymenttypes.OutFlow{
+		10: {
+			{ToAddress: sp1, Rate: math.NewInt(1), Status: paymenttypes.OUT_FLOW_STATUS_ACTIVE},
+			{ToAddress: sp2, Rate: math.NewInt(1), Status: paymenttypes.OUT_FLOW_STATUS_FROZEN},
+		},
+		30: {
+			{ToAddress: sp2, Rate: math.NewInt(3), Status: paymenttypes.OUT_FLOW_STATUS_ACTIVE},
+		},
+	}
+	s.handle(10, common.HexToHash("0x21"), s.streamRecordUpdate(account, 1010, 100))
+
+	flows, err := s.db.GetStreamRecordOutFlows(s.ctx, common.HexToAddress(account))
+	s.Require().NoError(err)
+	s.Require().Len(flows, 2)
+	s.Equal(common.HexToAddress(sp1), flows[0].To)
+	s.Equal(int64(1), flows[0].Rate.Raw().Int64())
+	s.Equal(paymenttypes.OUT_FLOW_STATUS_FROZEN.String(), flows[1].Status)
+
+	var record models.StreamRecord
+	s.Require().NoError(s.db.Db.Where("account = ?", common.HexToAddress(account)).Take(&record).Error)
+	s.Equal(uint64(2), record.OutFlowCount)
+	s.Equal(int64(100), record.StaticBalance.Raw().Int64())
+
+	s.handle(30, common.HexToHash("0x23"), s.streamRecordUpdate(account, 1030, 60))
+
+	flows, err = s.db.GetStreamRecordOutFlows(s.ctx, common.HexToAddress(account))
+	s.Require().NoError(err)
+	s.Require().Len(flows, 1)
+	s.Equal(common.HexToAddress(sp2), flows[0].To)
+	s.Equal(int64(3), flows[0].Rate.Raw().Int64())
+	s.Equal(int64(30), flows[0].UpdateAt)
+
+	s.Require().NoError(s.db.Db.Where("account = ?", common.HexToAddress(account)).Take(&record).Error)
+	s.Equal(uint64(1), record.OutFlowCount)
+}
+
+// TestStreamRecordUpdate_NoOutFlowsClient verifies that the known out flows are kept when they are not read from the chain
+func (s *PaymentHandlerTestSuite) TestStreamRecordUpdate_NoOutFlowsClient() {
+	account := "0x00000000000000000000000000000000000000d2"
+	sp := "0x00000000000000000000000000000000000000e3"
+
+	s.outFlows.outFlows[account] = map[int64][]paymenttypes.OutFlow{
+		10: {{ToAddress: sp, Rate: math.NewInt(1), Status: paymenttypes.OUT_FLOW_STATUS_ACTIVE}},
+	}
+	s.handle(10, common.HexToHash("0x31"), s.streamRecordUpdate(account, 1010, 100))
+
+	s.module = payment.NewModule(s.db, nil)
+	s.handle(20, common.HexToHash("0x32"), s.streamRecordUpdate(account, 1020, 80))
+
+	flows, err := s.db.GetStreamRecordOutFlows(s.ctx, common.HexToAddress(account))
+	s.Require().NoError(err)
+	s.Require().Len(flows, 1)
+	s.Equal(common.HexToAddress(sp), flows[0].To)
+	s.Equal(int64(10), flows[0].UpdateAt)
+}
+
+// TestStreamRecordUpdate_OutFlowsQueriedOncePerBlock verifies that the out flows of an account updated several times
+// in a block are read from the chain once
+func (s *PaymentHandlerTestSuite) TestStreamRecordUpdate_OutFlowsQueriedOncePerBlock() {
+	account := "0x00000000000000000000000000000000000000d3"
+	other := "0x00000000000000000000000000000000000000d4"
+
+	s.handle(10, common.HexToHash("0x41"), s.streamRecordUpdate(account, 1010, 100))
+	s.handle(10, common.HexToHash("0x42"), s.streamRecordUpdate(account, 1010, 90))
+	s.handle(10, common.HexToHash("0x44"), s.streamRecordUpdate(other, 1010, 90))
+	s.Equal(2, s.outFlows.queries)
+
+	s.handle(20, common.HexToHash("0x43"), s.streamRecordUpdate(account, 1020, 80))
+	s.Equal(3, s.outFlows.queries)
+}
+
+// TestStreamRecordUpdate_OutFlowsUnavailable verifies that the stream record is recorded, and the known out flows
+// kept, when the out flows cannot be read from the chain at the height of the update
+func (s *PaymentHandlerTestSuite) TestStreamRecordUpdate_OutFlowsUnavailable() {
+	account := "0x00000000000000000000000000000000000000d5"
+	sp := "0x00000000000000000000000000000000000000e4"
+
+	s.outFlows.outFlows[account] = map[int64][]paymenttypes.OutFlow{
+		10: {{ToAddress: sp, Rate: math.NewInt(1), Status: paymenttypes.OUT_FLOW_STATUS_ACTIVE}},
+	}
+	s.handle(10, common.HexToHash("0x51"), s.streamRecordUpdate(account, 1010, 100))
+
+	// the node pruned the state of the block
+	s.outFlows.unavailable[account] = true
+	s.handle(20, common.HexToHash("0x52"), s.streamRecordUpdate(account, 1020, 80))
+	s.handle(20, common.HexToHash("0x53"), s.streamRecordUpdate(account, 1020, 70))
+	s.Equal(2, s.outFlows.queries)
+
+	var record models.StreamRecord
+	s.Require().NoError(s.db.Db.Where("account = ?", common.HexToAddress(account)).Take(&record).Error)
+	s.Equal(int64(70), record.StaticBalance.Raw().Int64())
+	history, err := s.db.GetStreamRecordHistory(s.ctx, common.HexToAddress(account), 1000, 1030)
+	s.Require().NoError(err)
+	s.Len(history, 2)
+
+	flows, err := s.db.GetStreamRecordOutFlows(s.ctx, common.HexToAddress(account))
+	s.Require().NoError(err)
+	s.Require().Len(flows, 1)
+	s.Equal(common.HexToAddress(sp), flows[0].To)
+	s.Equal(int64(10), flows[0].UpdateAt)
+}
@@ -0,0 +1,261 @@
+package permission
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"regexp"
+	"time"
+
+	evmtypes "github.com/evmos/evmos/v12/types"
+	"github.com/evmos/evmos/v12/types/resource"
+	permissiontypes "github.com/evmos/evmos/v12/x/permission/types"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/log"
+	"github.com/forbole/juno/v4/models"
+)
+
+// Decision is the result of an access check
+type Decision struct {
+	// Effect is either EFFECT_ALLOW or EFFECT_DENY
+	Effect permissiontypes.Effect
+	// Owner is true when the principal was allowed as the owner of the resource
+	Owner bool
+	// Permission and Statement are the policy and statement the effect comes from.
+	// Both are nil when the principal is the owner or no statement matched.
+	Permission *models.Permission
+	Statement  *models.Statements
+}
+
+// Evaluator answers access checks from the indexed policies, following the rules of the chain:
+// the owner has full permissions, a deny statement wins over allow ones, the account's own policy
+// is checked before the policies of its groups, and objects also inherit the policies of their bucket.
+type Evaluator struct {
+	db database.Database
+}
+
+// NewEvaluator builds a new Evaluator instance
+func NewEvaluator(db database.Database) *Evaluator {
+	return &Evaluator{
+		db: db,
+	}
+}
+
+// Evaluate checks whether the principal is allowed to perform the action on the resource at the given time
+func (e *Evaluator) Evaluate(ctx context.Context, principal *permissiontypes.Principal, resourceType resource.ResourceType,
+	resourceID common.Hash, action permissiontypes.ActionType, at time.Time,
+) (*Decision, error) {
+	switch resourceType {
+	case resource.RESOURCE_TYPE_BUCKET:
+		bucket, err := e.db.GetBucket(ctx, resourceID)
+		if err != nil || bucket == nil {
+			return deny(), err
+		}
+		if isOwner(principal, bucket.Owner) {
+			return &Decision{Effect: permissiontypes.EFFECT_ALLOW, Owner: true}, nil
+		}
+		return e.verifyPolicy(ctx, principal, resourceType, resourceID, action, "", at)
+
+	case resource.RESOURCE_TYPE_OBJECT:
+		object, err := e.db.GetObject(ctx, resourceID)
+		if err != nil || object == nil {
+			return deny(), err
+		}
+		if isOwner(principal, object.Owner) {
+			return &Decision{Effect: permissiontypes.EFFECT_ALLOW, Owner: true}, nil
+		}
+
+		// the bucket policy applies to the object through the statements whose resources match it
+		grn := evmtypes.NewObjectGRN(object.BucketName, object.ObjectName).String()
+		bucketDecision, err := e.verifyPolicy(ctx, principal, resource.RESOURCE_TYPE_BUCKET, object.BucketID, action, grn, at)
+		if err != nil || (bucketDecision.Effect == permissiontypes.EFFECT_DENY && bucketDecision.Statement != nil) {
+			return bucketDecision, err
+		}
+		// an explicit allow or deny of the object policy takes precedence over the bucket policy
+		objectDecision, err := e.verifyPolicy(ctx, principal, resourceType, resourceID, action, "", at)
+		if err != nil || objectDecision.Statement != nil {
+			return objectDecision, err
+		}
+		return bucketDecision, nil
+
+	case resource.RESOURCE_TYPE_GROUP:
+		group, err := e.db.GetGroup(ctx, resourceID)
+		if err != nil || group == nil {
+			return deny(), err
+		}
+		if isOwner(principal, group.Owner) {
+			return &Decision{Effect: permissiontypes.EFFECT_ALLOW, Owner: true}, nil
+		}
+		return e.verifyPolicy(ctx, principal, resourceType, resourceID, action, "", at)
+	}
+
+	return nil, fmt.Errorf("unsupported resource type %s", resourceType.String())
+}
+
+// verifyPolicy evaluates the policies of a single resource. An unspecified effect is reported as a deny without statement.
+func (e *Evaluator) verifyPolicy(ctx context.Context, principal *permissiontypes.Principal, resourceType resource.ResourceType,
+	resourceID common.Hash, action permissiontypes.ActionType, grn string, at time.Time,
+) (*Decision, error) {
+	permissions, err := e.db.GetPermissionsByResource(ctx, resourceType.String(), resourceID)
+	if err != nil || len(permissions) == 0 {
+		return deny(), err
+	}
+
+	policyIDs := make([]common.Hash, 0, len(permissions))
+	for _, p := range permissions {
+		policyIDs = append(policyIDs, p.PolicyID)
+	}
+	statements, err := e.db.GetStatementsByPolicyIDs(ctx, policyIDs)
+	if err != nil {
+		return nil, err
+	}
+	statementsByPolicy := make(map[common.Hash][]*models.Statements, len(permissions))
+	for _, s := range statements {
+		statementsByPolicy[s.PolicyID] = append(statementsByPolicy[s.PolicyID], s)
+	}
+
+	// the policy granted to the principal itself
+	for _, p := range permissions {
+		if !principalMatches(principal, p) {
+			continue
+		}
+		if principal.Type == permissiontypes.PRINCIPAL_TYPE_GNFD_GROUP && !e.groupExists(ctx, p.PrincipalValue) {
+			continue
+		}
+		effect, statement := evalPolicy(p, statementsByPolicy[p.PolicyID], action, grn, at)
+		if effect != permissiontypes.EFFECT_UNSPECIFIED {
+			return &Decision{Effect: effect, Permission: p, Statement: statement}, nil
+		}
+	}
+	if principal.Type != permissiontypes.PRINCIPAL_TYPE_GNFD_ACCOUNT {
+		return deny(), nil
+	}
+
+	// the policies granted to the groups the account is a member of
+	account := common.HexToAddress(principal.Value)
+	var allowed *Decision
+	for _, p := range permissions {
+		if p.PrincipalType != int32(permissiontypes.PRINCIPAL_TYPE_GNFD_GROUP) || !e.groupExists(ctx, p.PrincipalValue) {
+			continue
+		}
+		effect, statement := evalPolicy(p, statementsByPolicy[p.PolicyID], action, grn, at)
+		if effect == permissiontypes.EFFECT_UNSPECIFIED {
+			continue
+		}
+		member, err := e.db.GetGroupMember(ctx, groupIDFromPrincipal(p.PrincipalValue), account)
+		if err != nil {
+			return nil, err
+		}
+		if member == nil || (member.ExpirationTime != 0 && member.ExpirationTime <= at.Unix()) {
+			continue
+		}
+		if effect == permissiontypes.EFFECT_DENY {
+			return &Decision{Effect: effect, Permission: p, Statement: statement}, nil
+		}
+		if allowed == nil {
+			allowed = &Decision{Effect: effect, Permission: p, Statement: statement}
+		}
+	}
+	if allowed != nil {
+		return allowed, nil
+	}
+	return deny(), nil
+}
+
+func (e *Evaluator) groupExists(ctx context.Context, principalValue string) bool {
+	group, err := e.db.GetGroup(ctx, groupIDFromPrincipal(principalValue))
+	if err != nil {
+		log.Errorw("failed to get group", "group_id", principalValue, "err", err)
+		return false
+	}
+	return group != nil
+}
+
+// evalPolicy returns the effect of the policy for the action, with the statement it comes from
+func evalPolicy(p *models.Permission, statements []*models.Statements, action permissiontypes.ActionType, grn string, at time.Time) (permissiontypes.Effect, *models.Statements) {
+	if p.ExpirationTime != 0 && p.ExpirationTime < at.Unix() {
+		return permissiontypes.EFFECT_UNSPECIFIED, nil
+	}
+
+	var allowed *models.Statements
+	for _, s := range statements {
+		if s.ExpirationTime != 0 && s.ExpirationTime < at.Unix() {
+			continue
+		}
+		switch evalStatement(s, action, grn) {
+		case permissiontypes.EFFECT_DENY:
+			return permissiontypes.EFFECT_DENY, s
+		case permissiontypes.EFFECT_ALLOW:
+			if allowed == nil {
+				allowed = s
+			}
+		}
+	}
+	if allowed != nil {
+		return permissiontypes.EFFECT_ALLOW, allowed
+	}
+	return permissiontypes.EFFECT_UNSPECIFIED, nil
+}
+
+// evalStatement returns the effect of the statement for the action. When grn is set, the statement only
+// applies if one of its resource patterns matches it.
+func evalStatement(s *models.Statements, action permissiontypes.ActionType, grn string) permissiontypes.Effect {
+	if grn != "" {
+		matched := false
+		for _, res := range s.Resources {
+			reg, err := regexp.Compile(res)
+			if err != nil {
+				continue
+			}
+			if reg.MatchString(grn) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return permissiontypes.EFFECT_UNSPECIFIED
+		}
+	}
+
+	value, ok := actionTypeMap[action]
+	if !ok {
+		return permissiontypes.EFFECT_UNSPECIFIED
+	}
+	allValue := actionTypeMap[permissiontypes.ACTION_TYPE_ALL]
+	if s.ActionValue&(1<<value) == 0 && s.ActionValue&(1<<allValue) == 0 {
+		return permissiontypes.EFFECT_UNSPECIFIED
+	}
+	return permissiontypes.Effect(permissiontypes.Effect_value[s.Effect])
+}
+
+func principalMatches(principal *permissiontypes.Principal, p *models.Permission) bool {
+	if p.PrincipalType != int32(principal.Type) {
+		return false
+	}
+	switch principal.Type {
+	case permissiontypes.PRINCIPAL_TYPE_GNFD_ACCOUNT:
+		return common.HexToAddress(p.PrincipalValue) == common.HexToAddress(principal.Value)
+	case permissiontypes.PRINCIPAL_TYPE_GNFD_GROUP:
+		return groupIDFromPrincipal(p.PrincipalValue) == groupIDFromPrincipal(principal.Value)
+	}
+	return false
+}
+
+func isOwner(principal *permissiontypes.Principal, owner common.Address) bool {
+	return principal.Type == permissiontypes.PRINCIPAL_TYPE_GNFD_ACCOUNT && common.HexToAddress(principal.Value) == owner
+}
+
+// groupIDFromPrincipal converts the decimal group id of a principal to the form stored in the groups table
+func groupIDFromPrincipal(value string) common.Hash {
+	id, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return common.Hash{}
+	}
+	return common.BigToHash(id)
+}
+
+func deny() *Decision {
+	return &Decision{Effect: permissiontypes.EFFECT_DENY}
+}
@@ -0,0 +1,51 @@
+package permission
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-co-op/gocron"
+
+	"github.com/forbole/juno/v4/log"
+)
+
+// sweepInterval is the interval between two sweeps of the expired policies
+const sweepInterval = 10 * time.Minute
+
+// RegisterPeriodicOperations implements modules.PeriodicOperationsModule
+func (m *Module) RegisterPeriodicOperations(scheduler *gocron.Scheduler) error {
+	log.Debugw("setting up periodic tasks", "module", m.Name())
+
+	if _, err := scheduler.Every(sweepInterval).Do(func() {
+		m.SweepExpiredPolicies(context.Background())
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SweepExpiredPolicies marks the policies and statements which expired before the time of the last indexed block
+// as removed. The chain time is used, so that the policies are not removed before the chain expires them while
+// the indexer catches up.
+func (m *Module) SweepExpiredPolicies(ctx context.Context) {
+	epoch, err := m.db.GetEpoch(ctx)
+	if err != nil {
+		log.Errorw("failed to get the last indexed block", "module", m.Name(), "err", err)
+		return
+	}
+	if epoch.BlockHeight <= 0 {
+		return
+	}
+
+	now := epoch.UpdateTime
+	policies, statements, err := m.db.RemoveExpiredPolicies(ctx, now)
+	if err != nil {
+		log.Errorw("failed to sweep expired policies", "module", m.Name(), "err", err)
+		return
+	}
+
+	log.PermissionSweptCount.WithLabelValues("policies").Add(float64(policies))
+	log.PermissionSweptCount.WithLabelValues("statements").Add(float64(statements))
+	log.PermissionLastSweep.Set(float64(now))
+	log.Infow("swept expired policies", "module", m.Name(), "policies", policies, "statements", statements)
+}
@@ -15,8 +15,9 @@ const (
 )
 
 var (
-	_ modules.Module              = &Module{}
-	_ modules.PrepareTablesModule = &Module{}
+	_ modules.Module                   = &Module{}
+	_ modules.PrepareTablesModule      = &Module{}
+	_ modules.PeriodicOperationsModule = &Module{}
 )
 
 // Module represents the payment module
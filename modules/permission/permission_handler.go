@@ -8,7 +8,9 @@ import (
 	tmctypes "github.com/cometbft/cometbft/rpc/core/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/gogoproto/proto"
+	"github.com/evmos/evmos/v12/types/resource"
 	permissiontypes "github.com/evmos/evmos/v12/x/permission/types"
+	storagetypes "github.com/evmos/evmos/v12/x/storage/types"
 
 	"github.com/forbole/juno/v4/common"
 	"github.com/forbole/juno/v4/log"
@@ -16,13 +18,21 @@ import (
 )
 
 var (
-	EventPutPolicy    = proto.MessageName(&permissiontypes.EventPutPolicy{})
-	EventDeletePolicy = proto.MessageName(&permissiontypes.EventDeletePolicy{})
+	EventPutPolicy          = proto.MessageName(&permissiontypes.EventPutPolicy{})
+	EventDeletePolicy       = proto.MessageName(&permissiontypes.EventDeletePolicy{})
+	EventStalePolicyCleanup = proto.MessageName(&storagetypes.EventStalePolicyCleanup{})
+	EventDeleteBucket       = proto.MessageName(&storagetypes.EventDeleteBucket{})
+	EventDeleteObject       = proto.MessageName(&storagetypes.EventDeleteObject{})
+	EventDeleteGroup        = proto.MessageName(&storagetypes.EventDeleteGroup{})
 )
 
 var PolicyEvents = map[string]bool{
-	EventPutPolicy:    true,
-	EventDeletePolicy: true,
+	EventPutPolicy:          true,
+	EventDeletePolicy:       true,
+	EventStalePolicyCleanup: true,
+	EventDeleteBucket:       true,
+	EventDeleteObject:       true,
+	EventDeleteGroup:        true,
 }
 
 var actionTypeMap = map[permissiontypes.ActionType]int{
@@ -69,6 +79,34 @@ func (m *Module) HandleEvent(ctx context.Context, block *tmctypes.ResultBlock, _
 			return errors.New("cancel delete policy event assert error")
 		}
 		return m.handleDeletePolicy(ctx, block, deletePolicy)
+	case EventStalePolicyCleanup:
+		cleanup, ok := typedEvent.(*storagetypes.EventStalePolicyCleanup)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventStalePolicyCleanup", "event", typedEvent)
+			return errors.New("stale policy cleanup event assert error")
+		}
+		return m.handleStalePolicyCleanup(ctx, block, cleanup)
+	case EventDeleteBucket:
+		deleteBucket, ok := typedEvent.(*storagetypes.EventDeleteBucket)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventDeleteBucket", "event", typedEvent)
+			return errors.New("delete bucket event assert error")
+		}
+		return m.removeResourcePolicies(ctx, block, resource.RESOURCE_TYPE_BUCKET, []common.Hash{common.BigToHash(deleteBucket.BucketId.BigInt())})
+	case EventDeleteObject:
+		deleteObject, ok := typedEvent.(*storagetypes.EventDeleteObject)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventDeleteObject", "event", typedEvent)
+			return errors.New("delete object event assert error")
+		}
+		return m.removeResourcePolicies(ctx, block, resource.RESOURCE_TYPE_OBJECT, []common.Hash{common.BigToHash(deleteObject.ObjectId.BigInt())})
+	case EventDeleteGroup:
+		deleteGroup, ok := typedEvent.(*storagetypes.EventDeleteGroup)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventDeleteGroup", "event", typedEvent)
+			return errors.New("delete group event assert error")
+		}
+		return m.removeResourcePolicies(ctx, block, resource.RESOURCE_TYPE_GROUP, []common.Hash{common.BigToHash(deleteGroup.GroupId.BigInt())})
 	}
 
 	return nil
@@ -149,3 +187,40 @@ func (m *Module) handleDeletePolicy(ctx context.Context, block *tmctypes.ResultB
 	}
 	return nil
 }
+
+// handleStalePolicyCleanup removes the policies the chain garbage collected for deleted resources
+func (m *Module) handleStalePolicyCleanup(ctx context.Context, block *tmctypes.ResultBlock, event *storagetypes.EventStalePolicyCleanup) error {
+	if event.DeleteInfo == nil {
+		return nil
+	}
+
+	tx := m.db.Begin(ctx)
+	for resourceType, ids := range map[resource.ResourceType]*storagetypes.Ids{
+		resource.RESOURCE_TYPE_BUCKET: event.DeleteInfo.BucketIds,
+		resource.RESOURCE_TYPE_OBJECT: event.DeleteInfo.ObjectIds,
+		resource.RESOURCE_TYPE_GROUP:  event.DeleteInfo.GroupIds,
+	} {
+		if ids == nil {
+			continue
+		}
+		resourceIDs := make([]common.Hash, 0, len(ids.Id))
+		for _, id := range ids.Id {
+			resourceIDs = append(resourceIDs, common.BigToHash(id.BigInt()))
+		}
+		if _, err := tx.RemovePoliciesByResources(ctx, resourceType.String(), resourceIDs, block.Block.Time.Unix()); err != nil {
+			tx.Rollback()
+			log.Errorw("failed to clean up stale policies", "resource_type", resourceType.String(), "err", err)
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// removeResourcePolicies removes the policies of deleted resources, along with their statements
+func (m *Module) removeResourcePolicies(ctx context.Context, block *tmctypes.ResultBlock, resourceType resource.ResourceType, resourceIDs []common.Hash) error {
+	_, err := m.db.RemovePoliciesByResources(ctx, resourceType.String(), resourceIDs, block.Block.Time.Unix())
+	if err != nil {
+		log.Errorw("failed to remove resource policies", "resource_type", resourceType.String(), "err", err)
+	}
+	return err
+}
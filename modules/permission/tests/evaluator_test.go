@@ -0,0 +1,265 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/evmos/evmos/v12/types/resource"
+	permissiontypes "github.com/evmos/evmos/v12/x/permission/types"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/modules/permission"
+	"github.com/forbole/juno/v4/modules/testutil"
+)
+
+const (
+	owner    = "0x00000000000000000000000000000000000000A1"
+	grantee  = "0x00000000000000000000000000000000000000A2"
+	stranger = "0x00000000000000000000000000000000000000A3"
+)
+
+// MockDB serves the buckets and objects from memory, the policies and groups from sqlite
+type MockDB struct {
+	*database.Impl
+	buckets map[common.Hash]*models.Bucket
+	objects map[common.Hash]*models.Object
+}
+
+func (db *MockDB) GetBucket(ctx context.Context, bucketId common.Hash) (*models.Bucket, error) {
+	return db.buckets[bucketId], nil
+}
+
+func (db *MockDB) GetObject(ctx context.Context, objectId common.Hash) (*models.Object, error) {
+	return db.objects[objectId], nil
+}
+
+func NewMockDB(t testing.TB) *MockDB {
+	return &MockDB{
+		Impl:    testutil.NewDB(t, &models.Permission{}, &models.Statements{}, &models.Group{}, &models.Epoch{}, &models.SyncRange{}),
+		buckets: make(map[common.Hash]*models.Bucket),
+		objects: make(map[common.Hash]*models.Object),
+	}
+}
+
+type EvaluatorTestSuite struct {
+	suite.Suite
+	db        *MockDB
+	module    *permission.Module
+	evaluator *permission.Evaluator
+	ctx       context.Context
+}
+
+func TestEvaluatorTestSuite(t *testing.T) {
+	suite.Run(t, new(EvaluatorTestSuite))
+}
+
+func (s *EvaluatorTestSuite) SetupTest() {
+	s.db = NewMockDB(s.T())
+	s.module = permission.NewModule(s.db)
+	s.evaluator = permission.NewEvaluator(s.db)
+	s.ctx = context.Background()
+}
+
+func (s *EvaluatorTestSuite) putPolicy(event *permissiontypes.EventPutPolicy) {
+	testutil.HandleEvent(s.T(), s.ctx, s.module, 10, common.Hash{}, event)
+}
+
+func (s *EvaluatorTestSuite) addBucket(bucketID int64, name string) {
+	id := common.BigToHash(math.NewInt(bucketID).BigInt())
+	s.db.buckets[id] = &models.Bucket{BucketID: id, BucketName: name, Owner: common.HexToAddress(owner)}
+}
+
+func (s *EvaluatorTestSuite) addObject(bucketID, objectID int64, bucketName, objectName string) {
+	id := common.BigToHash(math.NewInt(objectID).BigInt())
+	s.db.objects[id] = &models.Object{
+		ID:         uint64(objectID),
+		BucketID:   common.BigToHash(math.NewInt(bucketID).BigInt()),
+		BucketName: bucketName,
+		ObjectID:   id,
+		ObjectName: objectName,
+		Owner:      common.HexToAddress(owner),
+	}
+}
+
+func (s *EvaluatorTestSuite) addGroupMember(groupID int64, account string, expirationTime int64) {
+	s.Require().NoError(s.db.Db.Create(&models.Group{
+		GroupID:        common.BigToHash(math.NewInt(groupID).BigInt()),
+		AccountID:      common.HexToAddress(account),
+		Owner:          common.HexToAddress(owner),
+		ExpirationTime: expirationTime,
+	}).Error)
+}
+
+func (s *EvaluatorTestSuite) evaluate(principal *permissiontypes.Principal, resourceType resource.ResourceType, resourceID int64, action permissiontypes.ActionType, at int64) *permission.Decision {
+	decision, err := s.evaluator.Evaluate(s.ctx, principal, resourceType, common.BigToHash(math.NewInt(resourceID).BigInt()), action, time.Unix(at, 0))
+	s.Require().NoError(err)
+	return decision
+}
+
+func account(addr string) *permissiontypes.Principal {
+	return &permissiontypes.Principal{Type: permissiontypes.PRINCIPAL_TYPE_GNFD_ACCOUNT, Value: addr}
+}
+
+func group(id string) *permissiontypes.Principal {
+	return &permissiontypes.Principal{Type: permissiontypes.PRINCIPAL_TYPE_GNFD_GROUP, Value: id}
+}
+
+// TestBucketPolicy_OwnerAndAccount verifies the owner override and the allow/deny statements of an account policy
+func (s *EvaluatorTestSuite) TestBucketPolicy_OwnerAndAccount() {
+	s.addBucket(101, "bucket-a")
+	s.putPolicy(&permissiontypes.EventPutPolicy{
+		Principal:    account(grantee),
+		ResourceType: resource.RESOURCE_TYPE_BUCKET,
+		ResourceId:   math.NewUint(101),
+		PolicyId:     math.NewUint(1001),
+		Statements: []*permissiontypes.Statement{
+			{Effect: permissiontypes.EFFECT_ALLOW, Actions: []permissiontypes.ActionType{permissiontypes.ACTION_UPDATE_BUCKET_INFO}},
+			{Effect: permissiontypes.EFFECT_DENY, Actions: []permissiontypes.ActionType{permissiontypes.ACTION_DELETE_BUCKET}},
+		},
+	})
+
+	decision := s.evaluate(account(owner), resource.RESOURCE_TYPE_BUCKET, 101, permissiontypes.ACTION_DELETE_BUCKET, 2000)
+	s.Equal(permissiontypes.EFFECT_ALLOW, decision.Effect)
+	s.True(decision.Owner)
+
+	decision = s.evaluate(account(grantee), resource.RESOURCE_TYPE_BUCKET, 101, permissiontypes.ACTION_UPDATE_BUCKET_INFO, 2000)
+	s.Equal(permissiontypes.EFFECT_ALLOW, decision.Effect)
+	s.Require().NotNil(decision.Permission)
+	s.Equal(common.BigToHash(math.NewInt(1001).BigInt()), decision.Permission.PolicyID)
+
+	decision = s.evaluate(account(grantee), resource.RESOURCE_TYPE_BUCKET, 101, permissiontypes.ACTION_DELETE_BUCKET, 2000)
+	s.Equal(permissiontypes.EFFECT_DENY, decision.Effect)
+	s.NotNil(decision.Statement)
+
+	decision = s.evaluate(account(stranger), resource.RESOURCE_TYPE_BUCKET, 101, permissiontypes.ACTION_UPDATE_BUCKET_INFO, 2000)
+	s.Equal(permissiontypes.EFFECT_DENY, decision.Effect)
+	s.Nil(decision.Statement)
+}
+
+// TestBucketPolicy_GroupMembership verifies that group policies only apply to unexpired members
+func (s *EvaluatorTestSuite) TestBucketPolicy_GroupMembership() {
+	s.addBucket(102, "bucket-b")
+	s.addGroupMember(201, "0x0", 0)
+	s.addGroupMember(201, grantee, 0)
+	s.addGroupMember(201, stranger, 1500)
+	s.putPolicy(&permissiontypes.EventPutPolicy{
+		Principal:    group("201"),
+		ResourceType: resource.RESOURCE_TYPE_BUCKET,
+		ResourceId:   math.NewUint(102),
+		PolicyId:     math.NewUint(1002),
+		Statements: []*permissiontypes.Statement{
+			{Effect: permissiontypes.EFFECT_ALLOW, Actions: []permissiontypes.ActionType{permissiontypes.ACTION_TYPE_ALL}},
+		},
+	})
+
+	decision := s.evaluate(account(grantee), resource.RESOURCE_TYPE_BUCKET, 102, permissiontypes.ACTION_LIST_OBJECT, 2000)
+	s.Equal(permissiontypes.EFFECT_ALLOW, decision.Effect)
+
+	decision = s.evaluate(account(stranger), resource.RESOURCE_TYPE_BUCKET, 102, permissiontypes.ACTION_LIST_OBJECT, 1400)
+	s.Equal(permissiontypes.EFFECT_ALLOW, decision.Effect)
+
+	decision = s.evaluate(account(stranger), resource.RESOURCE_TYPE_BUCKET, 102, permissiontypes.ACTION_LIST_OBJECT, 2000)
+	s.Equal(permissiontypes.EFFECT_DENY, decision.Effect)
+
+	decision = s.evaluate(group("201"), resource.RESOURCE_TYPE_BUCKET, 102, permissiontypes.ACTION_LIST_OBJECT, 2000)
+	s.Equal(permissiontypes.EFFECT_ALLOW, decision.Effect)
+}
+
+// TestObjectPolicy_InheritsBucketPolicy verifies that bucket statements apply to the objects matching their resources
+func (s *EvaluatorTestSuite) TestObjectPolicy_InheritsBucketPolicy() {
+	s.addBucket(103, "bucket-c")
+	s.addObject(103, 301, "bucket-c", "public/a.txt")
+	s.addObject(103, 302, "bucket-c", "private/b.txt")
+	s.putPolicy(&permissiontypes.EventPutPolicy{
+		Principal:    account(grantee),
+		ResourceType: resource.RESOURCE_TYPE_BUCKET,
+		ResourceId:   math.NewUint(103),
+		PolicyId:     math.NewUint(1003),
+		Statements: []*permissiontypes.Statement{
+			{
+				Effect:    permissiontypes.EFFECT_ALLOW,
+				Actions:   []permissiontypes.ActionType{permissiontypes.ACTION_GET_OBJECT},
+				Resources: []string{"grn:o::bucket-c/public/.*"},
+			},
+		},
+	})
+	s.putPolicy(&permissiontypes.EventPutPolicy{
+		Principal:    account(grantee),
+		ResourceType: resource.RESOURCE_TYPE_OBJECT,
+		ResourceId:   math.NewUint(302),
+		PolicyId:     math.NewUint(1004),
+		Statements: []*permissiontypes.Statement{
+			{Effect: permissiontypes.EFFECT_ALLOW, Actions: []permissiontypes.ActionType{permissiontypes.ACTION_GET_OBJECT}},
+		},
+	})
+
+	decision := s.evaluate(account(grantee), resource.RESOURCE_TYPE_OBJECT, 301, permissiontypes.ACTION_GET_OBJECT, 2000)
+	s.Equal(permissiontypes.EFFECT_ALLOW, decision.Effect)
+	s.Equal(common.BigToHash(math.NewInt(1003).BigInt()), decision.Permission.PolicyID)
+
+	decision = s.evaluate(account(grantee), resource.RESOURCE_TYPE_OBJECT, 302, permissiontypes.ACTION_GET_OBJECT, 2000)
+	s.Equal(permissiontypes.EFFECT_ALLOW, decision.Effect)
+	s.Equal(common.BigToHash(math.NewInt(1004).BigInt()), decision.Permission.PolicyID)
+
+	decision = s.evaluate(account(grantee), resource.RESOURCE_TYPE_OBJECT, 301, permissiontypes.ACTION_DELETE_OBJECT, 2000)
+	s.Equal(permissiontypes.EFFECT_DENY, decision.Effect)
+}
+
+// TestPolicy_Expiration verifies that expired policies and statements are ignored
+func (s *EvaluatorTestSuite) TestPolicy_Expiration() {
+	policyExpiration := time.Unix(3000, 0)
+	statementExpiration := time.Unix(2000, 0)
+	s.addBucket(104, "bucket-d")
+	s.putPolicy(&permissiontypes.EventPutPolicy{
+		Principal:      account(grantee),
+		ResourceType:   resource.RESOURCE_TYPE_BUCKET,
+		ResourceId:     math.NewUint(104),
+		PolicyId:       math.NewUint(1005),
+		ExpirationTime: &policyExpiration,
+		Statements: []*permissiontypes.Statement{
+			{Effect: permissiontypes.EFFECT_ALLOW, Actions: []permissiontypes.ActionType{permissiontypes.ACTION_LIST_OBJECT}},
+			{
+				Effect:         permissiontypes.EFFECT_DENY,
+				Actions:        []permissiontypes.ActionType{permissiontypes.ACTION_LIST_OBJECT},
+				ExpirationTime: &statementExpiration,
+			},
+		},
+	})
+
+	decision := s.evaluate(account(grantee), resource.RESOURCE_TYPE_BUCKET, 104, permissiontypes.ACTION_LIST_OBJECT, 1500)
+	s.Equal(permissiontypes.EFFECT_DENY, decision.Effect)
+	s.NotNil(decision.Statement)
+
+	decision = s.evaluate(account(grantee), resource.RESOURCE_TYPE_BUCKET, 104, permissiontypes.ACTION_LIST_OBJECT, 2500)
+	s.Equal(permissiontypes.EFFECT_ALLOW, decision.Effect)
+
+	decision = s.evaluate(account(grantee), resource.RESOURCE_TYPE_BUCKET, 104, permissiontypes.ACTION_LIST_OBJECT, 3500)
+	s.Equal(permissiontypes.EFFECT_DENY, decision.Effect)
+	s.Nil(decision.Statement)
+}
+
+// TestPutPolicy_ProcessedAgain verifies that the statements of a policy are saved once when its block is processed again
+func (s *EvaluatorTestSuite) TestPutPolicy_ProcessedAgain() {
+	s.addBucket(101, "bucket-a")
+	for i := 0; i < 2; i++ {
+		s.putPolicy(&permissiontypes.EventPutPolicy{
+			Principal:    account(grantee),
+			ResourceType: resource.RESOURCE_TYPE_BUCKET,
+			ResourceId:   math.NewUint(101),
+			PolicyId:     math.NewUint(1001),
+			Statements: []*permissiontypes.Statement{
+				{Effect: permissiontypes.EFFECT_ALLOW, Actions: []permissiontypes.ActionType{permissiontypes.ACTION_UPDATE_BUCKET_INFO}},
+				{Effect: permissiontypes.EFFECT_DENY, Actions: []permissiontypes.ActionType{permissiontypes.ACTION_DELETE_BUCKET}},
+			},
+		})
+	}
+
+	var count int64
+	s.Require().NoError(s.db.Db.Model(&models.Statements{}).Where("policy_id = ?", common.BigToHash(math.NewInt(1001).BigInt())).Count(&count).Error)
+	s.Equal(int64(2), count)
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cosmossdk.io/math"
+	abci "github.com/cometbft/cometbft/abci/types"
+	tmctypes "github.com/cometbft/cometbft/rpc/core/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/gogoproto/proto"
+	"github.com/evmos/evmos/v12/types/resource"
+	permissiontypes "github.com/evmos/evmos/v12/x/permission/types"
+	storagetypes "github.com/evmos/evmos/v12/x/storage/types"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/modules/permission"
+	"github.com/forbole/juno/v4/modules/testutil"
+)
+
+type PermissionHandlerTestSuite struct {
+	suite.Suite
+	db     *MockDB
+	module *permission.Module
+	ctx    context.Context
+}
+
+func TestPermissionHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(PermissionHandlerTestSuite))
+}
+
+func (s *PermissionHandlerTestSuite) SetupTest() {
+	s.db = NewMockDB(s.T())
+	s.module = permission.NewModule(s.db)
+	s.ctx = context.Background()
+}
+
+func (s *PermissionHandlerTestSuite) handle(height int64, event proto.Message) {
+	testutil.HandleEvent(s.T(), s.ctx, s.module, height, common.Hash{}, event)
+}
+
+func (s *PermissionHandlerTestSuite) putPolicy(resourceType resource.ResourceType, resourceID, policyID uint64, expirationTime *time.Time, statementExpirationTime *time.Time) {
+	s.handle(10, &permissiontypes.EventPutPolicy{
+		Principal:      account(grantee),
+		ResourceType:   resourceType,
+		ResourceId:     math.NewUint(resourceID),
+		PolicyId:       math.NewUint(policyID),
+		ExpirationTime: expirationTime,
+		Statements: []*permissiontypes.Statement{
+			{Effect: permissiontypes.EFFECT_ALLOW, Actions: []permissiontypes.ActionType{permissiontypes.ACTION_GET_OBJECT}},
+			{
+				Effect:         permissiontypes.EFFECT_DENY,
+				Actions:        []permissiontypes.ActionType{permissiontypes.ACTION_DELETE_OBJECT},
+				ExpirationTime: statementExpirationTime,
+			},
+		},
+	})
+}
+
+func (s *PermissionHandlerTestSuite) policy(policyID uint64) (*models.Permission, []*models.Statements) {
+	id := common.BigToHash(math.NewUint(policyID).BigInt())
+
+	var p models.Permission
+	s.Require().NoError(s.db.Db.Where("policy_id = ?", id).Take(&p).Error)
+	var statements []*models.Statements
+	s.Require().NoError(s.db.Db.Where("policy_id = ?", id).Order("id").Find(&statements).Error)
+	return &p, statements
+}
+
+// TestDeleteResource_RemovesPolicies verifies that deleting a resource removes its policies and statements
+func (s *PermissionHandlerTestSuite) TestDeleteResource_RemovesPolicies() {
+	s.putPolicy(resource.RESOURCE_TYPE_BUCKET, 401, 2001, nil, nil)
+	s.putPolicy(resource.RESOURCE_TYPE_OBJECT, 402, 2002, nil, nil)
+
+	s.handle(20, &storagetypes.EventDeleteBucket{BucketId: math.NewUint(401)})
+
+	p, statements := s.policy(2001)
+	s.True(p.Removed)
+	s.Equal(int64(1020), p.UpdateTimestamp)
+	s.Require().Len(statements, 2)
+	for _, statement := range statements {
+		s.True(statement.Removed)
+	}
+
+	p, statements = s.policy(2002)
+	s.False(p.Removed)
+	s.False(statements[0].Removed)
+}
+
+// TestStalePolicyCleanup_RemovesPolicies verifies the handling of the chain garbage collection of policies
+func (s *PermissionHandlerTestSuite) TestStalePolicyCleanup_RemovesPolicies() {
+	s.putPolicy(resource.RESOURCE_TYPE_OBJECT, 403, 2003, nil, nil)
+	s.putPolicy(resource.RESOURCE_TYPE_GROUP, 404, 2004, nil, nil)
+	s.putPolicy(resource.RESOURCE_TYPE_GROUP, 405, 2005, nil, nil)
+
+	// the event is emitted by the EndBlocker of the chain
+	sdkEvent, err := sdk.TypedEventToEvent(&storagetypes.EventStalePolicyCleanup{
+		BlockNum: 30,
+		DeleteInfo: &storagetypes.DeleteInfo{
+			ObjectIds: &storagetypes.Ids{Id: []math.Uint{math.NewUint(403)}},
+			GroupIds:  &storagetypes.Ids{Id: []math.Uint{math.NewUint(404)}},
+		},
+	})
+	s.Require().NoError(err)
+	event := abci.Event(sdkEvent)
+	event.Attributes = append(event.Attributes, abci.EventAttribute{Key: "mode", Value: "EndBlock"})
+	testutil.ExportBlockEvents(s.T(), s.ctx, s.db, s.module, &tmctypes.ResultBlockResults{Height: 30, FinalizeBlockEvents: []abci.Event{event}})
+
+	p, _ := s.policy(2003)
+	s.True(p.Removed)
+	p, _ = s.policy(2004)
+	s.True(p.Removed)
+	p, _ = s.policy(2005)
+	s.False(p.Removed)
+}
+
+// TestSweepExpiredPolicies verifies that the sweep removes expired policies and statements only
+func (s *PermissionHandlerTestSuite) TestSweepExpiredPolicies() {
+	expired := time.Unix(5000, 0)
+	alive := time.Unix(9000, 0)
+	s.putPolicy(resource.RESOURCE_TYPE_BUCKET, 406, 2006, &expired, nil)
+	s.putPolicy(resource.RESOURCE_TYPE_BUCKET, 407, 2007, &alive, &expired)
+
+	// nothing is swept before a block is indexed
+	s.module.SweepExpiredPolicies(s.ctx)
+	p, _ := s.policy(2006)
+	s.False(p.Removed)
+
+	// the policies are swept at the time of the last indexed block
+	s.Require().NoError(s.db.SaveProcessedHeight(s.ctx, 60))
+	s.Require().NoError(s.db.SaveEpoch(s.ctx, &models.Epoch{OneRowId: true, BlockHeight: 60, UpdateTime: 6000}))
+	s.module.SweepExpiredPolicies(s.ctx)
+
+	p, statements := s.policy(2006)
+	s.True(p.Removed)
+	s.Equal(int64(6000), p.UpdateTimestamp)
+	s.True(statements[0].Removed)
+	s.True(statements[1].Removed)
+
+	p, statements = s.policy(2007)
+	s.False(p.Removed)
+	s.False(statements[0].Removed)
+	s.True(statements[1].Removed)
+}
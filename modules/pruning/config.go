@@ -1,13 +1,33 @@
 package pruning
 
 import (
+	"time"
+
 	"gopkg.in/yaml.v3"
+
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/models"
+)
+
+const (
+	// defaultSchedule is the interval between two pruning runs when not configured
+	defaultSchedule = 10 * time.Minute
+	// defaultBatchSize is the maximum number of rows deleted by a single statement when not configured
+	defaultBatchSize = 1000
 )
 
 type Config struct {
 	KeepRecent int64 `yaml:"keep_recent"`
 	KeepEvery  int64 `yaml:"keep_every"`
-	Interval   int64 `yaml:"interval"`
+	// Interval is deprecated and ignored, pruning runs every Schedule. A warning is logged when it is set
+	Interval int64 `yaml:"interval"`
+	// Schedule is the interval between two pruning runs
+	Schedule time.Duration `yaml:"schedule"`
+	// BatchSize is the maximum number of rows deleted by a single statement
+	BatchSize int `yaml:"batch_size"`
+	// Retention lists the retention policies of the pruned tables. When empty, the blocks and txs
+	// of the last KeepRecent heights are kept, along with the ones of every KeepEvery-th height.
+	Retention []database.RetentionPolicy `yaml:"retention"`
 }
 
 // NewConfig allows to build a new Config instance
@@ -27,3 +47,36 @@ func ParseConfig(bz []byte) (*Config, error) {
 	err := yaml.Unmarshal(bz, &cfg)
 	return cfg.Config, err
 }
+
+// GetSchedule returns the interval between two pruning runs
+func (cfg *Config) GetSchedule() time.Duration {
+	if cfg.Schedule <= 0 {
+		return defaultSchedule
+	}
+	return cfg.Schedule
+}
+
+// GetBatchSize returns the maximum number of rows deleted by a single statement
+func (cfg *Config) GetBatchSize() int {
+	if cfg.BatchSize <= 0 {
+		return defaultBatchSize
+	}
+	return cfg.BatchSize
+}
+
+// GetRetention returns the retention policies of the pruned tables
+func (cfg *Config) GetRetention() []database.RetentionPolicy {
+	if len(cfg.Retention) > 0 || cfg.KeepRecent <= 0 {
+		return cfg.Retention
+	}
+
+	var policies []database.RetentionPolicy
+	for _, table := range []string{(&models.Tx{}).TableName(), (&models.Block{}).TableName()} {
+		policies = append(policies, database.RetentionPolicy{
+			Table:       table,
+			KeepHeights: uint64(cfg.KeepRecent),
+			KeepEvery:   uint64(cfg.KeepEvery),
+		})
+	}
+	return policies
+}
@@ -2,9 +2,11 @@ package pruning_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/forbole/juno/v4/database"
 	"github.com/forbole/juno/v4/modules/pruning"
 )
 
@@ -29,3 +31,39 @@ pruning:
 	require.NoError(t, err)
 	require.Nil(t, cfg)
 }
+
+func TestConfig_Retention(t *testing.T) {
+	data := []byte(`
+pruning:
+  schedule: 5m
+  batch_size: 500
+  retention:
+    - table: txs
+      keep_days: 30
+    - table: objects
+      keep_days: 7
+`)
+
+	cfg, err := pruning.ParseConfig(data)
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Minute, cfg.GetSchedule())
+	require.Equal(t, 500, cfg.GetBatchSize())
+	require.Equal(t, []database.RetentionPolicy{
+		{Table: "txs", KeepDays: 30},
+		{Table: "objects", KeepDays: 7},
+	}, cfg.GetRetention())
+	require.NoError(t, pruning.RunAdditionalOperations(cfg))
+
+	// keep_recent and keep_every apply to blocks and txs when no retention is set
+	cfg = pruning.NewConfig(100, 10, 1)
+	require.Equal(t, []database.RetentionPolicy{
+		{Table: "txs", KeepHeights: 100, KeepEvery: 10},
+		{Table: "blocks", KeepHeights: 100, KeepEvery: 10},
+	}, cfg.GetRetention())
+	require.NoError(t, pruning.RunAdditionalOperations(cfg))
+
+	require.Error(t, pruning.RunAdditionalOperations(pruning.NewConfig(0, 10, 1)))
+	require.Error(t, pruning.RunAdditionalOperations(&pruning.Config{
+		Retention: []database.RetentionPolicy{{Table: "buckets", KeepDays: 1, KeepEvery: 2}},
+	}))
+}
@@ -1,6 +1,10 @@
 package pruning
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/forbole/juno/v4/log"
+)
 
 // RunAdditionalOperations runs the additional operations for the pruning module
 func RunAdditionalOperations(cfg *Config) error {
@@ -12,6 +16,20 @@ func checkConfig(cfg *Config) error {
 	if cfg == nil {
 		return fmt.Errorf("pruning config is not set but module is enabled")
 	}
+	if cfg.Interval != 0 {
+		log.Warnw("the pruning interval is deprecated and ignored, set the schedule instead",
+			"interval", cfg.Interval, "schedule", cfg.GetSchedule())
+	}
+
+	policies := cfg.GetRetention()
+	if len(policies) == 0 {
+		return fmt.Errorf("pruning is enabled, but neither keep_recent nor retention is set")
+	}
+	for _, policy := range policies {
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
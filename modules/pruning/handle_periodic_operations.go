@@ -0,0 +1,67 @@
+package pruning
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-co-op/gocron"
+
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/log"
+)
+
+// RegisterPeriodicOperations implements modules.PeriodicOperationsModule
+func (m *Module) RegisterPeriodicOperations(scheduler *gocron.Scheduler) error {
+	log.Debugw("setting up periodic tasks", "module", m.Name())
+
+	if _, err := scheduler.Every(m.cfg.GetSchedule()).SingletonMode().Do(func() {
+		if err := m.Prune(context.Background(), time.Now()); err != nil {
+			log.Errorw("failed to prune the database", "module", m.Name(), "err", err)
+		}
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Prune deletes the rows falling out of the retention policies, in batches. The windows of the policies
+// end at the last stored block and at now.
+func (m *Module) Prune(ctx context.Context, now time.Time) error {
+	pruningDb, ok := m.db.(database.PruningDb)
+	if !ok {
+		return fmt.Errorf("pruning is enabled, but your database does not implement PruningDb")
+	}
+
+	latestHeight, err := m.db.GetLastBlockHeight(ctx)
+	if err != nil {
+		return fmt.Errorf("error while getting the last block height: %s", err)
+	}
+
+	batchSize := m.cfg.GetBatchSize()
+	for _, policy := range m.cfg.GetRetention() {
+		var total int64
+		for {
+			if err = ctx.Err(); err != nil {
+				return err
+			}
+
+			deleted, err := pruningDb.ApplyRetention(ctx, policy, latestHeight, now.Unix(), batchSize)
+			if err != nil {
+				return fmt.Errorf("error while pruning table %s: %s", policy.Table, err)
+			}
+			total += deleted
+			log.PruningDeletedCount.WithLabelValues(policy.Table).Add(float64(deleted))
+			log.PruningBatchCount.WithLabelValues(policy.Table).Inc()
+
+			if deleted < int64(batchSize) {
+				break
+			}
+		}
+		log.Infow("pruned table", "module", m.Name(), "table", policy.Table, "deleted", total, "height", latestHeight)
+	}
+
+	log.PruningLastRun.Set(float64(now.Unix()))
+	log.PruningLastHeight.Set(float64(latestHeight))
+	return pruningDb.StoreLastPruned(int64(latestHeight))
+}
@@ -1,14 +1,20 @@
 package pruning
 
 import (
+	"context"
+
+	"gorm.io/gorm/schema"
+
 	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/models"
 	"github.com/forbole/juno/v4/modules"
 	"github.com/forbole/juno/v4/types/config"
 )
 
 var (
 	_ modules.Module                     = &Module{}
-	_ modules.BlockModule                = &Module{}
+	_ modules.PrepareTablesModule        = &Module{}
+	_ modules.PeriodicOperationsModule   = &Module{}
 	_ modules.AdditionalOperationsModule = &Module{}
 )
 
@@ -41,6 +47,16 @@ func (m *Module) Name() string {
 	return "pruning"
 }
 
+// PrepareTables implements modules.PrepareTablesModule
+func (m *Module) PrepareTables() error {
+	return m.db.PrepareTables(context.TODO(), []schema.Tabler{&models.Pruning{}})
+}
+
+// AutoMigrate implements modules.PrepareTablesModule
+func (m *Module) AutoMigrate() error {
+	return nil
+}
+
 // RunAdditionalOperations implements
 func (m *Module) RunAdditionalOperations() error {
 	return RunAdditionalOperations(m.cfg)
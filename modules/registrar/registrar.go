@@ -7,16 +7,21 @@ import (
 	"github.com/forbole/juno/v4/database"
 	"github.com/forbole/juno/v4/log"
 	"github.com/forbole/juno/v4/modules"
+	"github.com/forbole/juno/v4/modules/api"
+	"github.com/forbole/juno/v4/modules/archive"
 	"github.com/forbole/juno/v4/modules/block"
 	"github.com/forbole/juno/v4/modules/bucket"
+	"github.com/forbole/juno/v4/modules/challenge"
 	"github.com/forbole/juno/v4/modules/epoch"
 	"github.com/forbole/juno/v4/modules/group"
 	"github.com/forbole/juno/v4/modules/messages"
 	"github.com/forbole/juno/v4/modules/object"
+	"github.com/forbole/juno/v4/modules/outbox"
 	"github.com/forbole/juno/v4/modules/payment"
 	"github.com/forbole/juno/v4/modules/permission"
 	"github.com/forbole/juno/v4/modules/pruning"
 	storageprovider "github.com/forbole/juno/v4/modules/storage_provider"
+	"github.com/forbole/juno/v4/modules/storagequery"
 	"github.com/forbole/juno/v4/modules/telemetry"
 	"github.com/forbole/juno/v4/modules/validator"
 	virtualgroup "github.com/forbole/juno/v4/modules/virtual_group"
@@ -93,13 +98,18 @@ func (r *DefaultRegistrar) BuildModules(ctx Context) modules.Modules {
 		bucket.NewModule(ctx.Database),
 		object.NewModule(ctx.Database),
 		pruning.NewModule(ctx.JunoConfig, ctx.Database),
-		telemetry.NewModule(ctx.JunoConfig),
+		archive.NewModule(ctx.JunoConfig, ctx.Database),
+		outbox.NewModule(ctx.JunoConfig, ctx.Database),
+		telemetry.NewModule(ctx.JunoConfig, ctx.Database),
+		api.NewModule(ctx.JunoConfig, database.ReadOnly(ctx.Database)),
+		storagequery.NewModule(ctx.JunoConfig, database.ReadOnly(ctx.Database)),
 		epoch.NewModule(ctx.Database),
-		payment.NewModule(ctx.Database),
+		payment.NewModule(ctx.Database, payment.NewOutFlowsClient(ctx.JunoConfig)),
 		permission.NewModule(ctx.Database),
 		group.NewModule(ctx.Database),
 		storageprovider.NewModule(ctx.Database),
 		virtualgroup.NewModule(ctx.Database),
+		challenge.NewModule(ctx.Database),
 	}
 }
 
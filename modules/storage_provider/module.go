@@ -38,10 +38,10 @@ func (m *Module) Name() string {
 
 // PrepareTables implements
 func (m *Module) PrepareTables() error {
-	return m.db.PrepareTables(context.TODO(), []schema.Tabler{&models.StorageProvider{}})
+	return m.db.PrepareTables(context.TODO(), []schema.Tabler{&models.StorageProvider{}, &models.SpStatusHistory{}, &models.SpPriceHistory{}, &models.GlobalStorePrice{}})
 }
 
 // AutoMigrate implements
 func (m *Module) AutoMigrate() error {
-	return m.db.AutoMigrate(context.TODO(), []schema.Tabler{&models.StorageProvider{}})
+	return m.db.AutoMigrate(context.TODO(), []schema.Tabler{&models.StorageProvider{}, &models.SpStatusHistory{}, &models.SpPriceHistory{}, &models.GlobalStorePrice{}})
 }
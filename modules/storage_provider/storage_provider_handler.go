@@ -20,6 +20,10 @@ var (
 	EventCreateStorageProvider = proto.MessageName(&sptypes.EventCreateStorageProvider{})
 	EventEditStorageProvider   = proto.MessageName(&sptypes.EventEditStorageProvider{})
 	EventSpStoragePriceUpdate  = proto.MessageName(&sptypes.EventSpStoragePriceUpdate{})
+	EventGlobalSpPriceUpdate   = proto.MessageName(&sptypes.EventGlobalSpStorePriceUpdate{})
+	EventUpdateSpStatus        = proto.MessageName(&sptypes.EventUpdateStorageProviderStatus{})
+	EventSpExit                = proto.MessageName(&vgtypes.EventStorageProviderExit{})
+	EventSpForcedExit          = proto.MessageName(&vgtypes.EventStorageProviderForcedExit{})
 	EventCompleteSpExit        = proto.MessageName(&vgtypes.EventCompleteStorageProviderExit{})
 )
 
@@ -27,6 +31,10 @@ var StorageProviderEvents = map[string]bool{
 	EventCreateStorageProvider: true,
 	EventEditStorageProvider:   true,
 	EventSpStoragePriceUpdate:  true,
+	EventGlobalSpPriceUpdate:   true,
+	EventUpdateSpStatus:        true,
+	EventSpExit:                true,
+	EventSpForcedExit:          true,
 	EventCompleteSpExit:        true,
 }
 
@@ -67,11 +75,39 @@ func (m *Module) HandleEvent(ctx context.Context, block *tmctypes.ResultBlock, t
 			return errors.New("storage provider price update event assert error")
 		}
 		return m.handleSpStoragePriceUpdate(ctx, block, txHash, spStoragePriceUpdate)
+	case EventGlobalSpPriceUpdate:
+		globalSpPriceUpdate, ok := typedEvent.(*sptypes.EventGlobalSpStorePriceUpdate)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventGlobalSpStorePriceUpdate", "event", typedEvent)
+			return errors.New("global storage provider price update event assert error")
+		}
+		return m.handleGlobalSpStorePriceUpdate(ctx, block, txHash, globalSpPriceUpdate)
+	case EventUpdateSpStatus:
+		updateSpStatus, ok := typedEvent.(*sptypes.EventUpdateStorageProviderStatus)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventUpdateStorageProviderStatus", "event", typedEvent)
+			return errors.New("update storage provider status event assert error")
+		}
+		return m.handleUpdateStorageProviderStatus(ctx, block, txHash, updateSpStatus)
+	case EventSpExit:
+		spExit, ok := typedEvent.(*vgtypes.EventStorageProviderExit)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventStorageProviderExit", "event", typedEvent)
+			return errors.New("storage provider exit event assert error")
+		}
+		return m.handleStorageProviderExit(ctx, block, txHash, spExit)
+	case EventSpForcedExit:
+		spForcedExit, ok := typedEvent.(*vgtypes.EventStorageProviderForcedExit)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventStorageProviderForcedExit", "event", typedEvent)
+			return errors.New("storage provider forced exit event assert error")
+		}
+		return m.handleStorageProviderForcedExit(ctx, block, txHash, spForcedExit)
 	case EventCompleteSpExit:
 		completeSpExit, ok := typedEvent.(*vgtypes.EventCompleteStorageProviderExit)
 		if !ok {
 			log.Errorw("type assert error", "type", "EventCompleteSpExit", "event", typedEvent)
-			return errors.New("storage provider exit event assert error")
+			return errors.New("complete storage provider exit event assert error")
 		}
 
 		return m.handleCompleteStorageProviderExit(ctx, block, txHash, completeSpExit)
@@ -106,7 +142,25 @@ func (m *Module) handleCreateStorageProvider(ctx context.Context, block *tmctype
 		Removed:      false,
 	}
 
-	return m.db.CreateStorageProvider(ctx, storageProvider)
+	history := &models.SpStatusHistory{
+		SpId:       createStorageProvider.SpId,
+		ToStatus:   storageProvider.Status,
+		Reason:     models.SpStatusReasonCreate,
+		Height:     block.Block.Height,
+		TxHash:     txHash,
+		UpdateTime: block.Block.Time.UTC().Unix(),
+	}
+
+	tx := m.db.Begin(ctx)
+	if err := tx.CreateStorageProvider(ctx, storageProvider); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.SaveSpStatusHistory(ctx, history); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
 
 func (m *Module) handleEditStorageProvider(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, editStorageProvider *sptypes.EventEditStorageProvider) error {
@@ -146,16 +200,136 @@ func (m *Module) handleSpStoragePriceUpdate(ctx context.Context, block *tmctypes
 		Removed:      false,
 	}
 
-	return m.db.UpdateStorageProvider(ctx, storageProvider)
+	price := &models.SpPriceHistory{
+		SpId:          spStoragePriceUpdate.SpId,
+		UpdateTimeSec: spStoragePriceUpdate.UpdateTimeSec,
+		ReadPrice:     storageProvider.ReadPrice,
+		FreeReadQuota: spStoragePriceUpdate.FreeReadQuota,
+		StorePrice:    storageProvider.StorePrice,
+
+		Height: block.Block.Height,
+		TxHash: txHash,
+	}
+
+	tx := m.db.Begin(ctx)
+	if err := tx.UpdateStorageProvider(ctx, storageProvider); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.SaveSpPriceHistory(ctx, price); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Module) handleGlobalSpStorePriceUpdate(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, globalSpPriceUpdate *sptypes.EventGlobalSpStorePriceUpdate) error {
+	price := &models.GlobalStorePrice{
+		UpdateTimeSec:       globalSpPriceUpdate.UpdateTimeSec,
+		ReadPrice:           (*common.Big)(globalSpPriceUpdate.ReadPrice.BigInt()),
+		PrimaryStorePrice:   (*common.Big)(globalSpPriceUpdate.PrimaryStorePrice.BigInt()),
+		SecondaryStorePrice: (*common.Big)(globalSpPriceUpdate.SecondaryStorePrice.BigInt()),
+
+		Height: block.Block.Height,
+		TxHash: txHash,
+	}
+
+	return m.db.SaveGlobalStorePrice(ctx, price)
+}
+
+func (m *Module) handleUpdateStorageProviderStatus(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, updateSpStatus *sptypes.EventUpdateStorageProviderStatus) error {
+	data := &models.StorageProvider{
+		SpId:   updateSpStatus.SpId,
+		Status: updateSpStatus.NewStatus,
+
+		UpdateAt:     block.Block.Height,
+		UpdateTxHash: txHash,
+	}
+	return m.transitStatus(ctx, block, txHash, data, updateSpStatus.PreStatus, models.SpStatusReasonUpdate)
+}
+
+func (m *Module) handleStorageProviderExit(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, spExit *vgtypes.EventStorageProviderExit) error {
+	current, err := m.db.GetStorageProvider(ctx, spExit.StorageProviderId)
+	if err != nil {
+		return err
+	}
+
+	data := &models.StorageProvider{
+		SpId:   spExit.StorageProviderId,
+		Status: sptypes.STATUS_GRACEFUL_EXITING.String(),
+
+		UpdateAt:     block.Block.Height,
+		UpdateTxHash: txHash,
+	}
+	return m.transitStatus(ctx, block, txHash, data, statusOf(current), models.SpStatusReasonGracefulExit)
+}
+
+func (m *Module) handleStorageProviderForcedExit(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, spForcedExit *vgtypes.EventStorageProviderForcedExit) error {
+	current, err := m.db.GetStorageProvider(ctx, spForcedExit.StorageProviderId)
+	if err != nil {
+		return err
+	}
+
+	data := &models.StorageProvider{
+		SpId:   spForcedExit.StorageProviderId,
+		Status: sptypes.STATUS_FORCED_EXITING.String(),
+
+		UpdateAt:     block.Block.Height,
+		UpdateTxHash: txHash,
+	}
+	return m.transitStatus(ctx, block, txHash, data, statusOf(current), models.SpStatusReasonForcedExit)
 }
 
 func (m *Module) handleCompleteStorageProviderExit(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, completeStorageProviderExit *vgtypes.EventCompleteStorageProviderExit) error {
+	current, err := m.db.GetStorageProvider(ctx, completeStorageProviderExit.StorageProviderId)
+	if err != nil {
+		return err
+	}
+
+	reason := models.SpStatusReasonCompleteExit
+	if completeStorageProviderExit.ForcedExit {
+		reason = models.SpStatusReasonCompleteForcedExit
+	}
+
 	data := &models.StorageProvider{
-		SpId: completeStorageProviderExit.StorageProviderId,
+		SpId:   completeStorageProviderExit.StorageProviderId,
+		Status: models.SpStatusExited,
 
 		UpdateAt:     block.Block.Height,
 		UpdateTxHash: txHash,
 		Removed:      true,
 	}
-	return m.db.UpdateStorageProvider(ctx, data)
+	return m.transitStatus(ctx, block, txHash, data, statusOf(current), reason)
+}
+
+// statusOf returns the status of the sp, empty when it is not indexed or is removed
+func statusOf(sp *models.StorageProvider) string {
+	if sp == nil {
+		return ""
+	}
+	return sp.Status
+}
+
+// transitStatus updates the sp and records the status transition in a single transaction
+func (m *Module) transitStatus(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, data *models.StorageProvider, fromStatus, reason string) error {
+	history := &models.SpStatusHistory{
+		SpId:       data.SpId,
+		FromStatus: fromStatus,
+		ToStatus:   data.Status,
+		Reason:     reason,
+		Height:     block.Block.Height,
+		TxHash:     txHash,
+		UpdateTime: block.Block.Time.UTC().Unix(),
+	}
+
+	tx := m.db.Begin(ctx)
+	if err := tx.UpdateStorageProvider(ctx, data); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.SaveSpStatusHistory(ctx, history); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/gogoproto/proto"
+	sptypes "github.com/evmos/evmos/v12/x/sp/types"
+	vgtypes "github.com/evmos/evmos/v12/x/virtualgroup/types"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/models"
+	storageprovider "github.com/forbole/juno/v4/modules/storage_provider"
+	"github.com/forbole/juno/v4/modules/testutil"
+)
+
+type StorageProviderHandlerTestSuite struct {
+	suite.Suite
+	db     *database.Impl
+	module *storageprovider.Module
+	ctx    context.Context
+}
+
+func TestStorageProviderHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(StorageProviderHandlerTestSuite))
+}
+
+func (s *StorageProviderHandlerTestSuite) SetupTest() {
+	s.db = testutil.NewDB(s.T(), &models.StorageProvider{}, &models.SpStatusHistory{}, &models.SpPriceHistory{}, &models.GlobalStorePrice{})
+	s.module = storageprovider.NewModule(s.db)
+	s.ctx = context.Background()
+}
+
+func (s *StorageProviderHandlerTestSuite) handle(height int64, txHash common.Hash, event proto.Message) {
+	testutil.HandleEvent(s.T(), s.ctx, s.module, height, txHash, event)
+}
+
+// TestStatusLifecycle_RecordsHistory verifies that every status transition of a sp is recorded in order
+func (s *StorageProviderHandlerTestSuite) TestStatusLifecycle_RecordsHistory() {
+	deposit := sdk.NewCoin("amoca", math.NewInt(100))
+	s.handle(10, common.HexToHash("0x01"), &sptypes.EventCreateStorageProvider{
+		SpId:         1,
+		SpAddress:    "0x0000000000000000000000000000000000000001",
+		TotalDeposit: &deposit,
+		Status:       sptypes.STATUS_IN_SERVICE,
+	})
+	s.handle(20, common.HexToHash("0x02"), &sptypes.EventUpdateStorageProviderStatus{
+		SpId:      1,
+		PreStatus: sptypes.STATUS_IN_SERVICE.String(),
+		NewStatus: sptypes.STATUS_IN_MAINTENANCE.String(),
+	})
+	s.handle(30, common.HexToHash("0x03"), &vgtypes.EventStorageProviderExit{
+		StorageProviderId: 1,
+	})
+	s.handle(40, common.HexToHash("0x04"), &vgtypes.EventCompleteStorageProviderExit{
+		StorageProviderId: 1,
+		TotalDeposit:      math.NewInt(0),
+	})
+
+	var histories []*models.SpStatusHistory
+	err := s.db.Db.Where("sp_id = ?", 1).Order("height").Find(&histories).Error
+	s.Require().NoError(err)
+	s.Require().Len(histories, 4)
+
+	expected := []struct {
+		from, to, reason string
+		height           int64
+	}{
+		{"", sptypes.STATUS_IN_SERVICE.String(), models.SpStatusReasonCreate, 10},
+		{sptypes.STATUS_IN_SERVICE.String(), sptypes.STATUS_IN_MAINTENANCE.String(), models.SpStatusReasonUpdate, 20},
+		{sptypes.STATUS_IN_MAINTENANCE.String(), sptypes.STATUS_GRACEFUL_EXITING.String(), models.SpStatusReasonGracefulExit, 30},
+		{sptypes.STATUS_GRACEFUL_EXITING.String(), models.SpStatusExited, models.SpStatusReasonCompleteExit, 40},
+	}
+	for i, e := range expected {
+		s.Equal(e.from, histories[i].FromStatus)
+		s.Equal(e.to, histories[i].ToStatus)
+		s.Equal(e.reason, histories[i].Reason)
+		s.Equal(e.height, histories[i].Height)
+	}
+	s.Equal(common.HexToHash("0x03"), histories[2].TxHash)
+
+	// the removed sp is left out of the reads, its row is kept
+	sp, err := s.db.GetStorageProvider(s.ctx, 1)
+	s.Require().NoError(err)
+	s.Nil(sp)
+	var removed models.StorageProvider
+	s.Require().NoError(s.db.Db.Where("sp_id = ?", 1).Take(&removed).Error)
+	s.Equal(models.SpStatusExited, removed.Status)
+	s.True(removed.Removed)
+}
+
+// TestForcedExit_RecordsForcedReasons verifies that forced exits are distinguishable from graceful ones
+func (s *StorageProviderHandlerTestSuite) TestForcedExit_RecordsForcedReasons() {
+	deposit := sdk.NewCoin("amoca", math.NewInt(100))
+	s.handle(10, common.HexToHash("0x11"), &sptypes.EventCreateStorageProvider{
+		SpId:         2,
+		TotalDeposit: &deposit,
+		Status:       sptypes.STATUS_IN_JAILED,
+	})
+	s.handle(20, common.HexToHash("0x12"), &vgtypes.EventStorageProviderForcedExit{
+		StorageProviderId: 2,
+	})
+	s.handle(30, common.HexToHash("0x13"), &vgtypes.EventCompleteStorageProviderExit{
+		StorageProviderId: 2,
+		TotalDeposit:      math.NewInt(0),
+		ForcedExit:        true,
+	})
+
+	var histories []*models.SpStatusHistory
+	err := s.db.Db.Where("sp_id = ?", 2).Order("height").Find(&histories).Error
+	s.Require().NoError(err)
+	s.Require().Len(histories, 3)
+
+	s.Equal(sptypes.STATUS_IN_JAILED.String(), histories[1].FromStatus)
+	s.Equal(sptypes.STATUS_FORCED_EXITING.String(), histories[1].ToStatus)
+	s.Equal(models.SpStatusReasonForcedExit, histories[1].Reason)
+	s.Equal(sptypes.STATUS_FORCED_EXITING.String(), histories[2].FromStatus)
+	s.Equal(models.SpStatusReasonCompleteForcedExit, histories[2].Reason)
+}
+
+// TestStatusUpdate_ProcessedAgain verifies that a transition is recorded once, with its original statuses, when
+// its block is processed again
+func (s *StorageProviderHandlerTestSuite) TestStatusUpdate_ProcessedAgain() {
+	deposit := sdk.NewCoin("amoca", math.NewInt(100))
+	s.handle(10, common.HexToHash("0x31"), &sptypes.EventCreateStorageProvider{
+		SpId:         3,
+		TotalDeposit: &deposit,
+		Status:       sptypes.STATUS_IN_SERVICE,
+	})
+	for i := 0; i < 2; i++ {
+		s.handle(20, common.HexToHash("0x32"), &vgtypes.EventStorageProviderExit{
+			StorageProviderId: 3,
+		})
+	}
+
+	var histories []*models.SpStatusHistory
+	err := s.db.Db.Where("sp_id = ?", 3).Order("height").Find(&histories).Error
+	s.Require().NoError(err)
+	s.Require().Len(histories, 2)
+	s.Equal(sptypes.STATUS_IN_SERVICE.String(), histories[1].FromStatus)
+	s.Equal(sptypes.STATUS_GRACEFUL_EXITING.String(), histories[1].ToStatus)
+}
+
+// TestPriceUpdate_KeepsHistory verifies that old prices stay queryable after a sp updates its price
+func (s *StorageProviderHandlerTestSuite) TestPriceUpdate_KeepsHistory() {
+	deposit := sdk.NewCoin("amoca", math.NewInt(100))
+	s.handle(10, common.HexToHash("0x21"), &sptypes.EventCreateStorageProvider{
+		SpId:         3,
+		TotalDeposit: &deposit,
+	})
+	s.handle(20, common.HexToHash("0x22"), &sptypes.EventSpStoragePriceUpdate{
+		SpId:          3,
+		UpdateTimeSec: 1000,
+		ReadPrice:     math.LegacyNewDec(1),
+		StorePrice:    math.LegacyNewDec(2),
+		FreeReadQuota: 10,
+	})
+	s.handle(30, common.HexToHash("0x23"), &sptypes.EventSpStoragePriceUpdate{
+		SpId:          3,
+		UpdateTimeSec: 2000,
+		ReadPrice:     math.LegacyNewDec(3),
+		StorePrice:    math.LegacyNewDec(4),
+		FreeReadQuota: 20,
+	})
+
+	price, err := s.db.GetSpPriceAt(s.ctx, 3, 999)
+	s.Require().NoError(err)
+	s.Nil(price)
+
+	price, err = s.db.GetSpPriceAt(s.ctx, 3, 1500)
+	s.Require().NoError(err)
+	s.Require().NotNil(price)
+	s.Equal(uint64(10), price.FreeReadQuota)
+	s.Equal(math.LegacyNewDec(2).BigInt(), price.StorePrice.Raw())
+	s.Equal(int64(20), price.Height)
+
+	price, err = s.db.GetSpPriceAt(s.ctx, 3, 2000)
+	s.Require().NoError(err)
+	s.Require().NotNil(price)
+	s.Equal(uint64(20), price.FreeReadQuota)
+
+	sp, err := s.db.GetStorageProvider(s.ctx, 3)
+	s.Require().NoError(err)
+	s.Equal(uint64(20), sp.FreeReadQuota)
+}
+
+// TestGlobalPriceUpdate_EffectiveAt verifies the global store price lookup by timestamp
+func (s *StorageProviderHandlerTestSuite) TestGlobalPriceUpdate_EffectiveAt() {
+	s.handle(10, common.Hash{}, &sptypes.EventGlobalSpStorePriceUpdate{
+		UpdateTimeSec:       100,
+		ReadPrice:           math.LegacyNewDec(1),
+		PrimaryStorePrice:   math.LegacyNewDec(2),
+		SecondaryStorePrice: math.LegacyNewDec(3),
+	})
+	s.handle(20, common.Hash{}, &sptypes.EventGlobalSpStorePriceUpdate{
+		UpdateTimeSec:       200,
+		ReadPrice:           math.LegacyNewDec(4),
+		PrimaryStorePrice:   math.LegacyNewDec(5),
+		SecondaryStorePrice: math.LegacyNewDec(6),
+	})
+
+	price, err := s.db.GetGlobalStorePriceAt(s.ctx, 150)
+	s.Require().NoError(err)
+	s.Require().NotNil(price)
+	s.Equal(math.LegacyNewDec(2).BigInt(), price.PrimaryStorePrice.Raw())
+
+	price, err = s.db.GetGlobalStorePriceAt(s.ctx, 250)
+	s.Require().NoError(err)
+	s.Require().NotNil(price)
+	s.Equal(math.LegacyNewDec(6).BigInt(), price.SecondaryStorePrice.Raw())
+}
@@ -0,0 +1,35 @@
+package storagequery
+
+import (
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultRequestTimeout is the timeout of a request when not configured
+	defaultRequestTimeout = 10 * time.Second
+)
+
+type Config struct {
+	Port uint `yaml:"port"`
+	// RequestTimeout is the timeout of a request, DeadlineExceeded is returned once it is exceeded
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+}
+
+func ParseConfig(bz []byte) (*Config, error) {
+	type T struct {
+		Config *Config `yaml:"storage_query"`
+	}
+	var cfg T
+	err := yaml.Unmarshal(bz, &cfg)
+	return cfg.Config, err
+}
+
+// GetRequestTimeout returns the timeout of a request
+func (cfg *Config) GetRequestTimeout() time.Duration {
+	if cfg.RequestTimeout <= 0 {
+		return defaultRequestTimeout
+	}
+	return cfg.RequestTimeout
+}
@@ -0,0 +1,29 @@
+package storagequery_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/forbole/juno/v4/modules/storagequery"
+)
+
+func TestParseConfig(t *testing.T) {
+	data := []byte(`
+storage_query:
+  port: 9091
+`)
+
+	cfg, err := storagequery.ParseConfig(data)
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	require.Equal(t, uint(9091), cfg.Port)
+	require.Equal(t, 10*time.Second, cfg.GetRequestTimeout())
+
+	cfg, err = storagequery.ParseConfig([]byte(`invalid_field: yes`))
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+	require.Error(t, storagequery.RunAdditionalOperations(cfg, nil))
+	require.Error(t, storagequery.RunAdditionalOperations(&storagequery.Config{}, nil))
+}
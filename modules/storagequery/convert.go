@@ -0,0 +1,152 @@
+package storagequery
+
+import (
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	permissiontypes "github.com/evmos/evmos/v12/x/permission/types"
+	storagetypes "github.com/evmos/evmos/v12/x/storage/types"
+	vgtypes "github.com/evmos/evmos/v12/x/virtualgroup/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/models"
+)
+
+// toPage returns the page selected by the pagination of a request. The key of the pagination is the cursor
+// of the index, offsets are refused like the chain does.
+func toPage(pagination *query.PageRequest) (database.Page, error) {
+	if pagination == nil {
+		return database.Page{}, nil
+	}
+	if pagination.Limit > storagetypes.MaxPaginationLimit {
+		return database.Page{}, status.Errorf(codes.InvalidArgument, "exceed pagination limit %d", storagetypes.MaxPaginationLimit)
+	}
+	if pagination.Offset > 0 {
+		return database.Page{}, status.Error(codes.InvalidArgument, "offset queries are not allowed, use the key instead")
+	}
+	if pagination.Reverse {
+		return database.Page{}, status.Error(codes.InvalidArgument, "reverse queries are not supported")
+	}
+	return database.Page{Cursor: string(pagination.Key), Limit: int(pagination.Limit)}, nil
+}
+
+// toPageResponse returns the pagination of the response whose next page starts at cursor
+func toPageResponse(cursor string) *query.PageResponse {
+	if cursor == "" {
+		return &query.PageResponse{}
+	}
+	return &query.PageResponse{NextKey: []byte(cursor)}
+}
+
+// parseID returns the id of a resource given in decimal like the chain does
+func parseID(id string) (common.Hash, bool) {
+	u, err := sdkmath.ParseUint(id)
+	if err != nil {
+		return common.Hash{}, false
+	}
+	return common.BigToHash(u.BigInt()), true
+}
+
+func toUint(id common.Hash) sdkmath.Uint {
+	return sdkmath.NewUintFromBigInt(id.Big())
+}
+
+func toBucketInfo(bucket *models.Bucket) *storagetypes.BucketInfo {
+	return &storagetypes.BucketInfo{
+		Owner:                      bucket.Owner.String(),
+		BucketName:                 bucket.BucketName,
+		Visibility:                 storagetypes.VisibilityType(storagetypes.VisibilityType_value[bucket.Visibility]),
+		Id:                         toUint(bucket.BucketID),
+		SourceType:                 storagetypes.SourceType(storagetypes.SourceType_value[bucket.SourceType]),
+		CreateAt:                   bucket.CreateTime,
+		PaymentAddress:             bucket.PaymentAddress.String(),
+		GlobalVirtualGroupFamilyId: bucket.GlobalVirtualGroupFamilyId,
+		ChargedReadQuota:           bucket.ChargedReadQuota,
+		BucketStatus:               storagetypes.BucketStatus(storagetypes.BucketStatus_value[bucket.Status]),
+		SpAsDelegatedAgentDisabled: bucket.SpAsDelegatedAgentDisabled,
+	}
+}
+
+// toBucketExtraInfo returns the rate limit of the bucket. The current flow rate is not indexed, it is zero.
+func toBucketExtraInfo(bucket *models.Bucket) *storagetypes.BucketExtraInfo {
+	extra := &storagetypes.BucketExtraInfo{
+		IsRateLimited: bucket.FlowRateLimited,
+		// like on the chain, -1 means that no limit is set
+		FlowRateLimit:   sdkmath.NewInt(-1),
+		CurrentFlowRate: sdkmath.ZeroInt(),
+	}
+	if bucket.FlowRateLimit != nil {
+		extra.FlowRateLimit = sdkmath.NewIntFromBigInt(bucket.FlowRateLimit.Raw())
+	}
+	return extra
+}
+
+func toObjectInfo(object *models.Object) *storagetypes.ObjectInfo {
+	info := &storagetypes.ObjectInfo{
+		Owner:               object.Owner.String(),
+		Creator:             object.Creator.String(),
+		BucketName:          object.BucketName,
+		ObjectName:          object.ObjectName,
+		Id:                  toUint(object.ObjectID),
+		LocalVirtualGroupId: object.LocalVirtualGroupId,
+		PayloadSize:         object.PayloadSize,
+		Visibility:          storagetypes.VisibilityType(storagetypes.VisibilityType_value[object.Visibility]),
+		ContentType:         object.ContentType,
+		CreateAt:            object.CreateTime,
+		ObjectStatus:        storagetypes.ObjectStatus(storagetypes.ObjectStatus_value[object.Status]),
+		RedundancyType:      storagetypes.RedundancyType(storagetypes.RedundancyType_value[object.RedundancyType]),
+		SourceType:          storagetypes.SourceType(storagetypes.SourceType_value[object.SourceType]),
+		Checksums:           object.CheckSums,
+		IsUpdating:          object.IsUpdating,
+		UpdatedAt:           object.ContentUpdatedTime,
+		Version:             object.Version,
+	}
+	if object.Updater != (common.Address{}) {
+		info.UpdatedBy = object.Updater.String()
+	}
+	return info
+}
+
+func toGroupInfo(group *models.Group) *storagetypes.GroupInfo {
+	return &storagetypes.GroupInfo{
+		Owner:      group.Owner.String(),
+		GroupName:  group.GroupName,
+		SourceType: storagetypes.SourceType(storagetypes.SourceType_value[group.SourceType]),
+		Id:         toUint(group.GroupID),
+		Extra:      group.Extra,
+	}
+}
+
+// toGroupMember returns the membership of member. The id of the membership is not indexed, it is zero.
+func toGroupMember(member *models.Group) *permissiontypes.GroupMember {
+	groupMember := &permissiontypes.GroupMember{
+		Id:      sdkmath.ZeroUint(),
+		GroupId: toUint(member.GroupID),
+		Member:  member.AccountID.String(),
+	}
+	if member.ExpirationTime != 0 {
+		expiration := time.Unix(member.ExpirationTime, 0).UTC()
+		groupMember.ExpirationTime = &expiration
+	}
+	return groupMember
+}
+
+func toGlobalVirtualGroup(gvg *models.GlobalVirtualGroup) *vgtypes.GlobalVirtualGroup {
+	result := &vgtypes.GlobalVirtualGroup{
+		Id:                    gvg.GlobalVirtualGroupId,
+		FamilyId:              gvg.FamilyId,
+		PrimarySpId:           gvg.PrimarySpId,
+		SecondarySpIds:        gvg.SecondarySpIds,
+		StoredSize:            gvg.StoredSize,
+		VirtualPaymentAddress: gvg.VirtualPaymentAddress.String(),
+		TotalDeposit:          sdkmath.ZeroInt(),
+	}
+	if gvg.TotalDeposit != nil {
+		result.TotalDeposit = sdkmath.NewIntFromBigInt(gvg.TotalDeposit.Raw())
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package storagequery
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/forbole/juno/v4/database"
+)
+
+// RunAdditionalOperations runs the module additional operations
+func RunAdditionalOperations(cfg *Config, repo database.Repository) error {
+	err := checkConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	go startServer(cfg, repo)
+
+	return nil
+}
+
+// checkConfig checks if the given config is valid
+func checkConfig(cfg *Config) error {
+	if cfg == nil {
+		return fmt.Errorf("storage_query config is not set but module is enabled")
+	}
+
+	if cfg.Port == 0 {
+		return fmt.Errorf("storage_query is enabled, but no port is set")
+	}
+
+	return nil
+}
+
+// startServer starts the gRPC server using the given configuration
+func startServer(cfg *Config, repo database.Repository) {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
+	if err != nil {
+		panic(err)
+	}
+
+	err = NewServer(cfg, repo).Serve(listener)
+	if err != nil {
+		panic(err)
+	}
+}
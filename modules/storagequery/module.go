@@ -0,0 +1,51 @@
+package storagequery
+
+import (
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/modules"
+	"github.com/forbole/juno/v4/types/config"
+)
+
+const (
+	ModuleName = "storage_query"
+)
+
+var (
+	_ modules.Module                     = &Module{}
+	_ modules.AdditionalOperationsModule = &Module{}
+)
+
+// Module serves the storage Query gRPC service of the chain from the indexed data
+type Module struct {
+	cfg *Config
+	// repo only reads, the service never writes to the database
+	repo database.Repository
+}
+
+// NewModule returns a new Module implementation
+func NewModule(cfg config.Config, repo database.Repository) *Module {
+	bz, err := cfg.GetBytes()
+	if err != nil {
+		panic(err)
+	}
+
+	queryCfg, err := ParseConfig(bz)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Module{
+		cfg:  queryCfg,
+		repo: repo,
+	}
+}
+
+// Name implements modules.Module
+func (m *Module) Name() string {
+	return ModuleName
+}
+
+// RunAdditionalOperations implements modules.AdditionalOperationsModule
+func (m *Module) RunAdditionalOperations() error {
+	return RunAdditionalOperations(m.cfg, m.repo)
+}
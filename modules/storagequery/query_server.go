@@ -0,0 +1,269 @@
+package storagequery
+
+import (
+	"context"
+
+	storagetypes "github.com/evmos/evmos/v12/x/storage/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/models"
+)
+
+var _ storagetypes.QueryServer = &queryServer{}
+
+// queryServer answers the queries of the chain storage module from the index. The queries it does not
+// answer, which read state the index does not hold, are left unimplemented.
+// The errors match the ones of the chain, so that the clients handle them unchanged.
+type queryServer struct {
+	storagetypes.UnimplementedQueryServer
+
+	repo database.Repository
+}
+
+// HeadBucket implements storagetypes.QueryServer
+func (s *queryServer) HeadBucket(ctx context.Context, req *storagetypes.QueryHeadBucketRequest) (*storagetypes.QueryHeadBucketResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+
+	bucket, err := s.repo.GetBucketByName(ctx, req.BucketName)
+	if err != nil {
+		return nil, repoError(ctx, "HeadBucket", err)
+	}
+	if bucket == nil {
+		return nil, storagetypes.ErrNoSuchBucket
+	}
+	return &storagetypes.QueryHeadBucketResponse{
+		BucketInfo: toBucketInfo(bucket),
+		ExtraInfo:  toBucketExtraInfo(bucket),
+	}, nil
+}
+
+// HeadBucketById implements storagetypes.QueryServer
+func (s *queryServer) HeadBucketById(ctx context.Context, req *storagetypes.QueryHeadBucketByIdRequest) (*storagetypes.QueryHeadBucketResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	bucketID, ok := parseID(req.BucketId)
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid bucket id")
+	}
+
+	bucket, err := s.repo.GetBucket(ctx, bucketID)
+	if err != nil {
+		return nil, repoError(ctx, "HeadBucketById", err)
+	}
+	if bucket == nil {
+		return nil, storagetypes.ErrNoSuchBucket
+	}
+	return &storagetypes.QueryHeadBucketResponse{
+		BucketInfo: toBucketInfo(bucket),
+		ExtraInfo:  toBucketExtraInfo(bucket),
+	}, nil
+}
+
+// HeadObject implements storagetypes.QueryServer
+func (s *queryServer) HeadObject(ctx context.Context, req *storagetypes.QueryHeadObjectRequest) (*storagetypes.QueryHeadObjectResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+
+	object, err := s.repo.GetObjectByName(ctx, req.BucketName, req.ObjectName)
+	if err != nil {
+		return nil, repoError(ctx, "HeadObject", err)
+	}
+	if object == nil {
+		return nil, storagetypes.ErrNoSuchObject
+	}
+	return s.headObject(ctx, "HeadObject", object)
+}
+
+// HeadObjectById implements storagetypes.QueryServer
+func (s *queryServer) HeadObjectById(ctx context.Context, req *storagetypes.QueryHeadObjectByIdRequest) (*storagetypes.QueryHeadObjectResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	objectID, ok := parseID(req.ObjectId)
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid object id")
+	}
+
+	object, err := s.repo.GetObject(ctx, objectID)
+	if err != nil {
+		return nil, repoError(ctx, "HeadObjectById", err)
+	}
+	if object == nil {
+		return nil, storagetypes.ErrNoSuchObject
+	}
+	return s.headObject(ctx, "HeadObjectById", object)
+}
+
+// headObject returns the object along with the global virtual group storing it once it is sealed
+func (s *queryServer) headObject(ctx context.Context, method string, object *models.Object) (*storagetypes.QueryHeadObjectResponse, error) {
+	bucket, err := s.repo.GetBucketByName(ctx, object.BucketName)
+	if err != nil {
+		return nil, repoError(ctx, method, err)
+	}
+	if bucket == nil {
+		return nil, storagetypes.ErrNoSuchBucket
+	}
+
+	objectInfo := toObjectInfo(object)
+	response := &storagetypes.QueryHeadObjectResponse{ObjectInfo: objectInfo}
+	if objectInfo.ObjectStatus != storagetypes.OBJECT_STATUS_SEALED {
+		return response, nil
+	}
+
+	gvg, err := s.getObjectGVG(ctx, bucket.BucketID, object.LocalVirtualGroupId)
+	if err != nil {
+		return nil, repoError(ctx, method, err)
+	}
+	if gvg == nil {
+		return nil, storagetypes.ErrInvalidGlobalVirtualGroup.Wrapf("gvg not found. objectInfo: %s", objectInfo.String())
+	}
+	response.GlobalVirtualGroup = toGlobalVirtualGroup(gvg)
+	return response, nil
+}
+
+// getObjectGVG returns the global virtual group of the local virtual group of the bucket, nil if there is none
+func (s *queryServer) getObjectGVG(ctx context.Context, bucketID common.Hash, lvgID uint32) (*models.GlobalVirtualGroup, error) {
+	lvgs, err := s.repo.GetLVGsByBucketIDs(ctx, []common.Hash{bucketID})
+	if err != nil {
+		return nil, err
+	}
+	for _, lvg := range lvgs {
+		if lvg.LocalVirtualGroupId == lvgID {
+			return s.repo.GetGVG(ctx, lvg.GlobalVirtualGroupId)
+		}
+	}
+	return nil, nil
+}
+
+// ListObjects implements storagetypes.QueryServer
+func (s *queryServer) ListObjects(ctx context.Context, req *storagetypes.QueryListObjectsRequest) (*storagetypes.QueryListObjectsResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	if req.BucketName == "" {
+		return nil, status.Error(codes.InvalidArgument, "bucket name should not be empty")
+	}
+	page, err := toPage(req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.listObjects(ctx, "ListObjects", req.BucketName, page)
+}
+
+// ListObjectsByBucketId implements storagetypes.QueryServer
+func (s *queryServer) ListObjectsByBucketId(ctx context.Context, req *storagetypes.QueryListObjectsByBucketIdRequest) (*storagetypes.QueryListObjectsResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	page, err := toPage(req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+	bucketID, ok := parseID(req.BucketId)
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid bucket id")
+	}
+
+	bucket, err := s.repo.GetBucket(ctx, bucketID)
+	if err != nil {
+		return nil, repoError(ctx, "ListObjectsByBucketId", err)
+	}
+	if bucket == nil {
+		return nil, storagetypes.ErrNoSuchBucket
+	}
+	return s.listObjects(ctx, "ListObjectsByBucketId", bucket.BucketName, page)
+}
+
+func (s *queryServer) listObjects(ctx context.Context, method, bucketName string, page database.Page) (*storagetypes.QueryListObjectsResponse, error) {
+	objects, cursor, err := s.repo.ListObjectsByPrefix(ctx, bucketName, "", page)
+	if err != nil {
+		return nil, repoError(ctx, method, err)
+	}
+
+	objectInfos := make([]*storagetypes.ObjectInfo, 0, len(objects))
+	for _, object := range objects {
+		objectInfos = append(objectInfos, toObjectInfo(object))
+	}
+	return &storagetypes.QueryListObjectsResponse{ObjectInfos: objectInfos, Pagination: toPageResponse(cursor)}, nil
+}
+
+// HeadGroup implements storagetypes.QueryServer
+func (s *queryServer) HeadGroup(ctx context.Context, req *storagetypes.QueryHeadGroupRequest) (*storagetypes.QueryHeadGroupResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	if !common.IsHexAddress(req.GroupOwner) {
+		return nil, status.Error(codes.InvalidArgument, "invalid group owner")
+	}
+
+	group, err := s.repo.GetGroupByName(ctx, common.HexToAddress(req.GroupOwner), req.GroupName)
+	if err != nil {
+		return nil, repoError(ctx, "HeadGroup", err)
+	}
+	if group == nil {
+		return nil, storagetypes.ErrNoSuchGroup
+	}
+	return &storagetypes.QueryHeadGroupResponse{GroupInfo: toGroupInfo(group)}, nil
+}
+
+// ListGroups implements storagetypes.QueryServer
+func (s *queryServer) ListGroups(ctx context.Context, req *storagetypes.QueryListGroupsRequest) (*storagetypes.QueryListGroupsResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	page, err := toPage(req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+	if !common.IsHexAddress(req.GroupOwner) {
+		return nil, status.Error(codes.InvalidArgument, "invalid group owner")
+	}
+
+	groups, cursor, err := s.repo.ListGroupsByOwner(ctx, common.HexToAddress(req.GroupOwner), page)
+	if err != nil {
+		return nil, repoError(ctx, "ListGroups", err)
+	}
+
+	groupInfos := make([]*storagetypes.GroupInfo, 0, len(groups))
+	for _, group := range groups {
+		groupInfos = append(groupInfos, toGroupInfo(group))
+	}
+	return &storagetypes.QueryListGroupsResponse{GroupInfos: groupInfos, Pagination: toPageResponse(cursor)}, nil
+}
+
+// HeadGroupMember implements storagetypes.QueryServer
+func (s *queryServer) HeadGroupMember(ctx context.Context, req *storagetypes.QueryHeadGroupMemberRequest) (*storagetypes.QueryHeadGroupMemberResponse, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	if !common.IsHexAddress(req.Member) {
+		return nil, status.Error(codes.InvalidArgument, "invalid member")
+	}
+	if !common.IsHexAddress(req.GroupOwner) {
+		return nil, status.Error(codes.InvalidArgument, "invalid group owner")
+	}
+
+	group, err := s.repo.GetGroupByName(ctx, common.HexToAddress(req.GroupOwner), req.GroupName)
+	if err != nil {
+		return nil, repoError(ctx, "HeadGroupMember", err)
+	}
+	if group == nil {
+		return nil, storagetypes.ErrNoSuchGroup
+	}
+	member, err := s.repo.GetGroupMember(ctx, group.GroupID, common.HexToAddress(req.Member))
+	if err != nil {
+		return nil, repoError(ctx, "HeadGroupMember", err)
+	}
+	if member == nil {
+		return nil, storagetypes.ErrNoSuchGroupMember
+	}
+	return &storagetypes.QueryHeadGroupMemberResponse{GroupMember: toGroupMember(member)}, nil
+}
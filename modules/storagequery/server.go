@@ -0,0 +1,119 @@
+package storagequery
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	storagetypes "github.com/evmos/evmos/v12/x/storage/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/log"
+)
+
+// NewServer returns the gRPC server of the storage Query service. The messages are encoded like the
+// chain does, so that the clients of the chain can query it unchanged.
+func NewServer(cfg *Config, repo database.Repository) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ForceServerCodec(codec.NewProtoCodec(codectypes.NewInterfaceRegistry()).GRPCCodec()),
+		grpc.UnaryInterceptor(withHeight(cfg, repo)),
+	)
+	storagetypes.RegisterQueryServer(server, &queryServer{repo: repo})
+	return server
+}
+
+// HeightBoundHeader is set to "lower" in every response, the block height header being a lower bound of the
+// height the state is served at
+const HeightBoundHeader = "x-block-height-bound"
+
+// withHeight serves the requests at the last indexed height, which is returned in the block height header
+// like the chain does. It is the end of the first gap-free sync range, every block up to it is indexed. The
+// blocks are not necessarily indexed in order though, the state may hold the writes of the blocks indexed above
+// it, past a gap: the header is a lower bound of the height the state is served at, as HeightBoundHeader tells.
+// The requests for a height the index has not reached yet are refused, the older heights are served at the last
+// indexed one too since the index only holds the latest state.
+// The height and the reads of the handler are served from one snapshot of the index, so that a block indexed
+// meanwhile is not observed.
+func withHeight(cfg *Config, repo database.Repository) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, cfg.GetRequestTimeout())
+		defer cancel()
+
+		var resp interface{}
+		var serveErr error
+		err := repo.Snapshot(ctx, func(ctx context.Context) error {
+			resp, serveErr = serveAtHeight(ctx, repo, req, info, handler)
+			return serveErr
+		})
+		if serveErr != nil {
+			return nil, serveErr
+		}
+		// the snapshot could not be started or ended
+		if err != nil {
+			return nil, repoError(ctx, info.FullMethod, err)
+		}
+		return resp, nil
+	}
+}
+
+func serveAtHeight(ctx context.Context, repo database.Repository, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	synced, err := repo.GetSyncedHeight(ctx)
+	if err != nil {
+		return nil, repoError(ctx, info.FullMethod, err)
+	}
+	if synced == 0 {
+		return nil, status.Error(codes.Unavailable, "no block is indexed yet")
+	}
+	indexed := int64(synced)
+
+	requested, err := requestedHeight(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if requested > indexed {
+		return nil, status.Errorf(codes.Unavailable, "height %d is not indexed yet, the last indexed height is %d",
+			requested, indexed)
+	}
+
+	err = grpc.SetHeader(ctx, metadata.Pairs(grpctypes.GRPCBlockHeightHeader, strconv.FormatInt(indexed, 10),
+		HeightBoundHeader, "lower"))
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// requestedHeight returns the height set in the block height header of the request, 0 when it is not set
+func requestedHeight(ctx context.Context) (int64, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(grpctypes.GRPCBlockHeightHeader)
+	if len(values) == 0 {
+		return 0, nil
+	}
+	height, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil || height < 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid height %q", values[0])
+	}
+	return height, nil
+}
+
+// repoError returns the status of a failed read of the repository
+func repoError(ctx context.Context, method string, err error) error {
+	if errors.Is(err, database.ErrInvalidCursor) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	// the request timed out or was canceled
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	log.Errorw("failed to serve storage query", "module", ModuleName, "method", method, "err", err)
+	return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,243 @@
+package storagequery_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	storagetypes "github.com/evmos/evmos/v12/x/storage/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	databaseconfig "github.com/forbole/juno/v4/database/config"
+	"github.com/forbole/juno/v4/database/sqlclient"
+	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/modules/storagequery"
+)
+
+func newClient(t *testing.T) (storagetypes.QueryClient, *database.Impl) {
+	db, err := sqlclient.New(&databaseconfig.Config{Type: databaseconfig.SQLite, DSN: "file:storagequery_" + t.Name() + "?mode=memory&cache=shared"})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Epoch{}, &models.SyncRange{}, &models.Bucket{}, &models.Object{}, &models.LocalVirtualGroup{},
+		&models.GlobalVirtualGroup{}, &models.Group{}))
+	impl := &database.Impl{Db: db}
+
+	listener := bufconn.Listen(1 << 20)
+	server := storagequery.NewServer(&storagequery.Config{}, impl)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(codec.NewProtoCodec(codectypes.NewInterfaceRegistry()).GRPCCodec())),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return storagetypes.NewQueryClient(conn), impl
+}
+
+// indexUpTo records the blocks up to height as processed
+func indexUpTo(t *testing.T, db *database.Impl, height uint64) {
+	ctx := context.Background()
+	for h := uint64(1); h <= height; h++ {
+		require.NoError(t, db.SaveProcessedHeight(ctx, h))
+	}
+	require.NoError(t, db.SaveEpoch(ctx, &models.Epoch{OneRowId: true, BlockHeight: int64(height)}))
+}
+
+func TestServer_Height(t *testing.T) {
+	client, db := newClient(t)
+	ctx := context.Background()
+
+	_, err := client.HeadBucket(ctx, &storagetypes.QueryHeadBucketRequest{BucketName: "bucket"})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	indexUpTo(t, db, 10)
+	require.NoError(t, db.SaveBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x01"), BucketName: "bucket"}))
+
+	// the older heights are served at the last indexed one
+	for _, requested := range []string{"", "5", "10"} {
+		callCtx := ctx
+		if requested != "" {
+			callCtx = metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, requested)
+		}
+		var header metadata.MD
+		_, err = client.HeadBucket(callCtx, &storagetypes.QueryHeadBucketRequest{BucketName: "bucket"}, grpc.Header(&header))
+		require.NoError(t, err)
+		require.Equal(t, []string{"10"}, header.Get(grpctypes.GRPCBlockHeightHeader))
+	}
+
+	callCtx := metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, "11")
+	_, err = client.HeadBucket(callCtx, &storagetypes.QueryHeadBucketRequest{BucketName: "bucket"})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Contains(t, err.Error(), "height 11 is not indexed yet, the last indexed height is 10")
+
+	callCtx = metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, "latest")
+	_, err = client.HeadBucket(callCtx, &storagetypes.QueryHeadBucketRequest{BucketName: "bucket"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestServer_HeightBelowGap verifies that the requests are bounded by the blocks indexed without a gap, not by
+// an epoch saved past a block still missing
+func TestServer_HeightBelowGap(t *testing.T) {
+	client, db := newClient(t)
+	ctx := context.Background()
+
+	for _, h := range []uint64{1, 2, 3, 4, 5, 7, 8, 9, 10} {
+		require.NoError(t, db.SaveProcessedHeight(ctx, h))
+	}
+	require.NoError(t, db.Db.Create(&models.Epoch{OneRowId: true, BlockHeight: 10}).Error)
+	require.NoError(t, db.SaveBucket(ctx, &models.Bucket{BucketID: common.HexToHash("0x01"), BucketName: "bucket"}))
+
+	var header metadata.MD
+	_, err := client.HeadBucket(ctx, &storagetypes.QueryHeadBucketRequest{BucketName: "bucket"}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Equal(t, []string{"5"}, header.Get(grpctypes.GRPCBlockHeightHeader))
+	// the state holds the writes of the blocks 7 to 10 too
+	require.Equal(t, []string{"lower"}, header.Get(storagequery.HeightBoundHeader))
+
+	callCtx := metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, "8")
+	_, err = client.HeadBucket(callCtx, &storagetypes.QueryHeadBucketRequest{BucketName: "bucket"})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Contains(t, err.Error(), "height 8 is not indexed yet, the last indexed height is 5")
+
+	// the gap is filled
+	require.NoError(t, db.SaveProcessedHeight(ctx, 6))
+	_, err = client.HeadBucket(callCtx, &storagetypes.QueryHeadBucketRequest{BucketName: "bucket"}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Equal(t, []string{"10"}, header.Get(grpctypes.GRPCBlockHeightHeader))
+}
+
+func TestServer_BucketsAndObjects(t *testing.T) {
+	client, db := newClient(t)
+	ctx := context.Background()
+	indexUpTo(t, db, 10)
+
+	bucketID := common.HexToHash("0x01")
+	owner := common.HexToAddress("0x0a")
+	require.NoError(t, db.SaveBucket(ctx, &models.Bucket{
+		BucketID: bucketID, BucketName: "bucket", Owner: owner, Visibility: storagetypes.VISIBILITY_TYPE_PUBLIC_READ.String(),
+		Status: storagetypes.BUCKET_STATUS_CREATED.String(), CreateTime: 1700000000,
+	}))
+	require.NoError(t, db.SaveObject(ctx, &models.Object{
+		BucketID: bucketID, BucketName: "bucket", ObjectID: common.HexToHash("0x02"), ObjectName: "sealed",
+		Owner: owner, LocalVirtualGroupId: 1, PayloadSize: 42, Status: storagetypes.OBJECT_STATUS_SEALED.String(),
+	}))
+	require.NoError(t, db.SaveObject(ctx, &models.Object{
+		BucketID: bucketID, BucketName: "bucket", ObjectID: common.HexToHash("0x03"), ObjectName: "created",
+		Owner: owner, Status: storagetypes.OBJECT_STATUS_CREATED.String(),
+	}))
+	require.NoError(t, db.Db.Create(&models.LocalVirtualGroup{LocalVirtualGroupId: 1, GlobalVirtualGroupId: 7, BucketID: bucketID}).Error)
+	require.NoError(t, db.Db.Create(&models.GlobalVirtualGroup{GlobalVirtualGroupId: 7, PrimarySpId: 1, SecondarySpIds: common.Uint32Array{2, 3}}).Error)
+
+	bucket, err := client.HeadBucket(ctx, &storagetypes.QueryHeadBucketRequest{BucketName: "bucket"})
+	require.NoError(t, err)
+	require.Equal(t, "1", bucket.BucketInfo.Id.String())
+	require.Equal(t, owner.String(), bucket.BucketInfo.Owner)
+	require.Equal(t, storagetypes.VISIBILITY_TYPE_PUBLIC_READ, bucket.BucketInfo.Visibility)
+	require.Equal(t, int64(1700000000), bucket.BucketInfo.CreateAt)
+	require.Equal(t, "-1", bucket.ExtraInfo.FlowRateLimit.String())
+
+	bucket, err = client.HeadBucketById(ctx, &storagetypes.QueryHeadBucketByIdRequest{BucketId: "1"})
+	require.NoError(t, err)
+	require.Equal(t, "bucket", bucket.BucketInfo.BucketName)
+	require.Equal(t, "-1", bucket.ExtraInfo.FlowRateLimit.String())
+
+	_, err = client.HeadBucket(ctx, &storagetypes.QueryHeadBucketRequest{BucketName: "missing"})
+	require.ErrorContains(t, err, storagetypes.ErrNoSuchBucket.Error())
+	_, err = client.HeadBucketById(ctx, &storagetypes.QueryHeadBucketByIdRequest{BucketId: "bucket"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	object, err := client.HeadObject(ctx, &storagetypes.QueryHeadObjectRequest{BucketName: "bucket", ObjectName: "sealed"})
+	require.NoError(t, err)
+	require.Equal(t, "2", object.ObjectInfo.Id.String())
+	require.Equal(t, uint64(42), object.ObjectInfo.PayloadSize)
+	require.Equal(t, uint32(7), object.GlobalVirtualGroup.Id)
+	require.Equal(t, []uint32{2, 3}, object.GlobalVirtualGroup.SecondarySpIds)
+
+	object, err = client.HeadObjectById(ctx, &storagetypes.QueryHeadObjectByIdRequest{ObjectId: "3"})
+	require.NoError(t, err)
+	require.Equal(t, "created", object.ObjectInfo.ObjectName)
+	require.Nil(t, object.GlobalVirtualGroup)
+
+	_, err = client.HeadObjectById(ctx, &storagetypes.QueryHeadObjectByIdRequest{ObjectId: "4"})
+	require.ErrorContains(t, err, storagetypes.ErrNoSuchObject.Error())
+
+	objects, err := client.ListObjects(ctx, &storagetypes.QueryListObjectsRequest{BucketName: "bucket", Pagination: &query.PageRequest{Limit: 1}})
+	require.NoError(t, err)
+	require.Len(t, objects.ObjectInfos, 1)
+	require.Equal(t, "sealed", objects.ObjectInfos[0].ObjectName)
+	require.NotEmpty(t, objects.Pagination.NextKey)
+
+	objects, err = client.ListObjectsByBucketId(ctx, &storagetypes.QueryListObjectsByBucketIdRequest{
+		BucketId: "1", Pagination: &query.PageRequest{Key: objects.Pagination.NextKey, Limit: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, objects.ObjectInfos, 1)
+	require.Equal(t, "created", objects.ObjectInfos[0].ObjectName)
+	require.Empty(t, objects.Pagination.NextKey)
+
+	_, err = client.ListObjects(ctx, &storagetypes.QueryListObjectsRequest{BucketName: "bucket", Pagination: &query.PageRequest{Offset: 1}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.ListObjects(ctx, &storagetypes.QueryListObjectsRequest{BucketName: "bucket", Pagination: &query.PageRequest{Key: []byte("key")}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// the queries the index cannot answer are left unimplemented
+	_, err = client.ListBuckets(ctx, &storagetypes.QueryListBucketsRequest{})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestServer_Groups(t *testing.T) {
+	client, db := newClient(t)
+	ctx := context.Background()
+	indexUpTo(t, db, 10)
+
+	owner := common.HexToAddress("0x0a")
+	member := common.HexToAddress("0x0b")
+	require.NoError(t, db.Db.Create([]*models.Group{
+		{GroupID: common.HexToHash("0x01"), GroupName: "group", Owner: owner, Extra: "extra"},
+		{GroupID: common.HexToHash("0x01"), GroupName: "group", Owner: owner, AccountID: member, ExpirationTime: 1700000000},
+		{GroupID: common.HexToHash("0x02"), GroupName: "other", Owner: owner},
+	}).Error)
+
+	group, err := client.HeadGroup(ctx, &storagetypes.QueryHeadGroupRequest{GroupOwner: owner.String(), GroupName: "group"})
+	require.NoError(t, err)
+	require.Equal(t, "1", group.GroupInfo.Id.String())
+	require.Equal(t, "extra", group.GroupInfo.Extra)
+
+	_, err = client.HeadGroup(ctx, &storagetypes.QueryHeadGroupRequest{GroupOwner: owner.String(), GroupName: "missing"})
+	require.ErrorContains(t, err, storagetypes.ErrNoSuchGroup.Error())
+	_, err = client.HeadGroup(ctx, &storagetypes.QueryHeadGroupRequest{GroupOwner: "owner", GroupName: "group"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	groups, err := client.ListGroups(ctx, &storagetypes.QueryListGroupsRequest{GroupOwner: owner.String()})
+	require.NoError(t, err)
+	require.Len(t, groups.GroupInfos, 2)
+	require.Equal(t, "group", groups.GroupInfos[0].GroupName)
+	require.Equal(t, "other", groups.GroupInfos[1].GroupName)
+
+	groupMember, err := client.HeadGroupMember(ctx, &storagetypes.QueryHeadGroupMemberRequest{
+		Member: member.String(), GroupOwner: owner.String(), GroupName: "group",
+	})
+	require.NoError(t, err)
+	require.Equal(t, member.String(), groupMember.GroupMember.Member)
+	require.Equal(t, "1", groupMember.GroupMember.GroupId.String())
+	require.Equal(t, int64(1700000000), groupMember.GroupMember.ExpirationTime.Unix())
+
+	_, err = client.HeadGroupMember(ctx, &storagetypes.QueryHeadGroupMemberRequest{
+		Member: owner.String(), GroupOwner: owner.String(), GroupName: "group",
+	})
+	require.ErrorContains(t, err, storagetypes.ErrNoSuchGroupMember.Error())
+}
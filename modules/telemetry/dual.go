@@ -0,0 +1,88 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/forbole/juno/v4/database/dual"
+	"github.com/forbole/juno/v4/log"
+)
+
+// watermarkResponse describes the indexing state of one of the databases written by the dual database
+type watermarkResponse struct {
+	Name          string `json:"name"`
+	Height        uint64 `json:"height"`
+	Lag           uint64 `json:"lag"`
+	Authoritative bool   `json:"authoritative"`
+	Diverged      string `json:"diverged,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// registerDualRoutes adds the routes switching the authoritative database of db while the indexer runs:
+// GET /dual returns the watermarks of the databases, and POST /dual/switch?to=secondary makes the given database
+// authoritative, with the checks of dual.Database.Switch unless force=true is set
+func registerDualRoutes(router *mux.Router, db *dual.Database) {
+	router.HandleFunc("/dual", func(w http.ResponseWriter, r *http.Request) {
+		writeWatermarks(w, http.StatusOK, db)
+	}).Methods(http.MethodGet)
+
+	router.HandleFunc("/dual/switch", func(w http.ResponseWriter, r *http.Request) {
+		var to int
+		switch r.URL.Query().Get("to") {
+		case "primary":
+			to = dual.Primary
+		case "secondary":
+			to = dual.Secondary
+		default:
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "to must be primary or secondary"})
+			return
+		}
+
+		force := false
+		if value := r.URL.Query().Get("force"); value != "" {
+			var err error
+			if force, err = strconv.ParseBool(value); err != nil {
+				writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid force " + strconv.Quote(value)})
+				return
+			}
+		}
+
+		if err := db.Switch(to, force); err != nil {
+			writeJSON(w, http.StatusConflict, errorResponse{Error: err.Error()})
+			return
+		}
+		writeWatermarks(w, http.StatusOK, db)
+	}).Methods(http.MethodPost)
+}
+
+func writeWatermarks(w http.ResponseWriter, status int, db *dual.Database) {
+	watermarks := db.Watermarks()
+	result := make([]watermarkResponse, 0, len(watermarks))
+	for _, watermark := range watermarks {
+		resp := watermarkResponse{
+			Name:          watermark.Name,
+			Height:        watermark.Height,
+			Lag:           watermark.Lag,
+			Authoritative: watermark.Authoritative,
+		}
+		if watermark.Diverged != nil {
+			resp.Diverged = watermark.Diverged.Error()
+		}
+		result = append(result, resp)
+	}
+	writeJSON(w, status, result)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Debugw("failed to write telemetry response", "module", ModuleName, "err", err)
+	}
+}
@@ -0,0 +1,87 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/database/dual"
+	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/modules/testutil"
+)
+
+func processBlock(t *testing.T, db database.Database, height uint64) {
+	ctx := context.Background()
+	tx := db.Begin(ctx)
+	require.NoError(t, tx.SaveProcessedHeight(ctx, height))
+	require.NoError(t, tx.SaveEpoch(ctx, &models.Epoch{OneRowId: true, BlockHeight: int64(height)}))
+	require.NoError(t, tx.Commit())
+}
+
+func request(t *testing.T, router http.Handler, method, target string) (int, []watermarkResponse) {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var watermarks []watermarkResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &watermarks))
+	return rec.Code, watermarks
+}
+
+func TestDualRoutes_Switch(t *testing.T) {
+	primary := testutil.NewDB(t, &models.SyncRange{}, &models.Epoch{})
+	secondary := testutil.NewDB(t, &models.SyncRange{}, &models.Epoch{})
+	db := dual.New(primary, secondary, dual.Primary)
+	router := mux.NewRouter()
+	registerDualRoutes(router, db)
+
+	processBlock(t, db, 1)
+	processBlock(t, db, 2)
+	code, watermarks := request(t, router, http.MethodGet, "/dual")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, []watermarkResponse{
+		{Name: "primary", Height: 2, Authoritative: true},
+		{Name: "secondary", Height: 2},
+	}, watermarks)
+
+	code, watermarks = request(t, router, http.MethodPost, "/dual/switch?to=secondary")
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, watermarks[dual.Secondary].Authoritative)
+	code, _ = request(t, router, http.MethodPost, "/dual/switch?to=primary")
+	require.Equal(t, http.StatusOK, code)
+
+	// a failed write leaves the secondary database behind, it is only switched to when forced
+	require.NoError(t, secondary.Db.Migrator().DropTable(&models.SyncRange{}))
+	processBlock(t, db, 3)
+	code, _ = request(t, router, http.MethodPost, "/dual/switch?to=secondary")
+	require.Equal(t, http.StatusConflict, code)
+	require.Equal(t, dual.Primary, authoritative(db))
+
+	code, watermarks = request(t, router, http.MethodPost, "/dual/switch?to=secondary&force=true")
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, watermarks[dual.Secondary].Authoritative)
+	require.NotEmpty(t, watermarks[dual.Secondary].Diverged)
+
+	for _, target := range []string{"/dual/switch", "/dual/switch?to=third", "/dual/switch?to=primary&force=maybe"} {
+		code, _ = request(t, router, http.MethodPost, target)
+		require.Equal(t, http.StatusBadRequest, code, target)
+	}
+	code, _ = request(t, router, http.MethodGet, "/dual/switch?to=primary")
+	require.Equal(t, http.StatusMethodNotAllowed, code)
+}
+
+func authoritative(db *dual.Database) int {
+	for i, watermark := range db.Watermarks() {
+		if watermark.Authoritative {
+			return i
+		}
+	}
+	return -1
+}
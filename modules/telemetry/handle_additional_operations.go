@@ -7,16 +7,19 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/database/dual"
 )
 
 // RunAdditionalOperations runs the module additional operations
-func RunAdditionalOperations(cfg *Config) error {
+func RunAdditionalOperations(cfg *Config, db database.Database) error {
 	err := checkConfig(cfg)
 	if err != nil {
 		return err
 	}
 
-	go startPrometheus(cfg)
+	go startPrometheus(cfg, db)
 
 	return nil
 }
@@ -30,10 +33,14 @@ func checkConfig(cfg *Config) error {
 	return nil
 }
 
-// startPrometheus starts a Prometheus server using the given configuration
-func startPrometheus(cfg *Config) {
+// startPrometheus starts a Prometheus server using the given configuration. When db writes to two databases,
+// the server also switches the authoritative one.
+func startPrometheus(cfg *Config, db database.Database) {
 	router := mux.NewRouter()
 	router.Handle("/metrics", promhttp.Handler())
+	if dualDb, ok := db.(*dual.Database); ok {
+		registerDualRoutes(router, dualDb)
+	}
 
 	// Create a new server
 	server := http.Server{
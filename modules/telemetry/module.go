@@ -1,6 +1,7 @@
 package telemetry
 
 import (
+	"github.com/forbole/juno/v4/database"
 	"github.com/forbole/juno/v4/modules"
 	"github.com/forbole/juno/v4/types/config"
 )
@@ -17,10 +18,11 @@ var (
 // Module represents the telemetry module
 type Module struct {
 	cfg *Config
+	db  database.Database
 }
 
 // NewModule returns a new Module implementation
-func NewModule(cfg config.Config) *Module {
+func NewModule(cfg config.Config, db database.Database) *Module {
 	bz, err := cfg.GetBytes()
 	if err != nil {
 		panic(err)
@@ -33,6 +35,7 @@ func NewModule(cfg config.Config) *Module {
 
 	return &Module{
 		cfg: telemetryCfg,
+		db:  db,
 	}
 }
 
@@ -43,5 +46,5 @@ func (m *Module) Name() string {
 
 // RunAdditionalOperations implements modules.AdditionalOperationsModule
 func (m *Module) RunAdditionalOperations() error {
-	return RunAdditionalOperations(m.cfg)
+	return RunAdditionalOperations(m.cfg, m.db)
 }
@@ -0,0 +1,87 @@
+// Package testutil holds the fixtures shared by the tests of the modules.
+package testutil
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cosmossdk.io/simapp/params"
+	tmctypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	moduletestutil "github.com/cosmos/cosmos-sdk/types/module/testutil"
+	"github.com/cosmos/gogoproto/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	databaseconfig "github.com/forbole/juno/v4/database/config"
+	"github.com/forbole/juno/v4/database/sqlclient"
+	"github.com/forbole/juno/v4/modules"
+	"github.com/forbole/juno/v4/parser"
+)
+
+// NewDB returns a database on a sqlite file private to the test, with the tables of the given models created.
+// The file is removed once the test ends, so that no state is shared between tests.
+func NewDB(t testing.TB, models ...interface{}) *database.Impl {
+	cfg := databaseconfig.NewDatabaseConfig("file:"+filepath.Join(t.TempDir(), "juno.db"), -1, -1, 100000, 100)
+	cfg.Type = databaseconfig.SQLite
+	db, err := sqlclient.New(&cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlclient.Close(db) })
+
+	require.NoError(t, db.AutoMigrate(models...))
+
+	encoding := moduletestutil.MakeTestEncodingConfig()
+	return &database.Impl{
+		Db: db,
+		EncodingConfig: &params.EncodingConfig{
+			InterfaceRegistry: encoding.InterfaceRegistry,
+			Codec:             encoding.Codec,
+			TxConfig:          encoding.TxConfig,
+			Amino:             encoding.Amino,
+		},
+	}
+}
+
+// Block returns the block at the given height, timed 1000 seconds after the epoch plus its height
+func Block(height int64) *tmctypes.ResultBlock {
+	return &tmctypes.ResultBlock{Block: &tmtypes.Block{Header: tmtypes.Header{Height: height, Time: time.Unix(1000+height, 0)}}}
+}
+
+// EventHandler is the part of a modules.EventModule the tests call
+type EventHandler interface {
+	HandleEvent(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, event sdk.Event) error
+}
+
+// HandleEvent has the module handle the typed event, emitted by the tx txHash of the block at the given height
+func HandleEvent(t testing.TB, ctx context.Context, module EventHandler, height int64, txHash common.Hash, event proto.Message) {
+	sdkEvent, err := sdk.TypedEventToEvent(event)
+	require.NoError(t, err)
+	require.NoError(t, module.HandleEvent(ctx, Block(height), txHash, sdkEvent))
+}
+
+// eventModule registers an EventHandler with the indexer as a modules.EventModule
+type eventModule struct {
+	EventHandler
+}
+
+func (m eventModule) Name() string { return "test" }
+
+func (m eventModule) ExtractEventStatements(context.Context, *tmctypes.ResultBlock, common.Hash, sdk.Event) (map[string][]interface{}, error) {
+	return nil, nil
+}
+
+func (m eventModule) SetCtx(string, interface{}) {}
+
+func (m eventModule) GetCtx(string) interface{} { return nil }
+
+func (m eventModule) ClearCtx() {}
+
+// ExportBlockEvents has the indexer hand the events emitted at the end of the block to the module
+func ExportBlockEvents(t testing.TB, ctx context.Context, db database.Database, module EventHandler, results *tmctypes.ResultBlockResults) {
+	indexer := &parser.Impl{Ctx: ctx, DB: db, Modules: []modules.Module{eventModule{module}}}
+	require.NoError(t, indexer.ExportBlockEvents(ctx, Block(results.Height), results))
+}
@@ -38,10 +38,10 @@ func (m *Module) Name() string {
 
 // PrepareTables implements
 func (m *Module) PrepareTables() error {
-	return m.db.PrepareTables(context.TODO(), []schema.Tabler{&models.GlobalVirtualGroup{}, &models.LocalVirtualGroup{}, &models.GlobalVirtualGroupFamily{}})
+	return m.db.PrepareTables(context.TODO(), []schema.Tabler{&models.GlobalVirtualGroup{}, &models.LocalVirtualGroup{}, &models.GlobalVirtualGroupFamily{}, &models.VgSwap{}})
 }
 
 // AutoMigrate implements
 func (m *Module) AutoMigrate() error {
-	return m.db.AutoMigrate(context.TODO(), []schema.Tabler{&models.GlobalVirtualGroup{}, &models.LocalVirtualGroup{}, &models.GlobalVirtualGroupFamily{}})
+	return m.db.AutoMigrate(context.TODO(), []schema.Tabler{&models.GlobalVirtualGroup{}, &models.LocalVirtualGroup{}, &models.GlobalVirtualGroupFamily{}, &models.VgSwap{}})
 }
@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"cosmossdk.io/math"
+	"github.com/cosmos/gogoproto/proto"
+	vgtypes "github.com/evmos/evmos/v12/x/virtualgroup/types"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
+	"github.com/forbole/juno/v4/models"
+	"github.com/forbole/juno/v4/modules/testutil"
+	virtualgroup "github.com/forbole/juno/v4/modules/virtual_group"
+)
+
+type VirtualGroupHandlerTestSuite struct {
+	suite.Suite
+	db     *database.Impl
+	module *virtualgroup.Module
+	ctx    context.Context
+}
+
+func TestVirtualGroupHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(VirtualGroupHandlerTestSuite))
+}
+
+func (s *VirtualGroupHandlerTestSuite) SetupTest() {
+	s.db = testutil.NewDB(s.T(), &models.GlobalVirtualGroup{}, &models.GlobalVirtualGroupFamily{}, &models.VgSwap{})
+	s.module = virtualgroup.NewModule(s.db)
+	s.ctx = context.Background()
+}
+
+func (s *VirtualGroupHandlerTestSuite) handle(height int64, event proto.Message) {
+	testutil.HandleEvent(s.T(), s.ctx, s.module, height, common.BigToHash(math.NewInt(height).BigInt()), event)
+}
+
+func (s *VirtualGroupHandlerTestSuite) createFamily(familyId, primarySpId uint32, gvgs map[uint32][]uint32) {
+	gvgIds := make([]uint32, 0, len(gvgs))
+	for gvgId, secondarySpIds := range gvgs {
+		gvgIds = append(gvgIds, gvgId)
+		s.handle(1, &vgtypes.EventCreateGlobalVirtualGroup{
+			Id:             gvgId,
+			FamilyId:       familyId,
+			PrimarySpId:    primarySpId,
+			SecondarySpIds: secondarySpIds,
+			TotalDeposit:   math.ZeroInt(),
+		})
+	}
+	s.handle(1, &vgtypes.EventCreateGlobalVirtualGroupFamily{
+		Id:                    familyId,
+		PrimarySpId:           primarySpId,
+		GlobalVirtualGroupIds: gvgIds,
+	})
+}
+
+func (s *VirtualGroupHandlerTestSuite) getSwaps(swapType string, familyId, gvgId uint32) []models.VgSwap {
+	var swaps []models.VgSwap
+	s.Require().NoError(s.db.Db.Where("swap_type = ? AND global_virtual_group_family_id = ? AND global_virtual_group_id = ?", swapType, familyId, gvgId).
+		Order("id").Find(&swaps).Error)
+	return swaps
+}
+
+// TestSwapOutFamily_UpdatesPrimarySp verifies that a completed family swap out moves the family and its gvgs to the successor
+func (s *VirtualGroupHandlerTestSuite) TestSwapOutFamily_UpdatesPrimarySp() {
+	s.createFamily(10, 1, map[uint32][]uint32{100: {2, 3}, 101: {3, 4}})
+
+	s.handle(10, &vgtypes.EventSwapOut{StorageProviderId: 1, GlobalVirtualGroupFamilyId: 10, SuccessorSpId: 5})
+	swaps := s.getSwaps(models.VgSwapTypeSwapOut, 10, 0)
+	s.Require().Len(swaps, 1)
+	s.Equal(models.VgSwapStatusPending, swaps[0].Status)
+	s.Equal(uint32(1), swaps[0].SpId)
+	s.Equal(uint32(5), swaps[0].SuccessorSpId)
+
+	s.handle(20, &vgtypes.EventCompleteSwapOut{StorageProviderId: 5, SrcStorageProviderId: 1, GlobalVirtualGroupFamilyId: 10})
+	swaps = s.getSwaps(models.VgSwapTypeSwapOut, 10, 0)
+	s.Require().Len(swaps, 1)
+	s.Equal(models.VgSwapStatusCompleted, swaps[0].Status)
+	s.Equal(int64(20), swaps[0].UpdateAt)
+
+	var vgf models.GlobalVirtualGroupFamily
+	s.Require().NoError(s.db.Db.Where("global_virtual_group_family_id = ?", 10).Take(&vgf).Error)
+	s.Equal(uint32(5), vgf.PrimarySpId)
+
+	for _, gvgId := range []uint32{100, 101} {
+		gvg, err := s.db.GetGVG(s.ctx, gvgId)
+		s.Require().NoError(err)
+		s.Equal(uint32(5), gvg.PrimarySpId)
+	}
+}
+
+// TestSwapOutSecondary_CancelAndComplete verifies that a cancelled swap out leaves the gvg untouched
+// and a completed one only replaces the exiting secondary sp
+func (s *VirtualGroupHandlerTestSuite) TestSwapOutSecondary_CancelAndComplete() {
+	s.createFamily(20, 1, map[uint32][]uint32{200: {2, 3, 4}})
+
+	s.handle(10, &vgtypes.EventSwapOut{StorageProviderId: 3, GlobalVirtualGroupIds: []uint32{200}, SuccessorSpId: 6})
+	s.handle(20, &vgtypes.EventCancelSwapOut{StorageProviderId: 3, GlobalVirtualGroupIds: []uint32{200}, SuccessorSpId: 6})
+	s.handle(30, &vgtypes.EventSwapOut{StorageProviderId: 3, GlobalVirtualGroupIds: []uint32{200}, SuccessorSpId: 7})
+
+	gvg, err := s.db.GetGVG(s.ctx, 200)
+	s.Require().NoError(err)
+	s.Equal(common.Uint32Array{2, 3, 4}, gvg.SecondarySpIds)
+
+	s.handle(40, &vgtypes.EventCompleteSwapOut{StorageProviderId: 7, SrcStorageProviderId: 3, GlobalVirtualGroupIds: []uint32{200}})
+
+	swaps := s.getSwaps(models.VgSwapTypeSwapOut, 0, 200)
+	s.Require().Len(swaps, 2)
+	s.Equal(models.VgSwapStatusCancelled, swaps[0].Status)
+	s.Equal(models.VgSwapStatusCompleted, swaps[1].Status)
+
+	gvg, err = s.db.GetGVG(s.ctx, 200)
+	s.Require().NoError(err)
+	s.Equal(uint32(1), gvg.PrimarySpId)
+	s.Equal(common.Uint32Array{2, 7, 4}, gvg.SecondarySpIds)
+}
+
+// TestSwapIn_ReserveOverrideAndComplete verifies the swap in state machine including an overridden reservation
+func (s *VirtualGroupHandlerTestSuite) TestSwapIn_ReserveOverrideAndComplete() {
+	s.createFamily(30, 1, map[uint32][]uint32{300: {2, 3}})
+
+	s.handle(10, &vgtypes.EventReserveSwapIn{StorageProviderId: 8, GlobalVirtualGroupId: 300, TargetSpId: 2, ExpirationTime: 1100})
+	s.handle(20, &vgtypes.EventReserveSwapIn{StorageProviderId: 9, GlobalVirtualGroupId: 300, TargetSpId: 2, ExpirationTime: 1200})
+	s.handle(30, &vgtypes.EventCompleteSwapIn{StorageProviderId: 9, TargetStorageProviderId: 2, GlobalVirtualGroupId: 300})
+
+	swaps := s.getSwaps(models.VgSwapTypeSwapIn, 0, 300)
+	s.Require().Len(swaps, 2)
+	s.Equal(uint32(8), swaps[0].SuccessorSpId)
+	s.Equal(models.VgSwapStatusCancelled, swaps[0].Status)
+	s.Equal(uint32(9), swaps[1].SuccessorSpId)
+	s.Equal(uint64(1200), swaps[1].ExpirationTime)
+	s.Equal(models.VgSwapStatusCompleted, swaps[1].Status)
+
+	gvg, err := s.db.GetGVG(s.ctx, 300)
+	s.Require().NoError(err)
+	s.Equal(common.Uint32Array{9, 3}, gvg.SecondarySpIds)
+
+	s.handle(40, &vgtypes.EventReserveSwapIn{StorageProviderId: 4, GlobalVirtualGroupFamilyId: 30, TargetSpId: 1, ExpirationTime: 1300})
+	s.handle(50, &vgtypes.EventCancelSwapIn{StorageProviderId: 4, GlobalVirtualGroupFamilyId: 30, TargetSpId: 1})
+
+	swaps = s.getSwaps(models.VgSwapTypeSwapIn, 30, 0)
+	s.Require().Len(swaps, 1)
+	s.Equal(models.VgSwapStatusCancelled, swaps[0].Status)
+
+	var vgf models.GlobalVirtualGroupFamily
+	s.Require().NoError(s.db.Db.Where("global_virtual_group_family_id = ?", 30).Take(&vgf).Error)
+	s.Equal(uint32(1), vgf.PrimarySpId)
+}
+
+// TestSwap_ProcessedAgain verifies that a swap is started once when its block is processed again
+func (s *VirtualGroupHandlerTestSuite) TestSwap_ProcessedAgain() {
+	for i := 0; i < 2; i++ {
+		s.handle(10, &vgtypes.EventSwapOut{StorageProviderId: 3, GlobalVirtualGroupIds: []uint32{200, 201}, SuccessorSpId: 6})
+		s.handle(20, &vgtypes.EventReserveSwapIn{StorageProviderId: 8, GlobalVirtualGroupId: 300, TargetSpId: 2, ExpirationTime: 1100})
+	}
+
+	for _, gvgId := range []uint32{200, 201} {
+		swaps := s.getSwaps(models.VgSwapTypeSwapOut, 0, gvgId)
+		s.Require().Len(swaps, 1)
+		s.Equal(models.VgSwapStatusPending, swaps[0].Status)
+	}
+	// the reservation is not taken for the expired one it overrides
+	swaps := s.getSwaps(models.VgSwapTypeSwapIn, 0, 300)
+	s.Require().Len(swaps, 1)
+	s.Equal(models.VgSwapStatusReserved, swaps[0].Status)
+}
@@ -11,6 +11,7 @@ import (
 	vgtypes "github.com/evmos/evmos/v12/x/virtualgroup/types"
 
 	"github.com/forbole/juno/v4/common"
+	"github.com/forbole/juno/v4/database"
 	"github.com/forbole/juno/v4/log"
 	"github.com/forbole/juno/v4/models"
 )
@@ -25,6 +26,12 @@ var (
 	EventCreateGlobalVirtualGroupFamily = proto.MessageName(&vgtypes.EventCreateGlobalVirtualGroupFamily{})
 	EventDeleteGlobalVirtualGroupFamily = proto.MessageName(&vgtypes.EventDeleteGlobalVirtualGroupFamily{})
 	EventUpdateGlobalVirtualGroupFamily = proto.MessageName(&vgtypes.EventUpdateGlobalVirtualGroupFamily{})
+	EventSwapOut                        = proto.MessageName(&vgtypes.EventSwapOut{})
+	EventCompleteSwapOut                = proto.MessageName(&vgtypes.EventCompleteSwapOut{})
+	EventCancelSwapOut                  = proto.MessageName(&vgtypes.EventCancelSwapOut{})
+	EventReserveSwapIn                  = proto.MessageName(&vgtypes.EventReserveSwapIn{})
+	EventCompleteSwapIn                 = proto.MessageName(&vgtypes.EventCompleteSwapIn{})
+	EventCancelSwapIn                   = proto.MessageName(&vgtypes.EventCancelSwapIn{})
 )
 
 var virtualGroupEvents = map[string]bool{
@@ -37,6 +44,12 @@ var virtualGroupEvents = map[string]bool{
 	EventCreateGlobalVirtualGroupFamily: true,
 	EventDeleteGlobalVirtualGroupFamily: true,
 	EventUpdateGlobalVirtualGroupFamily: true,
+	EventSwapOut:                        true,
+	EventCompleteSwapOut:                true,
+	EventCancelSwapOut:                  true,
+	EventReserveSwapIn:                  true,
+	EventCompleteSwapIn:                 true,
+	EventCancelSwapIn:                   true,
 }
 
 func (m *Module) ExtractEventStatements(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, event sdk.Event) (map[string][]interface{}, error) {
@@ -119,6 +132,48 @@ func (m *Module) HandleEvent(ctx context.Context, block *tmctypes.ResultBlock, t
 		}
 		data := m.handleUpdateGlobalVirtualGroupFamily(ctx, block, txHash, updateGlobalVirtualGroupFamily)
 		return m.db.UpdateVGF(ctx, data)
+	case EventSwapOut:
+		swapOut, ok := typedEvent.(*vgtypes.EventSwapOut)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventSwapOut", "event", typedEvent)
+			return errors.New("swap out event assert error")
+		}
+		return m.handleSwapOut(ctx, block, txHash, swapOut)
+	case EventCompleteSwapOut:
+		completeSwapOut, ok := typedEvent.(*vgtypes.EventCompleteSwapOut)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventCompleteSwapOut", "event", typedEvent)
+			return errors.New("complete swap out event assert error")
+		}
+		return m.handleCompleteSwapOut(ctx, block, txHash, completeSwapOut)
+	case EventCancelSwapOut:
+		cancelSwapOut, ok := typedEvent.(*vgtypes.EventCancelSwapOut)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventCancelSwapOut", "event", typedEvent)
+			return errors.New("cancel swap out event assert error")
+		}
+		return m.handleCancelSwapOut(ctx, block, txHash, cancelSwapOut)
+	case EventReserveSwapIn:
+		reserveSwapIn, ok := typedEvent.(*vgtypes.EventReserveSwapIn)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventReserveSwapIn", "event", typedEvent)
+			return errors.New("reserve swap in event assert error")
+		}
+		return m.handleReserveSwapIn(ctx, block, txHash, reserveSwapIn)
+	case EventCompleteSwapIn:
+		completeSwapIn, ok := typedEvent.(*vgtypes.EventCompleteSwapIn)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventCompleteSwapIn", "event", typedEvent)
+			return errors.New("complete swap in event assert error")
+		}
+		return m.handleCompleteSwapIn(ctx, block, txHash, completeSwapIn)
+	case EventCancelSwapIn:
+		cancelSwapIn, ok := typedEvent.(*vgtypes.EventCancelSwapIn)
+		if !ok {
+			log.Errorw("type assert error", "type", "EventCancelSwapIn", "event", typedEvent)
+			return errors.New("cancel swap in event assert error")
+		}
+		return m.handleCancelSwapIn(ctx, block, txHash, cancelSwapIn)
 	}
 
 	return nil
@@ -265,3 +320,208 @@ func (m *Module) handleUpdateGlobalVirtualGroupFamily(ctx context.Context, block
 		UpdateTime:   block.Block.Time.UTC().Unix(),
 	}
 }
+
+func (m *Module) handleSwapOut(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, swapOut *vgtypes.EventSwapOut) error {
+	tx := m.db.Begin(ctx)
+	for _, target := range swapTargets(swapOut.GlobalVirtualGroupFamilyId, swapOut.GlobalVirtualGroupIds) {
+		swap := newVgSwap(block, txHash, models.VgSwapTypeSwapOut, target[0], target[1], models.VgSwapStatusPending)
+		swap.SpId = swapOut.StorageProviderId
+		swap.SuccessorSpId = swapOut.SuccessorSpId
+		if err := tx.SaveVgSwap(ctx, swap); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (m *Module) handleCancelSwapOut(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, cancelSwapOut *vgtypes.EventCancelSwapOut) error {
+	tx := m.db.Begin(ctx)
+	for _, target := range swapTargets(cancelSwapOut.GlobalVirtualGroupFamilyId, cancelSwapOut.GlobalVirtualGroupIds) {
+		swap := updateVgSwap(block, txHash, models.VgSwapTypeSwapOut, target[0], target[1], models.VgSwapStatusCancelled)
+		if err := tx.UpdateVgSwap(ctx, models.VgSwapStatusPending, swap); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (m *Module) handleCompleteSwapOut(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, completeSwapOut *vgtypes.EventCompleteSwapOut) error {
+	tx := m.db.Begin(ctx)
+	for _, target := range swapTargets(completeSwapOut.GlobalVirtualGroupFamilyId, completeSwapOut.GlobalVirtualGroupIds) {
+		swap := updateVgSwap(block, txHash, models.VgSwapTypeSwapOut, target[0], target[1], models.VgSwapStatusCompleted)
+		if err := tx.UpdateVgSwap(ctx, models.VgSwapStatusPending, swap); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		var err error
+		if target[0] != vgtypes.NoSpecifiedFamilyID {
+			err = swapPrimarySp(ctx, tx, block, txHash, target[0], completeSwapOut.StorageProviderId)
+		} else {
+			err = swapSecondarySp(ctx, tx, block, txHash, target[1], completeSwapOut.SrcStorageProviderId, completeSwapOut.StorageProviderId)
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (m *Module) handleReserveSwapIn(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, reserveSwapIn *vgtypes.EventReserveSwapIn) error {
+	familyId, gvgId := swapInTarget(reserveSwapIn.GlobalVirtualGroupFamilyId, reserveSwapIn.GlobalVirtualGroupId)
+
+	// the chain lets a new reservation override an expired one
+	expired := updateVgSwap(block, txHash, models.VgSwapTypeSwapIn, familyId, gvgId, models.VgSwapStatusCancelled)
+	swap := newVgSwap(block, txHash, models.VgSwapTypeSwapIn, familyId, gvgId, models.VgSwapStatusReserved)
+	swap.SpId = reserveSwapIn.TargetSpId
+	swap.SuccessorSpId = reserveSwapIn.StorageProviderId
+	swap.ExpirationTime = reserveSwapIn.ExpirationTime
+
+	tx := m.db.Begin(ctx)
+	if err := tx.UpdateVgSwap(ctx, models.VgSwapStatusReserved, expired); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.SaveVgSwap(ctx, swap); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Module) handleCancelSwapIn(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, cancelSwapIn *vgtypes.EventCancelSwapIn) error {
+	familyId, gvgId := swapInTarget(cancelSwapIn.GlobalVirtualGroupFamilyId, cancelSwapIn.GlobalVirtualGroupId)
+	swap := updateVgSwap(block, txHash, models.VgSwapTypeSwapIn, familyId, gvgId, models.VgSwapStatusCancelled)
+
+	return m.db.UpdateVgSwap(ctx, models.VgSwapStatusReserved, swap)
+}
+
+func (m *Module) handleCompleteSwapIn(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, completeSwapIn *vgtypes.EventCompleteSwapIn) error {
+	familyId, gvgId := swapInTarget(completeSwapIn.GlobalVirtualGroupFamilyId, completeSwapIn.GlobalVirtualGroupId)
+	swap := updateVgSwap(block, txHash, models.VgSwapTypeSwapIn, familyId, gvgId, models.VgSwapStatusCompleted)
+
+	tx := m.db.Begin(ctx)
+	if err := tx.UpdateVgSwap(ctx, models.VgSwapStatusReserved, swap); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var err error
+	if familyId != vgtypes.NoSpecifiedFamilyID {
+		err = swapPrimarySp(ctx, tx, block, txHash, familyId, completeSwapIn.StorageProviderId)
+	} else {
+		err = swapSecondarySp(ctx, tx, block, txHash, gvgId, completeSwapIn.TargetStorageProviderId, completeSwapIn.StorageProviderId)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// swapTargets splits a swap out the way the chain keys it: the whole family if one is specified, otherwise every gvg.
+// Each target is a (family id, gvg id) pair.
+func swapTargets(familyId uint32, gvgIds []uint32) [][2]uint32 {
+	if familyId != vgtypes.NoSpecifiedFamilyID {
+		return [][2]uint32{{familyId, 0}}
+	}
+	targets := make([][2]uint32, 0, len(gvgIds))
+	for _, gvgId := range gvgIds {
+		targets = append(targets, [2]uint32{0, gvgId})
+	}
+	return targets
+}
+
+// swapInTarget returns the (family id, gvg id) pair a swap in is keyed by
+func swapInTarget(familyId, gvgId uint32) (uint32, uint32) {
+	if familyId != vgtypes.NoSpecifiedFamilyID {
+		return familyId, 0
+	}
+	return 0, gvgId
+}
+
+func newVgSwap(block *tmctypes.ResultBlock, txHash common.Hash, swapType string, familyId, gvgId uint32, status string) *models.VgSwap {
+	return &models.VgSwap{
+		SwapType:                   swapType,
+		GlobalVirtualGroupFamilyId: familyId,
+		GlobalVirtualGroupId:       gvgId,
+		Status:                     status,
+
+		CreateAt:     block.Block.Height,
+		CreateTxHash: txHash,
+		CreateTime:   block.Block.Time.UTC().Unix(),
+		UpdateAt:     block.Block.Height,
+		UpdateTxHash: txHash,
+		UpdateTime:   block.Block.Time.UTC().Unix(),
+	}
+}
+
+func updateVgSwap(block *tmctypes.ResultBlock, txHash common.Hash, swapType string, familyId, gvgId uint32, status string) *models.VgSwap {
+	return &models.VgSwap{
+		SwapType:                   swapType,
+		GlobalVirtualGroupFamilyId: familyId,
+		GlobalVirtualGroupId:       gvgId,
+		Status:                     status,
+
+		UpdateAt:     block.Block.Height,
+		UpdateTxHash: txHash,
+		UpdateTime:   block.Block.Time.UTC().Unix(),
+	}
+}
+
+// swapPrimarySp hands the family and all of its gvgs over to the successor sp
+func swapPrimarySp(ctx context.Context, tx database.Database, block *tmctypes.ResultBlock, txHash common.Hash, familyId, successorSpId uint32) error {
+	vgf := &models.GlobalVirtualGroupFamily{
+		GlobalVirtualGroupFamilyId: familyId,
+		PrimarySpId:                successorSpId,
+
+		UpdateAt:     block.Block.Height,
+		UpdateTxHash: txHash,
+		UpdateTime:   block.Block.Time.UTC().Unix(),
+	}
+	if err := tx.UpdateVGF(ctx, vgf); err != nil {
+		return err
+	}
+
+	gvg := &models.GlobalVirtualGroup{
+		PrimarySpId: successorSpId,
+
+		UpdateAt:     block.Block.Height,
+		UpdateTxHash: txHash,
+		UpdateTime:   block.Block.Time.UTC().Unix(),
+	}
+	return tx.UpdateGVGsByFamily(ctx, familyId, gvg)
+}
+
+// swapSecondarySp replaces the secondary sp of a gvg with the successor sp
+func swapSecondarySp(ctx context.Context, tx database.Database, block *tmctypes.ResultBlock, txHash common.Hash, gvgId, spId, successorSpId uint32) error {
+	gvg, err := tx.GetGVG(ctx, gvgId)
+	if err != nil {
+		return err
+	}
+	if gvg == nil {
+		log.Warnw("gvg not found when completing swap", "gvg_id", gvgId)
+		return nil
+	}
+
+	secondarySpIds := make(common.Uint32Array, len(gvg.SecondarySpIds))
+	copy(secondarySpIds, gvg.SecondarySpIds)
+	for i := len(secondarySpIds) - 1; i >= 0; i-- {
+		if secondarySpIds[i] == spId {
+			secondarySpIds[i] = successorSpId
+			break
+		}
+	}
+
+	return tx.UpdateGVG(ctx, &models.GlobalVirtualGroup{
+		GlobalVirtualGroupId: gvgId,
+		SecondarySpIds:       secondarySpIds,
+
+		UpdateAt:     block.Block.Height,
+		UpdateTxHash: txHash,
+		UpdateTime:   block.Block.Time.UTC().Unix(),
+	})
+}
@@ -46,6 +46,10 @@ type Indexer interface {
 	// ExportEvents accepts a slice of transactions and get events in order to save in database.
 	ExportEvents(ctx context.Context, block *tmctypes.ResultBlock, events *tmctypes.ResultBlockResults) error
 
+	// ExportBlockEvents handles the events emitted at the end of the block, outside any transaction.
+	// An error is returned if any event handler fails.
+	ExportBlockEvents(ctx context.Context, block *tmctypes.ResultBlock, events *tmctypes.ResultBlockResults) error
+
 	// HandleGenesis accepts a GenesisDoc and calls all the registered genesis handlers
 	// in the order in which they have been registered.
 	HandleGenesis(genesisDoc *tmtypes.GenesisDoc, appState map[string]json.RawMessage) error
@@ -62,8 +66,9 @@ type Indexer interface {
 	// HandleEvent accepts the transaction and handles events contained inside the transaction.
 	HandleEvent(ctx context.Context, block *tmctypes.ResultBlock, txHash common.Hash, event sdk.Event) error
 
-	// ExportEpoch accepts a finalized block height and block hash then inside the database.
-	ExportEpoch(block *tmctypes.ResultBlock) error
+	// ExportEpoch accepts a finalized block height and block hash then inside the database, along with the
+	// writes buffered in ctx.
+	ExportEpoch(ctx context.Context, block *tmctypes.ResultBlock) error
 
 	// GetBlockRecordNum returns total number of blocks stored in database.
 	GetBlockRecordNum(ctx context.Context) int64
@@ -93,8 +98,34 @@ type Impl struct {
 	DB   database.Database
 }
 
-func (i *Impl) ExportEpoch(block *tmctypes.ResultBlock) error {
-	return nil
+// ExportEpoch records the block as fully processed in the sync ranges, and moves the epoch to the end of the
+// first gap-free sync range, in the transaction flushing the writes of the block buffered in ctx. The epoch is
+// the last fully indexed block: it stays below a height still being processed, or failed, by another worker.
+func (i *Impl) ExportEpoch(ctx context.Context, block *tmctypes.ResultBlock) error {
+	tx := i.DB.Begin(ctx)
+	err := tx.FlushWriteBuffer(ctx)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to flush block writes: %s", err)
+	}
+
+	err = tx.SaveProcessedHeight(ctx, uint64(block.Block.Height))
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to persist processed height: %s", err)
+	}
+
+	err = tx.SaveEpoch(ctx, &models.Epoch{
+		OneRowId:    true,
+		BlockHeight: block.Block.Height,
+		BlockHash:   common.BytesToHash(block.BlockID.Hash),
+		UpdateTime:  block.Block.Time.UTC().Unix(),
+	})
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to persist epoch: %s", err)
+	}
+	return tx.Commit()
 }
 
 func (i *Impl) HandleGenesis(genesisDoc *tmtypes.GenesisDoc, appState map[string]json.RawMessage) error {
@@ -214,7 +245,22 @@ func (i *Impl) Process(height uint64) error {
 		return err
 	}
 
-	err = i.ExportEventsByTxs(i.Ctx, block, txs)
+	// the writes of the event handlers are buffered and flushed in batches once the block is handled
+	ctx := database.WithWriteBuffer(i.Ctx)
+	err = i.ExportEventsByTxs(ctx, block, txs)
+	if err != nil {
+		return err
+	}
+
+	// the events of the end of the block follow the ones of its txs, like the chain emits them
+	err = i.ExportBlockEvents(ctx, block, blockResults)
+	if err != nil {
+		return err
+	}
+
+	// the epoch and the sync ranges are committed along with the buffered writes of the block, they only move
+	// once everything else is persisted
+	err = i.ExportEpoch(ctx, block)
 	if err != nil {
 		return err
 	}
@@ -315,7 +361,8 @@ func (i *Impl) ExportEvents(ctx context.Context, block *tmctypes.ResultBlock, bl
 	txsResults := blockResults.TxsResults
 
 	for _, tx := range txsResults {
-		for _, event := range tx.Events {
+		for index, event := range tx.Events {
+			ctx := database.WithEventPosition(ctx, database.EventPosition{Height: uint64(block.Block.Height), EventIndex: index})
 			if err := i.HandleEvent(ctx, block, common.Hash{}, sdk.Event(event)); err != nil {
 				return err
 			}
@@ -327,7 +374,8 @@ func (i *Impl) ExportEvents(ctx context.Context, block *tmctypes.ResultBlock, bl
 func (i *Impl) ExportEventsByTxs(ctx context.Context, block *tmctypes.ResultBlock, txs []*types.Tx) error {
 	for _, tx := range txs {
 		txHash := common.HexToHash(tx.TxHash)
-		for _, event := range tx.Events {
+		for index, event := range tx.Events {
+			ctx := database.WithEventPosition(ctx, database.EventPosition{Height: uint64(block.Block.Height), TxHash: txHash, EventIndex: index})
 			if err := i.HandleEvent(ctx, block, txHash, sdk.Event(event)); err != nil {
 				return err
 			}
@@ -336,10 +384,33 @@ func (i *Impl) ExportEventsByTxs(ctx context.Context, block *tmctypes.ResultBloc
 	return nil
 }
 
+// ExportBlockEvents handles the events emitted at the end of the block, like the challenges started or the stale
+// policies cleaned up by the EndBlocker of the chain. They are handled like the events of the txs, without the mode
+// attribute the chain adds to them and with no tx hash.
+func (i *Impl) ExportBlockEvents(ctx context.Context, block *tmctypes.ResultBlock, blockResults *tmctypes.ResultBlockResults) error {
+	if blockResults == nil {
+		return nil
+	}
+
+	for index, event := range blockResults.FinalizeBlockEvents {
+		ctx := database.WithEventPosition(ctx, database.EventPosition{Height: uint64(block.Block.Height), EventIndex: index})
+		if err := i.HandleEvent(ctx, block, common.Hash{}, types.WithoutModeAttribute(event)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Processed tells whether the current Indexer has already processed the given height of Block
 // An error is returned if the operation fails.
+// The block row is saved before the events of the block are handled, so the sync ranges, committed along with
+// the writes of the events, are looked up instead.
 func (i *Impl) Processed(ctx context.Context, height uint64) (bool, error) {
-	return i.DB.HasBlock(ctx, height)
+	gaps, err := i.DB.GetSyncGaps(ctx, height, height)
+	if err != nil {
+		return false, err
+	}
+	return len(gaps) == 0, nil
 }
 
 // GetBlockRecordNum returns total number of blocks stored in database.
@@ -37,6 +37,7 @@ type Worker struct {
 // NewWorker allows to create a new Worker implementation.
 func NewWorker(ctx *Context, queue types.HeightQueue, index int, concurrentSync bool) *Worker {
 	return &Worker{
+		ctx:            context.Background(),
 		index:          index,
 		codec:          ctx.EncodingConfig.Codec,
 		node:           ctx.Node,
@@ -102,7 +103,7 @@ func (w *Worker) Start(ctx context.Context) {
 // height and associated metadata and export it to a database if it does not exist yet. It returns an
 // error if any export process fails.
 func (w *Worker) ProcessIfNotExists(height uint64) error {
-	exists, err := w.indexer.Processed(w.ctx, height)
+	exists, err := w.processed(height)
 	if err != nil {
 		return fmt.Errorf("error while searching for block: %s", err)
 	}
@@ -115,6 +116,20 @@ func (w *Worker) ProcessIfNotExists(height uint64) error {
 	return w.Process(height)
 }
 
+// processed tells whether the block at the given height has already been exported, asking the epoch module
+// when it is registered, the indexer otherwise. A height is processed once all of its writes are committed,
+// along with its sync range, so that a block which failed partway is processed again. The writes of the block
+// are not committed in a single transaction: those committed before the failure are written again, which
+// the database keeps idempotent (see database.Impl.session).
+func (w *Worker) processed(height uint64) (bool, error) {
+	for _, module := range w.modules {
+		if epochModule, ok := module.(modules.EpochModule); ok {
+			return epochModule.IsProcessed(height)
+		}
+	}
+	return w.indexer.Processed(w.ctx, height)
+}
+
 // Process fetches  a block for a given height and associated metadata and export it to a database.
 // It returns an error if any export process fails.
 func (w *Worker) Process(height uint64) error {
@@ -51,3 +51,15 @@ func FindAttributeByKey(event abci.Event, attrKey string) (abci.EventAttribute,
 
 	return abci.EventAttribute{}, fmt.Errorf("no attribute with key %s found inside event with type %s", attrKey, event.Type)
 }
+
+// WithoutModeAttribute returns the event emitted at the beginning or at the end of a block without the mode attribute
+// the chain adds to it, so that it is parsed like the events of the transactions
+func WithoutModeAttribute(event abci.Event) sdk.Event {
+	attributes := make([]abci.EventAttribute, 0, len(event.Attributes))
+	for _, attr := range event.Attributes {
+		if attr.Key != "mode" {
+			attributes = append(attributes, attr)
+		}
+	}
+	return sdk.Event{Type: event.Type, Attributes: attributes}
+}